@@ -14,17 +14,24 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"log"
 	"os"
-	"vault/internal/awsx"
+	"time"
 	"vault/internal/config"
 	"vault/internal/db"
 	"vault/internal/firebasex"
 	"vault/internal/httpx"
+	"vault/internal/ingest"
 	"vault/internal/jwtx"
 	"vault/internal/models"
+	"vault/internal/passwords"
+	"vault/internal/scan"
+	"vault/internal/storage"
+	"vault/internal/uploads"
 )
 
 var cfg *config.Config
@@ -51,8 +58,20 @@ func Init() {
 		return
 	}
 
-	if err := awsx.InitS3(cfg.AttachmentBucket, cfg.AwsRegion); err != nil {
-		log.Fatal("Failed to initialize S3 client:", err)
+	if err := initStorage(); err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+		return
+	}
+
+	uploads.Init(cfg.UploadConfig)
+
+	if err := scan.Init(cfg.ScanConfig); err != nil {
+		log.Fatal("Failed to initialize scanning:", err)
+		return
+	}
+
+	if err := passwords.Init(cfg.BreachCorpusDir); err != nil {
+		log.Fatal("Failed to load breach corpus:", err)
 		return
 	}
 
@@ -61,9 +80,46 @@ func Init() {
 		&models.Note{},
 		&models.NoteShare{},
 		&models.Attachment{},
+		&models.AttachmentText{},
+		&models.Blob{},
+		&models.Tag{},
+		&models.SavedSearch{},
+		&models.NoteEmbedding{},
 	)
 }
 
+// initStorage sets up the configured storage. Local storage runs ingest in-process,
+// which S3 triggers and a schedule sweeps as a Lambda otherwise.
+func initStorage() error {
+	switch cfg.Storage {
+	case "s3":
+		s3, err := storage.NewS3(cfg.AttachmentBucket, cfg.AwsRegion)
+		if err != nil {
+			return err
+		}
+		storage.Default = s3
+	case "local":
+		secret := cfg.StorageSecret
+		if secret == "" {
+			secret = cfg.JWTSecret
+		}
+		local, err := storage.NewLocal(cfg.StorageDir, cfg.StorageURL, []byte(secret))
+		if err != nil {
+			return err
+		}
+		local.Public = []string{"avatars/"}
+		local.OnUpload = func(ctx context.Context, key string) {
+			ingest.Process(ctx, local, key, local.URL)
+		}
+		storage.Default = local
+		go ingest.SweepEvery(context.Background(), local, time.Hour)
+		log.Printf("Keeping files in %s", cfg.StorageDir)
+	default:
+		return fmt.Errorf("unknown storage %q, expected s3 or local", cfg.Storage)
+	}
+	return nil
+}
+
 func main() {
 	Init()
 	r := httpx.Router(cfg.CORSOrigins)
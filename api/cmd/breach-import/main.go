@@ -0,0 +1,45 @@
+// Command breach-import builds the breached password corpus used by GET /me/password-health.
+//
+// It reads "SHA1:COUNT" lines (e.g. the ordered-by-hash SHA-1 dump of Have I Been Pwned)
+// from a file or stdin and splits them into k-anonymity range files:
+//
+//	breach-import -in pwned-passwords-sha1-ordered-by-hash.txt -out /var/lib/vault/breaches
+//
+// Point the API at the output directory with BREACH_CORPUS_DIR.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"vault/internal/passwords"
+)
+
+func main() {
+	in := flag.String("in", "-", "Input file of SHA1:COUNT lines, - for stdin")
+	out := flag.String("out", "", "Output corpus directory")
+	flag.Parse()
+
+	if *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("Failed to open input: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	stats, err := passwords.Import(r, *out)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	log.Printf("Imported %d hashes into %d ranges (%d lines skipped)", stats.Hashes, stats.Ranges, stats.Skipped)
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"log"
-	"vault/internal/awsx"
 	"vault/internal/config"
 	"vault/internal/db"
 	"vault/internal/httpx"
 	"vault/internal/ingest"
+	"vault/internal/scan"
+	"vault/internal/storage"
+	"vault/internal/uploads"
 )
 
 func main() {
@@ -23,10 +25,18 @@ func main() {
 		log.Fatalf("DB connection failed: %v", err)
 	}
 
-	if err := awsx.InitS3(cfg.AttachmentBucket, cfg.AwsRegion); err != nil {
+	uploads.Init(cfg.UploadConfig)
+
+	if err := scan.Init(cfg.ScanConfig); err != nil {
+		log.Fatalf("Scanner setup failed: %v", err)
+	}
+
+	s3, err := storage.NewS3(cfg.AttachmentBucket, cfg.AwsRegion)
+	if err != nil {
 		log.Fatal("Failed to initialize S3 client:", err)
 		return
 	}
+	storage.Default = s3
 
 	if err := ingest.Handle(); err != nil {
 		log.Fatalf("Failed to handle S3 event: %v", err)
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/client"
+
+	"github.com/google/uuid"
+)
+
+var attachCommands = map[string]command{
+	"ls":     listAttachments,
+	"put":    putAttachment,
+	"resume": resumeAttachment,
+	"get":    getAttachment,
+	"rm":     removeAttachment,
+}
+
+func listAttachments(app *cli, args []string) error {
+	flags := flag.NewFlagSet("attach ls", flag.ExitOnError)
+	note := flags.String("note", "", "Only attachments of this note")
+	mimeType := flags.String("type", "", "Only attachments of this MIME type")
+	all := flags.Bool("all", false, "Fetch every page")
+	limit := flags.Int("limit", 20, "Attachments per page")
+	cursor := flags.String("cursor", "", "Cursor of the page to start from")
+	parse(flags, args)
+
+	query := client.AttachmentsQuery{Limit: *limit, MimeType: *mimeType}
+	if *note != "" {
+		noteID, err := uuid.Parse(*note)
+		if err != nil {
+			return fmt.Errorf("invalid note ID: %w", err)
+		}
+		query.NoteID = noteID
+	}
+
+	attachments, err := collect(*all, *cursor, func(cursor string) ([]models.AttachmentRef, string, error) {
+		query.Cursor = cursor
+		resp, err := app.client.GetAttachments(app.ctx, query)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Attachments, resp.NextCursor, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(attachments))
+	for i, a := range attachments {
+		rows[i] = []string{
+			a.AttachmentOut.ID.String(),
+			truncate(a.Filename, 40),
+			a.MimeType,
+			formatSize(a.Size),
+			truncate(a.Title, 30),
+			a.NoteOut.ID.String(),
+		}
+	}
+
+	if attachments == nil {
+		attachments = []models.AttachmentRef{}
+	}
+	return app.out.table(attachments, []string{"ID", "FILENAME", "TYPE", "SIZE", "NOTE", "NOTE ID"}, rows)
+}
+
+// multipartSize is the size above which files are uploaded in parts, within the server's default
+// limit of files uploaded in one go, ATTACHMENT_MAX_SIZE.
+const multipartSize = 8 * 1024 * 1024
+
+// putAttachment uploads a file straight to storage through a presigned form, or in parts when it's large,
+// then confirms the upload so the attachment is ready, or rejected, by the time it returns.
+func putAttachment(app *cli, args []string) error {
+	flags := flag.NewFlagSet("attach put", flag.ExitOnError)
+	contentType := flags.String("type", "", "MIME type, detected when omitted")
+	parallel := flags.Int("parallel", 4, "Parts of large files uploaded at a time")
+	args = parse(flags, args)
+
+	if err := want(args, 2, "attach put <noteId> <file> [-type mime/type] [-parallel n]"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	file, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if *contentType == "" {
+		if *contentType, err = detectContentType(file); err != nil {
+			return err
+		}
+	}
+
+	request := models.PresignUploadRequest{
+		Filename:    filepath.Base(args[1]),
+		ContentType: *contentType,
+		Size:        info.Size(),
+	}
+	rows := [][2]string{
+		{"Uploaded", filepath.Base(args[1])},
+		{"Size", formatSize(info.Size())},
+		{"Type", *contentType},
+	}
+
+	if info.Size() > multipartSize {
+		upload, err := app.client.CreateMultipartUpload(app.ctx, noteID, request)
+		if err != nil {
+			return err
+		}
+		if err := app.client.UploadParts(app.ctx, noteID, upload.ID, file, info.Size(), *parallel); err != nil {
+			return fmt.Errorf("%w\nresume with: vault attach resume %s %s %s", err, noteID, upload.ID, args[1])
+		}
+		return completeAttachment(app, noteID, upload.ID, upload, append(rows, [2]string{"Key", upload.Key}))
+	}
+
+	presigned, err := app.client.GetUploadURL(app.ctx, noteID, request)
+	if err != nil {
+		return err
+	}
+
+	if err := app.client.Upload(app.ctx, presigned, filepath.Base(args[1]), file, info.Size()); err != nil {
+		return err
+	}
+
+	rows = append(rows, [2]string{"Key", presigned.Key})
+	if presigned.ID == nil {
+		return app.out.details(presigned, rows, "")
+	}
+	return completeAttachment(app, noteID, *presigned.ID, presigned, rows)
+}
+
+// resumeAttachment uploads the parts of a large file that didn't make it when attach put was interrupted.
+func resumeAttachment(app *cli, args []string) error {
+	flags := flag.NewFlagSet("attach resume", flag.ExitOnError)
+	parallel := flags.Int("parallel", 4, "Parts uploaded at a time")
+	args = parse(flags, args)
+
+	if err := want(args, 3, "attach resume <noteId> <attachmentId> <file> [-parallel n]"); err != nil {
+		return err
+	}
+
+	noteID, attachmentID, err := parseAttachment(args)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(args[2])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := app.client.UploadParts(app.ctx, noteID, attachmentID, file, info.Size(), *parallel); err != nil {
+		return err
+	}
+
+	rows := [][2]string{
+		{"Uploaded", filepath.Base(args[2])},
+		{"Size", formatSize(info.Size())},
+	}
+	return completeAttachment(app, noteID, attachmentID, nil, rows)
+}
+
+// checkTimeout is how long an upload is waited on to be checked, about as long as ingest takes at most.
+const checkTimeout = 2 * time.Minute
+
+// completeAttachment confirms an upload, waits for it to be checked and shows it with its status, failing when it was rejected.
+func completeAttachment(app *cli, noteID uuid.UUID, attachmentID uuid.UUID, out any, rows [][2]string) error {
+	completed, err := app.client.CompleteUpload(app.ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	// ingest checks the file in its own time
+	for wait := time.Now().Add(checkTimeout); completed.Status == models.ScanningUpload && time.Now().Before(wait); {
+		time.Sleep(time.Second)
+		if completed, err = app.client.GetDownloadURL(app.ctx, noteID, attachmentID); err != nil {
+			return err
+		}
+	}
+	if completed.Status == models.RejectedUpload {
+		return fmt.Errorf("upload rejected: %s", completed.Reason)
+	}
+
+	if out == nil {
+		out = completed
+	}
+	rows = append([][2]string{{"ID", attachmentID.String()}}, rows...)
+	rows = append(rows, [2]string{"Status", string(completed.Status)})
+	return app.out.details(out, rows, "")
+}
+
+func getAttachment(app *cli, args []string) error {
+	flags := flag.NewFlagSet("attach get", flag.ExitOnError)
+	out := flags.String("out", "", "Where to write the file, defaults to its name in the current directory")
+	args = parse(flags, args)
+
+	if err := want(args, 2, "attach get <noteId> <attachmentId> [-out path]"); err != nil {
+		return err
+	}
+
+	noteID, attachmentID, err := parseAttachment(args)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		note, err := app.client.GetNote(app.ctx, noteID)
+		if err != nil {
+			return err
+		}
+		for _, a := range note.Attachments {
+			if a.ID == attachmentID {
+				*out = filepath.Base(a.Filename)
+			}
+		}
+		if *out == "" {
+			return fmt.Errorf("note has no attachment %s", attachmentID)
+		}
+	}
+
+	presigned, err := app.client.GetDownloadURL(app.ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if presigned.URL == "" {
+		return fmt.Errorf("attachment is %s, not ready to download", presigned.Status)
+	}
+
+	resp, err := http.Get(presigned.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	// attachments from before hashing have none
+	if sum := hex.EncodeToString(hash.Sum(nil)); err == nil && presigned.SHA256 != "" && sum != presigned.SHA256 {
+		err = fmt.Errorf("download is corrupt, its SHA-256 is %s, expected %s", sum, presigned.SHA256)
+	}
+	if err != nil {
+		_ = os.Remove(*out)
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %s (%s)\n", *out, formatSize(n))
+	return nil
+}
+
+func removeAttachment(app *cli, args []string) error {
+	if err := want(args, 2, "attach rm <noteId> <attachmentId>"); err != nil {
+		return err
+	}
+
+	noteID, attachmentID, err := parseAttachment(args)
+	if err != nil {
+		return err
+	}
+
+	return app.client.DeleteAttachment(app.ctx, noteID, attachmentID)
+}
+
+func parseAttachment(args []string) (uuid.UUID, uuid.UUID, error) {
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	attachmentID, err := uuid.Parse(args[1])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid attachment ID: %w", err)
+	}
+
+	return noteID, attachmentID, nil
+}
+
+// detectContentType guesses the MIME type from the file extension, falling back to sniffing its first bytes.
+func detectContentType(file *os.File) (string, error) {
+	if t := mime.TypeByExtension(filepath.Ext(file.Name())); t != "" {
+		return t, nil
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"vault/internal/models"
+	"vault/pkg/client"
+)
+
+// login stores a session for later commands. The API signs users in through Firebase,
+// so a session is either exchanged for a Firebase ID token or taken from the web app.
+func login(app *cli, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	refresh := flags.String("refresh", "", "Refresh token of an existing session")
+	token := flags.String("token", "", "Access token of an existing session, it won't be refreshed")
+	firebase := flags.String("firebase", "", "Firebase ID token to sign in with")
+	parse(flags, args)
+
+	c := client.New(app.addr)
+
+	switch {
+	case *firebase != "":
+		if _, err := c.SignInWithFirebase(app.ctx, models.FirebaseSignInRequest{IDToken: *firebase}); err != nil {
+			return err
+		}
+	case *token != "":
+		c = client.New(app.addr, client.WithToken(*token))
+	default:
+		if *refresh == "" {
+			fmt.Fprint(os.Stderr, "Refresh token: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return err
+			}
+			*refresh = strings.TrimSpace(line)
+		}
+		c = client.New(app.addr, client.WithSession(models.Session{Refresh: *refresh}))
+		if err := c.Refresh(app.ctx); err != nil {
+			return err
+		}
+	}
+
+	user, err := c.Me(app.ctx)
+	if err != nil {
+		return err
+	}
+
+	app.creds.Addr, app.creds.Session = app.addr, c.Session()
+	if err := app.creds.save(); err != nil {
+		return fmt.Errorf("failed to store credentials: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Logged in to %s as %s\n", app.addr, user.Username)
+	return nil
+}
+
+func logout(app *cli, _ []string) error {
+	return app.creds.clear()
+}
+
+func whoami(app *cli, _ []string) error {
+	user, err := app.client.Me(app.ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.out.details(
+		user,
+		[][2]string{
+			{"ID", user.ID.String()},
+			{"Username", user.Username},
+			{"Email", user.Email},
+			{"Notes", fmt.Sprint(user.NotesCount)},
+			{"Attachments", fmt.Sprint(user.AttachmentsCount)},
+		},
+		"",
+	)
+}
+
+// showUsage prints the storage taken against the quota, then the largest types and notes.
+func showUsage(app *cli, args []string) error {
+	flags := flag.NewFlagSet("usage", flag.ExitOnError)
+	limit := flags.Int("limit", 5, "How many of the largest notes to show")
+	parse(flags, args)
+
+	usage, err := app.client.Usage(app.ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	tw := tabwriter.NewWriter(&body, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tATTACHMENTS\tSIZE")
+	for _, t := range usage.ByType {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", t.MimeType, t.Attachments, formatSize(t.Size))
+	}
+	fmt.Fprintln(tw, "\t\t")
+	fmt.Fprintln(tw, "NOTE\tATTACHMENTS\tSIZE")
+	for _, n := range usage.ByNote {
+		title := n.Title
+		if n.Deleted {
+			title += " (deleted)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", title, n.Attachments, formatSize(n.Size))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	return app.out.details(
+		usage,
+		[][2]string{
+			{"Plan", string(usage.Plan)},
+			{"Used", fmt.Sprintf("%s of %s", formatSize(usage.Used), formatSize(usage.Quota))},
+			{"Uploading", formatSize(usage.Pending)},
+			{"Available", formatSize(usage.Available)},
+			{"Attachments", fmt.Sprint(usage.Attachments)},
+		},
+		strings.TrimRight(body.String(), "\n"),
+	)
+}
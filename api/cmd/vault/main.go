@@ -0,0 +1,231 @@
+// Command vault is a command-line client for the Vault API.
+//
+// Log in once and the session is kept in the user's config directory:
+//
+//	vault login -refresh <refresh token>
+//
+// Then work with notes, attachments and shares:
+//
+//	vault notes ls -q invoice
+//	vault notes new -title "Staging DB" -type login -field username=app -field password=...
+//	vault attach put <noteId> ./report.pdf
+//	vault share add <noteId> jane@mail.com -permission read
+//
+// Every command prints a table by default, or JSON with -o json.
+//
+// Secrets can also be injected into other programs. An env template lists variables
+// whose values reference note fields:
+//
+//	DATABASE_PASSWORD=vault://123e4567-e89b-12d3-a456-426614174000/password
+//	DATABASE_URL=postgres://app:vault://123e4567-e89b-12d3-a456-426614174000/password@db:5432/app
+//
+// Print the resolved template as a dotenv file, or run a command with the secrets
+// in its environment, without writing them anywhere:
+//
+//	vault env -f .env.vault
+//	vault run -f .env.vault -- ./server
+//
+// The API address is read from -addr, VAULT_ADDR or the stored credentials (default http://localhost:8080),
+// and VAULT_TOKEN, when set, takes precedence over the stored session.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"vault/internal/models"
+	"vault/pkg/client"
+)
+
+const defaultAddr = "http://localhost:8080"
+
+// cli holds what every command needs: the API client, stored credentials and the output format.
+type cli struct {
+	ctx    context.Context
+	addr   string
+	creds  *credentials
+	client *client.Client
+	out    printer
+}
+
+type command func(app *cli, args []string) error
+
+var commands = map[string]command{
+	"login":  login,
+	"logout": logout,
+	"whoami": whoami,
+	"usage":  showUsage,
+	"notes":  group("notes", notesCommands),
+	"attach": group("attach", attachCommands),
+	"share":  group("share", shareCommands),
+	"env":    env,
+	"run":    run,
+}
+
+func main() {
+	flags := flag.NewFlagSet("vault", flag.ExitOnError)
+	flags.Usage = usage
+	addr := flags.String("addr", "", "API address")
+	output := flags.String("o", tableOutput, "Output format: table or json")
+	_ = flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if *output != tableOutput && *output != jsonOutput {
+		fail(fmt.Errorf("unknown output format %q", *output))
+	}
+
+	app, err := newCLI(*addr, *output)
+	if err != nil {
+		fail(err)
+	}
+
+	if err := cmd(app, args[1:]); err != nil {
+		fail(err)
+	}
+}
+
+func newCLI(addr string, output string) (*cli, error) {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	app := &cli{
+		ctx:   context.Background(),
+		addr:  firstOf(addr, os.Getenv("VAULT_ADDR"), creds.Addr, defaultAddr),
+		creds: creds,
+		out:   printer{w: os.Stdout, format: output},
+	}
+
+	session := creds.Session
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		session = models.Session{Token: token}
+	}
+
+	app.client = client.New(
+		app.addr,
+		client.WithSession(session),
+		client.OnSession(func(s models.Session) {
+			// keep refreshed tokens for the next run
+			if os.Getenv("VAULT_TOKEN") == "" {
+				app.creds.Addr, app.creds.Session = app.addr, s
+				_ = app.creds.save()
+			}
+		}),
+	)
+	return app, nil
+}
+
+// group dispatches to a subcommand, e.g. "notes ls".
+func group(name string, subcommands map[string]command) command {
+	return func(app *cli, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: vault %s <%s>", name, keys(subcommands))
+		}
+		cmd, ok := subcommands[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown command %q, expected one of <%s>", name+" "+args[0], keys(subcommands))
+		}
+		return cmd(app, args[1:])
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: vault [-addr url] [-o table|json] <command> [args]
+
+Account:
+  login [-refresh token | -token token | -firebase idToken]
+  logout
+  whoami
+  usage [-limit n]                   storage used against the quota, by type and largest notes
+
+Notes:
+  notes ls [-q query] [-archived] [-deleted] [-shared] [-all] [-limit n] [-cursor c]
+  notes show <noteId> [-reveal]
+  notes new [-title t] [-type note|login] [-field key=value ...] [-tag t ...] [-content c]   opens $EDITOR without -content
+  notes edit <noteId> [-title t] [-field key=value ...] [-tag t ...]                       opens $EDITOR on the content
+  notes rm <noteId> [-hard]
+  notes restore <noteId>
+
+Attachments:
+  attach ls [-note noteId] [-type mime/type] [-all] [-limit n] [-cursor c]
+  attach put <noteId> <file> [-type mime/type] [-parallel n]   large files go in parts
+  attach resume <noteId> <attachmentId> <file> [-parallel n]   finish an interrupted upload of a large file
+  attach get <noteId> <attachmentId> [-out path]
+  attach rm <noteId> <attachmentId>
+
+Sharing:
+  share add <noteId> <user> [-permission read|write] [-expires 2025-12-31T00:00:00Z]
+  share ls <noteId>
+  share revoke <noteId> <userId>
+
+Secrets:
+  env [-f template]                  print the resolved template as a dotenv file
+  run [-f template] -- command ...   run a command with the resolved secrets in its environment
+
+Environment:
+  VAULT_ADDR         API address (default `+defaultAddr+`)
+  VAULT_TOKEN        access token, overrides the stored session
+  VAULT_CONFIG_DIR   where credentials are stored (default: the user's config directory)
+  EDITOR             editor for note content`)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "vault:", err)
+	os.Exit(1)
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parse parses flags that may appear before, between or after positional arguments,
+// e.g. "share add <noteId> <user> -permission write", and returns the positional ones.
+func parse(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// want checks the number of positional arguments.
+func want(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: vault %s", usage)
+	}
+	return nil
+}
+
+func keys(m map[string]command) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
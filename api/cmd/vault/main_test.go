@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Interleaved flags", func(t *testing.T) {
+		flags := flag.NewFlagSet("share add", flag.ContinueOnError)
+		permission := flags.String("permission", "read", "")
+
+		args := parse(flags, []string{"note", "-permission", "write", "jane"})
+		assert.Equal(t, []string{"note", "jane"}, args)
+		assert.Equal(t, "write", *permission)
+	})
+
+	t.Run("Repeated fields", func(t *testing.T) {
+		fields := fieldsFlag{}
+		flags := flag.NewFlagSet("notes new", flag.ContinueOnError)
+		flags.Var(fields, "field", "")
+
+		parse(flags, []string{"-field", "username=app", "-field", "password=a=b"})
+		assert.Equal(t, fieldsFlag{"username": "app", "password": "a=b"}, fields)
+		assert.Error(t, fields.Set("novalue"))
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("VAULT_CONFIG_DIR", dir)
+
+	t.Run("Missing file", func(t *testing.T) {
+		creds, err := loadCredentials()
+		require.NoError(t, err)
+		assert.Empty(t, creds.Addr)
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		creds := &credentials{Addr: "https://vault.example.com", Session: models.Session{Token: "t", Refresh: "r"}}
+		require.NoError(t, creds.save())
+
+		info, err := os.Stat(filepath.Join(dir, "credentials.json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		loaded, err := loadCredentials()
+		require.NoError(t, err)
+		assert.Equal(t, creds, loaded)
+
+		require.NoError(t, loaded.clear())
+		require.NoError(t, loaded.clear())
+	})
+}
+
+func TestPrinter(t *testing.T) {
+	var buf bytes.Buffer
+
+	t.Run("Table", func(t *testing.T) {
+		buf.Reset()
+		p := printer{w: &buf, format: tableOutput}
+		require.NoError(t, p.table(nil, []string{"ID", "TITLE"}, [][]string{{"1", "First"}, {"22", "Second"}}))
+		assert.Equal(t, "ID  TITLE\n1   First\n22  Second\n", buf.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf.Reset()
+		p := printer{w: &buf, format: jsonOutput}
+		require.NoError(t, p.table([]string{"a"}, []string{"ID"}, nil))
+		assert.Equal(t, "[\n  \"a\"\n]\n", buf.String())
+	})
+
+	t.Run("Helpers", func(t *testing.T) {
+		assert.Equal(t, "512 B", formatSize(512))
+		assert.Equal(t, "1.5 KiB", formatSize(1536))
+		assert.Equal(t, "abc…", truncate("abcdef", 4))
+		assert.Equal(t, "a b", truncate("a\n  b", 10))
+	})
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"vault/internal/models"
+	"vault/pkg/client"
+
+	"github.com/google/uuid"
+)
+
+var notesCommands = map[string]command{
+	"ls":      listNotes,
+	"show":    showNote,
+	"new":     newNote,
+	"edit":    editNote,
+	"rm":      removeNote,
+	"restore": restoreNote,
+}
+
+// fieldsFlag collects repeated -field key=value flags.
+type fieldsFlag map[string]string
+
+func (f fieldsFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f fieldsFlag) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value")
+	}
+	f[key] = value
+	return nil
+}
+
+// listFlag collects repeated flags, e.g. -tag infra -tag aws.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func listNotes(app *cli, args []string) error {
+	flags := flag.NewFlagSet("notes ls", flag.ExitOnError)
+	q := flags.String("q", "", "Search query, e.g. tag:infra has:attachment aws")
+	archived := flags.Bool("archived", false, "Only archived notes")
+	deleted := flags.Bool("deleted", false, "Notes in the bin")
+	shared := flags.Bool("shared", false, "Notes shared with me")
+	all := flags.Bool("all", false, "Fetch every page")
+	limit := flags.Int("limit", 20, "Notes per page")
+	cursor := flags.String("cursor", "", "Cursor of the page to start from")
+	sort := flags.String("sort", "", "Order: newest, oldest, updated, title or relevance")
+	parse(flags, args)
+
+	var (
+		notes []models.NoteOut
+		err   error
+	)
+
+	switch {
+	case *deleted:
+		notes, err = collect(*all, *cursor, func(cursor string) ([]models.NoteOut, string, error) {
+			resp, err := app.client.GetDeletedNotes(app.ctx, client.PageQuery{Cursor: cursor, Limit: *limit, Sort: *sort, SkipCount: true})
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Notes, resp.NextCursor, nil
+		})
+	case *shared:
+		notes, err = collect(*all, *cursor, func(cursor string) ([]models.NoteOut, string, error) {
+			resp, err := app.client.SharedWithMe(app.ctx, client.PageQuery{Cursor: cursor, Limit: *limit, Sort: *sort, SkipCount: true})
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Notes, resp.NextCursor, nil
+		})
+	default:
+		query := client.NotesQuery{Limit: *limit, Q: *q, Sort: *sort, SkipCount: true}
+		if *archived {
+			query.Archived = archived
+		}
+		notes, err = collect(*all, *cursor, func(cursor string) ([]models.NoteOut, string, error) {
+			query.Cursor = cursor
+			resp, err := app.client.GetNotes(app.ctx, query)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Notes, resp.NextCursor, nil
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(notes))
+	for i, n := range notes {
+		rows[i] = []string{
+			n.ID.String(),
+			string(n.Type),
+			truncate(n.Title, 40),
+			n.Author.Username,
+			formatTime(n.UpdatedAt),
+			fmt.Sprint(len(n.Attachments)),
+		}
+	}
+
+	if notes == nil {
+		notes = []models.NoteOut{}
+	}
+	return app.out.table(notes, []string{"ID", "TYPE", "TITLE", "AUTHOR", "UPDATED", "FILES"}, rows)
+}
+
+func showNote(app *cli, args []string) error {
+	flags := flag.NewFlagSet("notes show", flag.ExitOnError)
+	reveal := flags.Bool("reveal", false, "Show passwords in plain text")
+	args = parse(flags, args)
+
+	if err := want(args, 1, "notes show <noteId> [-reveal]"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	note, err := app.client.GetNote(app.ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	pairs := [][2]string{
+		{"ID", note.ID.String()},
+		{"Title", note.Title},
+		{"Type", string(note.Type)},
+		{"Tags", strings.Join(note.Tags, ", ")},
+		{"Author", note.Author.Username},
+		{"Created", formatTime(note.CreatedAt)},
+		{"Updated", formatTime(note.UpdatedAt)},
+	}
+
+	names := make([]string, 0, len(note.Fields))
+	for name := range note.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := note.Fields[name]
+		if name == models.PasswordField && !*reveal {
+			value = "********"
+		}
+		pairs = append(pairs, [2]string{name, value})
+	}
+
+	for _, a := range note.Attachments {
+		pairs = append(pairs, [2]string{"Attachment", fmt.Sprintf("%s  %s (%s)", a.ID, a.Filename, formatSize(a.Size))})
+	}
+
+	return app.out.details(note, pairs, note.Content)
+}
+
+func newNote(app *cli, args []string) error {
+	fields := fieldsFlag{}
+	flags := flag.NewFlagSet("notes new", flag.ExitOnError)
+	title := flags.String("title", "", "Title")
+	noteType := flags.String("type", string(models.PlainNote), "Type: note or login")
+	content := flags.String("content", "", "Content, opens $EDITOR when omitted")
+	flags.Var(fields, "field", "Field as key=value, repeatable")
+	var tags listFlag
+	flags.Var(&tags, "tag", "Tag, repeatable")
+	parse(flags, args)
+
+	if *title == "" {
+		return fmt.Errorf("usage: vault notes new -title <title> [-type note|login] [-field key=value ...] [-tag t ...] [-content c]")
+	}
+
+	if *content == "" {
+		text, err := edit("")
+		if err != nil {
+			return err
+		}
+		*content = text
+	}
+
+	note, err := app.client.CreateNote(app.ctx, models.NoteIn{
+		Title:   *title,
+		Content: *content,
+		Type:    *noteType,
+		Fields:  fields,
+		Tags:    tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	return app.out.details(note, [][2]string{{"Created", note.ID.String()}}, "")
+}
+
+func editNote(app *cli, args []string) error {
+	fields := fieldsFlag{}
+	flags := flag.NewFlagSet("notes edit", flag.ExitOnError)
+	title := flags.String("title", "", "New title")
+	content := flags.String("content", "", "New content, opens $EDITOR when omitted and no other change is given")
+	flags.Var(fields, "field", "Field as key=value, repeatable, key= removes the field")
+	var tags listFlag
+	flags.Var(&tags, "tag", "Tag, repeatable, replaces all tags, -tag= removes them")
+	args = parse(flags, args)
+
+	if err := want(args, 1, "notes edit <noteId> [-title t] [-field key=value ...] [-tag t ...] [-content c]"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	note, err := app.client.GetNote(app.ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	in := models.NoteIn{
+		Title:   firstOf(*title, note.Title),
+		Content: firstOf(*content, note.Content),
+		Type:    string(note.Type),
+		Fields:  map[string]string{},
+	}
+
+	for k, v := range note.Fields {
+		in.Fields[k] = v
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(in.Fields, k)
+		} else {
+			in.Fields[k] = v
+		}
+	}
+
+	if len(tags) > 0 {
+		in.Tags = []string{}
+		for _, tag := range tags {
+			if tag != "" {
+				in.Tags = append(in.Tags, tag)
+			}
+		}
+	}
+
+	if *title == "" && *content == "" && len(fields) == 0 && len(tags) == 0 {
+		if in.Content, err = edit(note.Content); err != nil {
+			return err
+		}
+	}
+
+	updated, err := app.client.EditNote(app.ctx, noteID, in)
+	if err != nil {
+		return err
+	}
+
+	return app.out.details(updated, [][2]string{{"Updated", updated.ID.String()}}, "")
+}
+
+func removeNote(app *cli, args []string) error {
+	flags := flag.NewFlagSet("notes rm", flag.ExitOnError)
+	hard := flags.Bool("hard", false, "Delete for good instead of moving to the bin")
+	args = parse(flags, args)
+
+	if err := want(args, 1, "notes rm <noteId> [-hard]"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	return app.client.DeleteNote(app.ctx, noteID, *hard)
+}
+
+func restoreNote(app *cli, args []string) error {
+	if err := want(args, 1, "notes restore <noteId>"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	note, err := app.client.RestoreNote(app.ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	return app.out.details(note, [][2]string{{"Restored", note.ID.String()}}, "")
+}
+
+// collect fetches a single page, or every page from start on when all is set.
+// When there are more pages left, it tells how to get the next one.
+func collect[T any](all bool, start string, fetch func(cursor string) ([]T, string, error)) ([]T, error) {
+	var items []T
+	cursor := start
+
+	for {
+		batch, next, err := fetch(cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+
+		if next == "" {
+			return items, nil
+		}
+		if !all {
+			fmt.Fprintf(os.Stderr, "More results, continue with -cursor %s\n", next)
+			return items, nil
+		}
+		cursor = next
+	}
+}
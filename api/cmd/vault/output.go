@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+// printer renders command results as indented JSON or as aligned tables.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table prints rows under a header, unless JSON output is selected, in which case v is printed instead.
+func (p printer) table(v any, header []string, rows [][]string) error {
+	if p.format == jsonOutput {
+		return p.json(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// details prints key-value pairs followed by an optional body, unless JSON output is selected.
+func (p printer) details(v any, pairs [][2]string, body string) error {
+	if p.format == jsonOutput {
+		return p.json(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, pair := range pairs {
+		fmt.Fprintf(tw, "%s:\t%s\n", pair[0], pair[1])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if body != "" {
+		fmt.Fprintf(p.w, "\n%s\n", body)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"vault/internal/models"
+	"vault/internal/secretref"
+
+	"github.com/google/uuid"
+)
+
+// env resolves a template and prints it as a dotenv file to stdout.
+func env(app *cli, args []string) error {
+	flags := flag.NewFlagSet("env", flag.ExitOnError)
+	template := flags.String("f", ".env.vault", "Env template with vault:// references")
+	_ = flags.Parse(args)
+
+	entries, err := resolve(app, *template)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(secretref.FormatEnv(entries))
+	return nil
+}
+
+// run resolves a template and executes a command with the secrets added to its environment.
+// Secrets only ever live in the child's environment, nothing is written to disk.
+func run(app *cli, args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	template := flags.String("f", ".env.vault", "Env template with vault:// references")
+	_ = flags.Parse(args)
+
+	command := flags.Args()
+	if len(command) == 0 {
+		return fmt.Errorf("usage: vault run [-f template] -- command [args...]")
+	}
+
+	entries, err := resolve(app, *template)
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Env = append(os.Environ(), secretref.Environ(entries)...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	// forward signals so the child can shut down gracefully
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for s := range signals {
+			_ = child.Process.Signal(s)
+		}
+	}()
+
+	err = child.Wait()
+	signal.Stop(signals)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
+
+// resolve reads a template and replaces all of its references,
+// failing if any of them can't be resolved.
+func resolve(app *cli, path string) ([]secretref.Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := secretref.ParseEnv(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// each note is fetched once no matter how many of its fields are referenced
+	notes := make(map[uuid.UUID]*models.NoteOut)
+
+	resolved, errs := secretref.Expand(entries, func(ref secretref.Ref) (string, error) {
+		note, ok := notes[ref.NoteID]
+		if !ok {
+			fetched, err := app.client.GetNote(app.ctx, ref.NoteID)
+			if err != nil {
+				return "", err
+			}
+			note = fetched
+			notes[ref.NoteID] = note
+		}
+
+		value, ok := secretref.Field(*note, ref.Field)
+		if !ok {
+			return "", fmt.Errorf("note has no field %q", ref.Field)
+		}
+		return value, nil
+	})
+
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, "unresolved reference:", e)
+		}
+		return nil, fmt.Errorf("%d unresolved reference(s)", len(errs))
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+	"vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var shareCommands = map[string]command{
+	"add":    addShare,
+	"ls":     listShares,
+	"revoke": revokeShare,
+}
+
+func addShare(app *cli, args []string) error {
+	flags := flag.NewFlagSet("share add", flag.ExitOnError)
+	permission := flags.String("permission", string(models.ReadPermission), "Permission: read or write")
+	expires := flags.String("expires", "", "Expiry as RFC 3339, e.g. 2025-12-31T00:00:00Z")
+	args = parse(flags, args)
+
+	if err := want(args, 2, "share add <noteId> <user> [-permission read|write] [-expires time]"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	in := models.ShareToUserRequest{SharedWith: args[1], Permission: *permission}
+	if *expires != "" {
+		t, err := time.Parse(time.RFC3339, *expires)
+		if err != nil {
+			return fmt.Errorf("invalid expiry: %w", err)
+		}
+		in.Expires = &t
+	}
+
+	if err := app.client.ShareNote(app.ctx, noteID, in); err != nil {
+		return err
+	}
+
+	return listShares(app, args[:1])
+}
+
+func listShares(app *cli, args []string) error {
+	if err := want(args, 1, "share ls <noteId>"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	resp, err := app.client.GetNoteShares(app.ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(resp.Shares))
+	for i, s := range resp.Shares {
+		user, username, expires := "-", "-", "never"
+		if s.SharedWith != nil {
+			user, username = s.SharedWith.ID.String(), s.SharedWith.Username
+		}
+		if s.Expires != nil {
+			expires = formatTime(*s.Expires)
+		}
+		rows[i] = []string{user, username, s.Permission, expires}
+	}
+
+	return app.out.table(resp, []string{"USER ID", "USERNAME", "PERMISSION", "EXPIRES"}, rows)
+}
+
+func revokeShare(app *cli, args []string) error {
+	if err := want(args, 2, "share revoke <noteId> <userId>"); err != nil {
+		return err
+	}
+
+	noteID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	userID, err := uuid.Parse(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	return app.client.RevokeNoteShare(app.ctx, noteID, userID)
+}
@@ -76,27 +76,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/me": {
-            "get": {
+        "/generate/passphrase": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns the currently authenticated user's information",
+                "description": "Generates a diceware passphrase from the EFF large wordlist and returns its entropy estimate",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "generate"
+                ],
+                "summary": "Generate a passphrase",
+                "operationId": "generatePassphrase",
+                "parameters": [
+                    {
+                        "description": "Passphrase options",
+                        "name": "options",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/PassphraseRequest"
+                        }
+                    }
                 ],
-                "summary": "Get current user",
-                "operationId": "me",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/UserOut"
+                            "$ref": "#/definitions/GeneratedSecretOut"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "401": {
@@ -106,7 +125,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -114,14 +133,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/avatar": {
+        "/generate/password": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generates a presigned S3 URL for uploading user avatar",
+                "description": "Generates a random password using a cryptographically secure source and returns its entropy estimate",
                 "consumes": [
                     "application/json"
                 ],
@@ -129,18 +148,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "generate"
                 ],
-                "summary": "Get presigned URL for avatar upload",
-                "operationId": "presign-avatar",
+                "summary": "Generate a password",
+                "operationId": "generatePassword",
                 "parameters": [
                     {
-                        "description": "Upload request",
-                        "name": "input",
+                        "description": "Password options",
+                        "name": "options",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/PresignUploadRequest"
+                            "$ref": "#/definitions/PasswordRequest"
                         }
                     }
                 ],
@@ -148,11 +166,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PresignUploadResponse"
+                            "$ref": "#/definitions/GeneratedSecretOut"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -164,7 +182,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -172,64 +190,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes": {
+        "/me": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns paginated notes for the authenticated user with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the currently authenticated user's information",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
-                ],
-                "summary": "List user notes",
-                "operationId": "getNotes",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search query",
-                        "name": "q",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Filter by archived status",
-                        "name": "archived",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Filter by encrypted status",
-                        "name": "encrypted",
-                        "in": "query"
-                    }
+                    "auth"
                 ],
+                "summary": "Get current user",
+                "operationId": "me",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NotesResponse"
+                            "$ref": "#/definitions/UserOut"
                         }
                     },
                     "401": {
@@ -245,14 +226,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/me/avatar": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Creates a note for the authenticated user",
+                "description": "Generates a presigned S3 form for uploading user avatar of the declared image type and size.\nIngest crops the image square and resizes it without its metadata, then sets avatar_url to a URL of its own.",
                 "consumes": [
                     "application/json"
                 ],
@@ -260,30 +243,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Create a new note",
-                "operationId": "createNote",
+                "summary": "Get presigned form for avatar upload",
+                "operationId": "presign-avatar",
                 "parameters": [
                     {
-                        "description": "Note object",
-                        "name": "note",
+                        "description": "Upload request",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/NoteIn"
+                            "$ref": "#/definitions/PresignUploadRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NoteOut"
+                            "$ref": "#/definitions/PresignUploadResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -295,7 +278,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -303,71 +286,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/attachments": {
+        "/me/password-health": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns paginated attachments for the authenticated user with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Checks passwords stored in the user's login items for breaches, reuse and weak strength.\nBreach checks run against a locally loaded hash corpus, passwords never leave the server.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
-                ],
-                "summary": "List user attachments",
-                "operationId": "getAttachments",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Filter by deleted notes",
-                        "name": "deleted",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by MIME type",
-                        "name": "mime_type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by note ID",
-                        "name": "note_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Sort by creation date (asc/desc)",
-                        "name": "sort",
-                        "in": "query"
-                    }
+                    "auth"
                 ],
+                "summary": "Password health report",
+                "operationId": "getPasswordHealth",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/AttachmentResponse"
+                            "$ref": "#/definitions/PasswordHealthReport"
                         }
                     },
                     "401": {
@@ -385,38 +324,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/deleted": {
+        "/me/searches": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns paginated soft-deleted notes for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the user's saved searches, pinned ones first.\nWith counts=true each search is run to count the notes it currently matches, for sidebar badges.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "List deleted notes",
-                "operationId": "getDeletedNotes",
+                "summary": "List saved searches",
+                "operationId": "getSavedSearches",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
+                        "type": "boolean",
+                        "description": "Count the notes each search matches",
+                        "name": "counts",
                         "in": "query"
                     }
                 ],
@@ -424,7 +352,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NotesResponse"
+                            "$ref": "#/definitions/SavedSearchesResponse"
                         }
                     },
                     "401": {
@@ -440,16 +368,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/notes/shared-with-me": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns paginated notes that have been shared with the authenticated user",
+                "description": "Saves a search query under a name. The query is checked the same way as in note search.",
                 "consumes": [
                     "application/json"
                 ],
@@ -457,31 +383,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "List shared notes",
-                "operationId": "getSharedNotes",
+                "summary": "Save a search",
+                "operationId": "createSavedSearch",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Saved search",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SavedSearchIn"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NotesResponse"
+                            "$ref": "#/definitions/SavedSearchOut"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query or too many saved searches",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "401": {
@@ -499,27 +426,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{noteId}": {
+        "/me/searches/{searchId}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves a specific note owned by the authenticated user",
+                "description": "Retrieves a saved search along with the number of notes it currently matches",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "Get a single note",
-                "operationId": "getNote",
+                "summary": "Get a saved search",
+                "operationId": "getSavedSearch",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note UUID",
-                        "name": "noteId",
+                        "description": "Saved search UUID",
+                        "name": "searchId",
                         "in": "path",
                         "required": true
                     }
@@ -528,7 +455,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NoteOut"
+                            "$ref": "#/definitions/SavedSearchOut"
                         }
                     },
                     "400": {
@@ -563,7 +490,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Updates the note fields for the authenticated user",
+                "description": "Renames, pins or changes the query of a saved search",
                 "consumes": [
                     "application/json"
                 ],
@@ -571,25 +498,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "Edit a note",
-                "operationId": "editNote",
+                "summary": "Edit a saved search",
+                "operationId": "editSavedSearch",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
+                        "description": "Saved search UUID",
+                        "name": "searchId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Note fields",
-                        "name": "note",
+                        "description": "Saved search",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/NoteIn"
+                            "$ref": "#/definitions/SavedSearchIn"
                         }
                     }
                 ],
@@ -597,7 +524,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NoteOut"
+                            "$ref": "#/definitions/SavedSearchOut"
                         }
                     },
                     "400": {
@@ -632,29 +559,18 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes a note owned by the authenticated user",
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "Delete a note",
-                "operationId": "deleteNote",
+                "summary": "Delete a saved search",
+                "operationId": "deleteSavedSearch",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
+                        "description": "Saved search UUID",
+                        "name": "searchId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "Hard delete flag",
-                        "name": "hard",
-                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -688,47 +604,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{noteId}/attachments": {
-            "post": {
-                "description": "Generates a presigned URL for uploading an attachment to a specific note",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/me/searches/{searchId}/results": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs a saved search with its own scope and sort, a page at a time",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "notes"
+                    "searches"
                 ],
-                "summary": "Generate a presigned S3 upload URL",
-                "operationId": "getUploadURL",
+                "summary": "Run a saved search",
+                "operationId": "runSavedSearch",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
+                        "description": "Saved search UUID",
+                        "name": "searchId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Upload parameters",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/PresignUploadRequest"
-                        }
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PresignUploadResponse"
+                            "$ref": "#/definitions/SearchResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "The saved query is no longer valid",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -739,8 +662,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Saved search not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -748,46 +677,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{noteId}/attachments/{attachmentId}": {
+        "/me/usage": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generates a temporary URL for securely downloading a note's attachment.",
+                "description": "Tells how much storage the user's attachments take against their plan's quota,\nby MIME type and by note, largest first. Notes in the bin count until they're deleted for good.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Get presigned download URL for an attachment",
-                "operationId": "getDownloadURL",
+                "summary": "Storage usage",
+                "operationId": "getUsage",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Note ID (UUID)",
-                        "name": "noteId",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Notes per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Attachment ID (UUID)",
-                        "name": "attachmentId",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page of notes",
+                        "name": "page",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PresignDownloadResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
+                            "$ref": "#/definitions/UsageOut"
                         }
                     },
                     "401": {
@@ -796,53 +722,103 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/notes": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Returns paginated notes for the authenticated user with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "notes"
                 ],
-                "summary": "Delete an attachment",
-                "operationId": "deleteAttachment",
+                "summary": "List user notes",
+                "operationId": "getNotes",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
-                        "in": "path",
-                        "required": true
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, deprecated in favor of cursor",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Attachment ID",
-                        "name": "attachmentId",
-                        "in": "path",
-                        "required": true
+                        "description": "Search query, see searchNotes for the syntax, ranks results when set",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by archived status",
+                        "name": "archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by encrypted status",
+                        "name": "encrypted",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "updated",
+                            "title",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "Order of the notes, relevance when q has words and newest otherwise",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count the total, false skips it and returns -1",
+                        "name": "count",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/NotesResponse"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid query",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -853,40 +829,49 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/notes/{noteId}/restore": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Restores a soft-deleted note owned by the authenticated user",
+                "description": "Creates a note for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "notes"
                 ],
-                "summary": "Restore a deleted note",
-                "operationId": "restoreNote",
+                "summary": "Create a new note",
+                "operationId": "createNote",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
-                        "in": "path",
-                        "required": true
+                        "description": "Note object",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NoteIn"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/NoteOut"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -900,12 +885,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -915,14 +894,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{noteId}/share": {
+        "/notes/attachments": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns a list of users the note has been shared with",
+                "description": "Returns paginated attachments for the authenticated user with optional filtering",
                 "consumes": [
                     "application/json"
                 ],
@@ -932,26 +911,71 @@ const docTemplate = `{
                 "tags": [
                     "notes"
                 ],
-                "summary": "List note shares",
-                "operationId": "getNoteShares",
+                "summary": "List user attachments",
+                "operationId": "getAttachments",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
-                        "in": "path",
-                        "required": true
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, deprecated in favor of cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by deleted notes",
+                        "name": "deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by MIME type",
+                        "name": "mime_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by note ID",
+                        "name": "note_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort by creation date (asc/desc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count the total, false skips it and returns -1",
+                        "name": "count",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/NoteShareResponse"
+                            "$ref": "#/definitions/AttachmentResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid cursor",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -962,27 +986,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "500": {
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/notes/deleted": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Allows the authenticated user to share a note they own with another user, specifying read or write permissions.",
+                "description": "Returns paginated soft-deleted notes for the authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -992,44 +1012,70 @@ const docTemplate = `{
                 "tags": [
                     "notes"
                 ],
-                "summary": "Share note with user",
-                "operationId": "shareNoteToUser",
+                "summary": "List deleted notes",
+                "operationId": "getDeletedNotes",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID (UUID)",
-                        "name": "noteId",
-                        "in": "path",
-                        "required": true
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
-                        "description": "Sharing request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ShareToUserRequest"
-                        }
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, deprecated in favor of cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "updated",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Order of the notes, most recently deleted first by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count the total, false skips it and returns -1",
+                        "name": "count",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/NotesResponse"
+                        }
                     },
                     "400": {
-                        "description": "Bad request (invalid UUID, payload, or permission)",
+                        "description": "Invalid cursor or sort",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Note not found or not owned by user",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -1037,47 +1083,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{noteId}/shares/{userId}": {
-            "delete": {
+        "/notes/search": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Removes note sharing permissions for a specific user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Full-text search over the user's notes and the ones shared with them through live shares,\nbest matches first. Each result carries the user's permission on the note.\nSupports \"quoted phrases\", -exclusion, OR and prefix* terms, and operators that can be negated too:\ntag:name, has:attachment, mime:type/subtype or mime:type/*, is:archived|encrypted|shared, type:note|login,\nowner:me|username|email, before:YYYY-MM-DD, after:YYYY-MM-DD and created: or updated: with a day or a range like 2025-01-01..2025-06-30.\nInvalid operators are listed in the details of the 400 response, other words with a colon are searched as text.\nSnippets are HTML-escaped with matches wrapped in \u003cmark\u003e.\nWhen nothing matches, a suggestion built from words in the user's notes may be returned,\nand with fuzzy=true notes with similar titles or content are returned instead.\nWith mode=semantic notes are ranked by how close they are in meaning to the words of the query,\noperators still apply but quotes, exclusions and OR don't.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "notes"
                 ],
-                "summary": "Revoke note access",
-                "operationId": "revokeNoteShare",
+                "summary": "Search notes",
+                "operationId": "searchNotes",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Note ID",
-                        "name": "noteId",
-                        "in": "path",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
                         "required": true
                     },
                     {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by archived status",
+                        "name": "archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by encrypted status",
+                        "name": "encrypted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fall back to similar titles and content when nothing matches",
+                        "name": "fuzzy",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "mine",
+                            "shared"
+                        ],
                         "type": "string",
-                        "description": "User ID to revoke access from",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "default": "all",
+                        "description": "Own notes, notes shared with the user or both",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "relevance",
+                            "newest",
+                            "oldest",
+                            "updated",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "relevance",
+                        "description": "Order of results",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "keyword",
+                            "semantic"
+                        ],
+                        "type": "string",
+                        "default": "keyword",
+                        "description": "Match keywords or meaning",
+                        "name": "mode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SearchResponse"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid query",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -1088,14 +1196,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Server error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -1103,9 +1205,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/refresh": {
-            "post": {
-                "description": "Refreshes JWT access token using a refresh token",
+        "/notes/shared-with-me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns paginated notes that have been shared with the authenticated user through shares that haven't expired",
                 "consumes": [
                     "application/json"
                 ],
@@ -1113,33 +1220,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "notes"
                 ],
-                "summary": "Refresh access token",
-                "operationId": "refresh",
+                "summary": "List shared notes",
+                "operationId": "getSharedNotes",
                 "parameters": [
                     {
-                        "description": "Refresh token payload",
-                        "name": "refreshToken",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, deprecated in favor of cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "updated",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Order of the notes, most recently shared first by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count the total, false skips it and returns -1",
+                        "name": "count",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Session"
+                            "$ref": "#/definitions/NotesResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid cursor or sort",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -1158,329 +1292,2423 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "AttachmentOut": {
-            "type": "object",
-            "required": [
-                "id"
-            ],
-            "properties": {
-                "filename": {
-                    "type": "string",
-                    "example": "document.pdf"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                },
-                "mime_type": {
-                    "type": "string",
-                    "example": "application/pdf"
-                },
-                "size": {
-                    "type": "integer",
-                    "example": 123456
-                }
-            }
-        },
-        "AttachmentRef": {
-            "type": "object",
-            "required": [
-                "attachment",
-                "note"
-            ],
-            "properties": {
-                "attachment": {
-                    "$ref": "#/definitions/AttachmentOut"
-                },
-                "note": {
-                    "$ref": "#/definitions/NoteOut"
-                }
-            }
         },
-        "AttachmentResponse": {
-            "type": "object",
-            "required": [
-                "attachments",
-                "total"
-            ],
-            "properties": {
-                "attachments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/AttachmentRef"
+        "/notes/{noteId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
+                ],
+                "description": "Retrieves a specific note owned by the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get a single note",
+                "operationId": "getNote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note UUID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/NoteOut"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the note fields for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Edit a note",
+                "operationId": "editNote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note fields",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NoteIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/NoteOut"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a note owned by the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Delete a note",
+                "operationId": "deleteNote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Hard delete flag",
+                        "name": "hard",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/attachments": {
+            "post": {
+                "description": "Generates a presigned form for uploading an attachment to a specific note.\nStorage only accepts a file of the declared content type and size, which have to be within the configured limits\nand fit in the user's storage quota.\nThe attachment is stored under its ID, the file name is what it's downloaded as.\nIt's pending until the upload is confirmed or storage reports it, then listed once ready.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Generate a presigned S3 upload form",
+                "operationId": "getUploadURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Upload parameters",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PresignUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PresignUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or the quota would be exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/attachments/multipart": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a pending attachment for a file uploaded in parts, which may be larger than getUploadURL takes,\nup to ATTACHMENT_MULTIPART_MAX_SIZE, within the same types and quota.\nParts are uploaded to URLs from presignParts, in parallel and again after failures,\nthen the upload is finished with completeUpload. Uploads left unfinished are aborted after a while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Start uploading a large attachment in parts",
+                "operationId": "createMultipartUpload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Upload parameters",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PresignUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MultipartUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or the quota would be exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/attachments/{attachmentId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tells whether the attachment's upload is still pending, being scanned, ready or was rejected and why.\nReady attachments come with a temporary URL for securely downloading them under their file name.",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get an attachment's upload status and download URL",
+                "operationId": "getDownloadURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID (UUID)",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID (UUID)",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PresignDownloadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deleting an attachment being uploaded in parts aborts the upload and drops its parts.",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Delete an attachment",
+                "operationId": "deleteAttachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/attachments/{attachmentId}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirms the file was uploaded to the presigned URL. Multipart uploads are completed from their parts,\nwhich have to be all there. Answers like getDownloadURL: the attachment is scanning until the file\nhas been checked, then ready or rejected and why, which getDownloadURL tells.",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Confirm an attachment's upload",
+                "operationId": "completeUpload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID (UUID)",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID (UUID)",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PresignDownloadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Nothing or only some parts were uploaded yet",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/attachments/{attachmentId}/parts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the parts of a multipart upload that arrived, and how the file is split into parts,\nso an interrupted upload can resume with the rest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "List the uploaded parts",
+                "operationId": "getUploadedParts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UploadedPartsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "The attachment isn't being uploaded in parts",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Presigns URLs to PUT parts of a multipart upload to, by number. Uploading a part again replaces it.\nURLs expire, so it's best to ask for them as parts are about to be uploaded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get URLs to upload parts to",
+                "operationId": "presignParts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Parts to upload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PresignPartsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PresignPartsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid part numbers, or the attachment isn't being uploaded in parts",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/related": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists notes about the same things as the given one, most similar first,\namong the user's notes and the ones shared with them. The content of encrypted notes is not compared.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Related notes",
+                "operationId": "getRelatedNotes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note UUID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restores a soft-deleted note owned by the authenticated user",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Restore a deleted note",
+                "operationId": "restoreNote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/share": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a list of users the note has been shared with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "List note shares",
+                "operationId": "getNoteShares",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/NoteShareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Allows the authenticated user to share a note they own with another user, specifying read or write permissions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Share note with user",
+                "operationId": "shareNoteToUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID (UUID)",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sharing request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ShareToUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad request (invalid UUID, payload, or permission)",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{noteId}/shares/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes note sharing permissions for a specific user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Revoke note access",
+                "operationId": "revokeNoteShare",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID to revoke access from",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Refreshes JWT access token using a refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "operationId": "refresh",
+                "parameters": [
+                    {
+                        "description": "Refresh token payload",
+                        "name": "refreshToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Session"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists what changed since a sync token, oldest change first: notes, attachments, shares and tags\nthat were created or updated, and tombstones for deletions and revoked or expired shares.\nNotes moved to the bin count as deleted. Without a token, everything current is listed.\nWhile more is true, sync again with the returned token. Changes may come again in a later sync.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Sync changes",
+                "operationId": "getSync",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token of the last sync",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 500,
+                        "description": "Changes per response, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Applies notes created, edited or deleted offline, each on its own, then lists changes like GET /sync.\nAn edit is applied when the note is still at its base version and reported as a conflict,\nalong with the note as it is now, when it changed in the meantime.\nAttachments and shares aren't uploaded here, they go through their own endpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Upload offline edits and sync",
+                "operationId": "postSync",
+                "parameters": [
+                    {
+                        "description": "Token of the last sync and offline edits",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SyncRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "default": 500,
+                        "description": "Changes per response, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token or too many edits",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "AttachmentOut": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "document.pdf"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "sha256": {
+                    "description": "of the content, to check downloads against",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 123456
+                },
+                "thumbnail_url": {
+                    "description": "of images and some PDFs",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ThumbnailURLs"
+                        }
+                    ]
+                }
+            }
+        },
+        "AttachmentRef": {
+            "type": "object",
+            "required": [
+                "attachment",
+                "note"
+            ],
+            "properties": {
+                "attachment": {
+                    "$ref": "#/definitions/AttachmentOut"
+                },
+                "note": {
+                    "$ref": "#/definitions/NoteOut"
+                }
+            }
+        },
+        "AttachmentResponse": {
+            "type": "object",
+            "required": [
+                "attachments",
+                "total"
+            ],
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AttachmentRef"
+                    }
+                },
+                "next_cursor": {
+                    "description": "absent on the last page",
+                    "type": "string",
+                    "example": "eyJ0IjoiMjAyNi0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"
+                },
+                "total": {
+                    "description": "-1 when not counted",
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "InternalError"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "error": {
+                    "type": "string",
+                    "example": "An unexpected error occurred"
+                }
+            }
+        },
+        "FirebaseSignInRequest": {
+            "type": "object",
+            "required": [
+                "idToken"
+            ],
+            "properties": {
+                "idToken": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jane_doe"
+                }
+            }
+        },
+        "GeneratedSecretOut": {
+            "type": "object",
+            "required": [
+                "entropy",
+                "value"
+            ],
+            "properties": {
+                "entropy": {
+                    "type": "number",
+                    "example": 51.7
+                },
+                "value": {
+                    "type": "string",
+                    "example": "correct-horse-battery-staple"
+                }
+            }
+        },
+        "LoginOut": {
+            "type": "object",
+            "required": [
+                "session",
+                "user"
+            ],
+            "properties": {
+                "session": {
+                    "$ref": "#/definitions/Session"
+                },
+                "user": {
+                    "$ref": "#/definitions/UserOut"
+                }
+            }
+        },
+        "MultipartUploadResponse": {
+            "type": "object",
+            "required": [
+                "id",
+                "key",
+                "part_size",
+                "parts"
+            ],
+            "properties": {
+                "id": {
+                    "description": "of the attachment being uploaded",
+                    "type": "string",
+                    "example": "0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"
+                },
+                "part_size": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "parts": {
+                    "description": "numbered from 1",
+                    "type": "integer",
+                    "example": 13
+                }
+            }
+        },
+        "NoteIn": {
+            "type": "object",
+            "required": [
+                "content",
+                "title"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Notes from the meeting with the client."
+                },
+                "fields": {
+                    "description": "an edit leaves them as they are when omitted or null",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "replaces the note's tags, left as is when omitted or null",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "infra",
+                        "aws"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Meeting Notes"
+                },
+                "type": {
+                    "description": "\"note\" or \"login\", an edit leaves it as is when omitted",
+                    "type": "string",
+                    "example": "login"
+                }
+            }
+        },
+        "NoteOut": {
+            "type": "object",
+            "required": [
+                "author",
+                "created_at",
+                "id"
+            ],
+            "properties": {
+                "archived": {
+                    "type": "boolean"
+                },
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AttachmentOut"
+                    }
+                },
+                "author": {
+                    "$ref": "#/definitions/PublicUserOut"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "Notes from the meeting with the client."
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "fields": {
+                    "$ref": "#/definitions/models.NoteFields"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Share"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "infra",
+                        "aws"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Meeting Notes"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/NoteType"
+                        }
+                    ],
+                    "example": "note"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "NoteShareResponse": {
+            "type": "object",
+            "properties": {
+                "shared": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Share"
+                    }
+                }
+            }
+        },
+        "NoteType": {
+            "type": "string",
+            "enum": [
+                "note",
+                "login"
+            ],
+            "x-enum-varnames": [
+                "PlainNote",
+                "LoginNote"
+            ]
+        },
+        "NoteUsage": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "note_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 2097152
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Meeting Notes"
+                }
+            }
+        },
+        "NotesResponse": {
+            "type": "object",
+            "required": [
+                "notes",
+                "total"
+            ],
+            "properties": {
+                "next_cursor": {
+                    "description": "absent on the last page",
+                    "type": "string",
+                    "example": "eyJ0IjoiMjAyNi0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/NoteOut"
+                    }
+                },
+                "total": {
+                    "description": "-1 when not counted",
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "PartURL": {
+            "type": "object",
+            "required": [
+                "number",
+                "url"
+            ],
+            "properties": {
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bucket.s3.amazonaws.com/attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d?partNumber=1\u0026uploadId=abc"
+                }
+            }
+        },
+        "PassphraseRequest": {
+            "type": "object",
+            "properties": {
+                "capitalize": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "include_number": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "separator": {
+                    "type": "string",
+                    "example": "-"
+                },
+                "words": {
+                    "type": "integer",
+                    "example": 6
+                }
+            }
+        },
+        "PasswordHealthItem": {
+            "type": "object",
+            "required": [
+                "issues",
+                "note_id"
+            ],
+            "properties": {
+                "breach_count": {
+                    "type": "integer",
+                    "example": 1024
                 },
-                "total": {
-                    "type": "integer",
-                    "example": 10
-                }
-            }
-        },
-        "ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string",
-                    "example": "InternalError"
-                },
-                "error": {
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "breached",
+                        "reused"
+                    ]
+                },
+                "note_id": {
                     "type": "string",
-                    "example": "An unexpected error occurred"
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "reused_with": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "strength": {
+                    "description": "0 (very weak) to 4 (very strong)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Email"
+                }
+            }
+        },
+        "PasswordHealthReport": {
+            "type": "object",
+            "required": [
+                "breach_check",
+                "checked",
+                "items"
+            ],
+            "properties": {
+                "breach_check": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "breached": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "checked": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PasswordHealthItem"
+                    }
+                },
+                "reused": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "weak": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "PasswordRequest": {
+            "type": "object",
+            "properties": {
+                "digits": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "exclude_ambiguous": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "length": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "lowercase": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "pronounceable": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "symbols": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "uppercase": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "Permission": {
+            "type": "string",
+            "enum": [
+                "read",
+                "write",
+                "owner"
+            ],
+            "x-enum-varnames": [
+                "ReadPermission",
+                "WritePermission",
+                "OwnerPermission"
+            ]
+        },
+        "Plan": {
+            "type": "string",
+            "enum": [
+                "free",
+                "pro"
+            ],
+            "x-enum-varnames": [
+                "FreePlan",
+                "ProPlan"
+            ]
+        },
+        "PresignDownloadResponse": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "why the upload was rejected",
+                    "type": "string",
+                    "example": "file exceeds the 10 MB limit"
+                },
+                "sha256": {
+                    "description": "of the content, to check the download against",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/UploadStatus"
+                        }
+                    ],
+                    "example": "ready"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://s3.com/download?key=example.txt"
+                }
+            }
+        },
+        "PresignPartsRequest": {
+            "type": "object",
+            "required": [
+                "parts"
+            ],
+            "properties": {
+                "parts": {
+                    "description": "numbers of the parts to upload",
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "PresignPartsResponse": {
+            "type": "object",
+            "required": [
+                "parts"
+            ],
+            "properties": {
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PartURL"
+                    }
+                }
+            }
+        },
+        "PresignUploadRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "filename",
+                "size"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "text/plain"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "example.txt"
+                },
+                "size": {
+                    "description": "in bytes, the upload has to be exactly this size",
+                    "type": "integer",
+                    "example": 1024
+                }
+            }
+        },
+        "PresignUploadResponse": {
+            "type": "object",
+            "required": [
+                "fields",
+                "key",
+                "url"
+            ],
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "of the attachment being uploaded",
+                    "type": "string",
+                    "example": "0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bucket.s3.amazonaws.com/"
+                }
+            }
+        },
+        "PublicUserOut": {
+            "type": "object",
+            "required": [
+                "id",
+                "username"
+            ],
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "example": "https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000-1760832000000/512.jpg"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jane_doe"
+                }
+            }
+        },
+        "SavedSearchIn": {
+            "type": "object",
+            "required": [
+                "name",
+                "query"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Infra keys"
+                },
+                "pinned": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "query": {
+                    "type": "string",
+                    "example": "tag:infra type:login -is:archived"
+                },
+                "scope": {
+                    "description": "\"all\", \"mine\" or \"shared\"",
+                    "type": "string",
+                    "example": "all"
+                },
+                "sort": {
+                    "description": "\"relevance\", \"newest\", \"oldest\", \"updated\" or \"title\"",
+                    "type": "string",
+                    "example": "relevance"
+                }
+            }
+        },
+        "SavedSearchOut": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "name",
+                "pinned",
+                "query",
+                "scope",
+                "sort"
+            ],
+            "properties": {
+                "count": {
+                    "description": "number of matching notes, when asked for",
+                    "type": "integer",
+                    "example": 12
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Infra keys"
+                },
+                "pinned": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "query": {
+                    "type": "string",
+                    "example": "tag:infra type:login -is:archived"
+                },
+                "scope": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SearchScope"
+                        }
+                    ],
+                    "example": "all"
+                },
+                "sort": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SearchSort"
+                        }
+                    ],
+                    "example": "relevance"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "SavedSearchesResponse": {
+            "type": "object",
+            "required": [
+                "searches"
+            ],
+            "properties": {
+                "searches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SavedSearchOut"
+                    }
+                }
+            }
+        },
+        "SearchResponse": {
+            "type": "object",
+            "required": [
+                "results",
+                "total"
+            ],
+            "properties": {
+                "fuzzy": {
+                    "description": "results are similar, not exact matches",
+                    "type": "boolean",
+                    "example": false
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SearchResult"
+                    }
+                },
+                "suggestion": {
+                    "description": "did you mean",
+                    "type": "string",
+                    "example": "staging database"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "SearchResult": {
+            "type": "object",
+            "required": [
+                "note",
+                "permission",
+                "rank",
+                "snippet"
+            ],
+            "properties": {
+                "note": {
+                    "$ref": "#/definitions/NoteOut"
+                },
+                "permission": {
+                    "description": "\"owner\", \"read\" or \"write\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Permission"
+                        }
+                    ],
+                    "example": "owner"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.42
+                },
+                "snippet": {
+                    "description": "HTML-escaped, matches wrapped in \u003cmark\u003e",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SearchSnippet"
+                        }
+                    ]
+                }
+            }
+        },
+        "SearchScope": {
+            "type": "string",
+            "enum": [
+                "all",
+                "mine",
+                "shared"
+            ],
+            "x-enum-comments": {
+                "AllScope": "own notes and the ones shared with the user",
+                "MineScope": "own notes only",
+                "SharedScope": "notes shared with the user only"
+            },
+            "x-enum-varnames": [
+                "AllScope",
+                "MineScope",
+                "SharedScope"
+            ]
+        },
+        "SearchSnippet": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "credentials for the \u003cmark\u003estaging\u003c/mark\u003e cluster … rotated monthly"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "\u003cmark\u003eStaging\u003c/mark\u003e database"
                 }
             }
         },
-        "FirebaseSignInRequest": {
+        "SearchSort": {
+            "type": "string",
+            "enum": [
+                "relevance",
+                "newest",
+                "oldest",
+                "updated",
+                "title"
+            ],
+            "x-enum-comments": {
+                "RelevanceSort": "best matches first",
+                "UpdatedSort": "recently updated first"
+            },
+            "x-enum-varnames": [
+                "RelevanceSort",
+                "NewestSort",
+                "OldestSort",
+                "UpdatedSort",
+                "TitleSort"
+            ]
+        },
+        "Session": {
             "type": "object",
             "required": [
-                "idToken"
+                "refresh",
+                "token"
             ],
             "properties": {
-                "idToken": {
+                "refresh": {
                     "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "jane_doe"
+                "token": {
+                    "type": "string"
                 }
             }
         },
-        "LoginOut": {
+        "Share": {
             "type": "object",
             "required": [
-                "session",
-                "user"
+                "id",
+                "permission"
             ],
             "properties": {
-                "session": {
-                    "$ref": "#/definitions/Session"
+                "expires": {
+                    "type": "string",
+                    "example": "2024-12-31T23:59:59Z"
                 },
-                "user": {
-                    "$ref": "#/definitions/UserOut"
+                "id": {
+                    "type": "string"
+                },
+                "permission": {
+                    "type": "string",
+                    "example": "read"
+                },
+                "with": {
+                    "$ref": "#/definitions/PublicUserOut"
                 }
             }
         },
-        "NoteIn": {
+        "ShareToUserRequest": {
             "type": "object",
             "required": [
-                "content",
-                "title"
+                "permission"
             ],
             "properties": {
-                "content": {
+                "expires": {
                     "type": "string",
-                    "example": "Notes from the meeting with the client."
+                    "example": "2024-12-31T23:59:59Z"
                 },
-                "title": {
+                "permission": {
+                    "description": "\"read\" or \"write\"",
                     "type": "string",
-                    "example": "Meeting Notes"
+                    "example": "read"
+                },
+                "shared_with": {
+                    "type": "string",
+                    "example": "username"
                 }
             }
         },
-        "NoteOut": {
+        "SyncChange": {
             "type": "object",
             "required": [
-                "author",
-                "created_at",
-                "id"
+                "id",
+                "kind",
+                "version"
             ],
             "properties": {
-                "archived": {
-                    "type": "boolean"
-                },
-                "attachments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/AttachmentOut"
-                    }
-                },
-                "author": {
-                    "$ref": "#/definitions/PublicUserOut"
-                },
-                "content": {
-                    "type": "string",
-                    "example": "Notes from the meeting with the client."
-                },
-                "created_at": {
-                    "type": "string"
+                "attachment": {
+                    "$ref": "#/definitions/AttachmentOut"
                 },
-                "encrypted": {
+                "deleted": {
                     "type": "boolean"
                 },
                 "id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 },
-                "shares": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/Share"
-                    }
+                "kind": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SyncKind"
+                        }
+                    ],
+                    "example": "note"
                 },
-                "title": {
-                    "type": "string",
-                    "example": "Meeting Notes"
+                "note": {
+                    "$ref": "#/definitions/NoteOut"
                 },
-                "updated_at": {
+                "note_id": {
+                    "description": "the note of an attachment or share",
                     "type": "string"
+                },
+                "share": {
+                    "$ref": "#/definitions/Share"
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "infra"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "NoteShareResponse": {
+        "SyncKind": {
+            "type": "string",
+            "enum": [
+                "note",
+                "attachment",
+                "share",
+                "tag"
+            ],
+            "x-enum-varnames": [
+                "NoteKind",
+                "AttachmentKind",
+                "ShareKind",
+                "TagKind"
+            ]
+        },
+        "SyncRequest": {
             "type": "object",
             "properties": {
-                "shared": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/Share"
+                        "$ref": "#/definitions/SyncUpload"
                     }
+                },
+                "token": {
+                    "description": "of the last sync, empty for a full one",
+                    "type": "string",
+                    "example": "djI6NDI6MTc2MDgzMjAwMDAwMDAwMDo3ODA6NzgwOg"
                 }
             }
         },
-        "NotesResponse": {
+        "SyncResponse": {
             "type": "object",
             "required": [
-                "notes",
-                "total"
+                "changes",
+                "token"
             ],
             "properties": {
-                "notes": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/NoteOut"
+                        "$ref": "#/definitions/SyncChange"
                     }
                 },
-                "total": {
-                    "type": "integer",
-                    "example": 10
+                "more": {
+                    "description": "more changes are waiting, sync again with the token",
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SyncResult"
+                    }
+                },
+                "token": {
+                    "type": "string",
+                    "example": "djI6NDI6MTc2MDgzMjAwMDAwMDAwMDo3ODA6NzgwOg"
                 }
             }
         },
-        "PresignDownloadResponse": {
+        "SyncResult": {
             "type": "object",
             "required": [
-                "url"
+                "id",
+                "status"
             ],
             "properties": {
-                "url": {
+                "current": {
+                    "description": "the note as it is on a conflict, absent when it was deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/NoteOut"
+                        }
+                    ]
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "string",
-                    "example": "https://s3.com/download?key=example.txt"
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SyncStatus"
+                        }
+                    ],
+                    "example": "applied"
+                },
+                "version": {
+                    "description": "of the note once the edit is applied",
+                    "type": "integer",
+                    "example": 43
                 }
             }
         },
-        "PresignUploadRequest": {
+        "SyncStatus": {
+            "type": "string",
+            "enum": [
+                "applied",
+                "conflict",
+                "rejected"
+            ],
+            "x-enum-comments": {
+                "ConflictSync": "the note changed since the base version",
+                "RejectedSync": "the edit is invalid, see the error"
+            },
+            "x-enum-varnames": [
+                "AppliedSync",
+                "ConflictSync",
+                "RejectedSync"
+            ]
+        },
+        "SyncUpload": {
             "type": "object",
             "required": [
-                "content_type",
-                "filename"
+                "id",
+                "op"
             ],
             "properties": {
-                "content_type": {
+                "base_version": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "id": {
                     "type": "string",
-                    "example": "text/plain"
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
                 },
-                "filename": {
+                "note": {
+                    "description": "required for upserts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/NoteIn"
+                        }
+                    ]
+                },
+                "op": {
+                    "description": "\"upsert\" or \"delete\"",
                     "type": "string",
-                    "example": "example.txt"
+                    "example": "upsert"
                 }
             }
         },
-        "PresignUploadResponse": {
+        "ThumbnailURLs": {
             "type": "object",
             "required": [
-                "key",
-                "url"
+                "large",
+                "medium",
+                "small"
             ],
             "properties": {
-                "key": {
-                    "type": "string",
-                    "example": "attachments/123e4567-e89b-12d3-a456-426614174000/example.txt"
+                "large": {
+                    "type": "string"
                 },
-                "url": {
+                "medium": {
+                    "type": "string"
+                },
+                "small": {
                     "type": "string",
-                    "example": "https://s3.com/upload?key=example.txt"
+                    "example": "https://bucket.s3.amazonaws.com/thumbnails/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d/small.jpg"
                 }
             }
         },
-        "PublicUserOut": {
+        "TypeUsage": {
             "type": "object",
-            "required": [
-                "id",
-                "username"
-            ],
             "properties": {
-                "avatar_url": {
-                    "type": "string",
-                    "example": "https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000"
+                "attachments": {
+                    "type": "integer",
+                    "example": 3
                 },
-                "id": {
+                "mime_type": {
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "example": "application/pdf"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "jane_doe"
+                "size": {
+                    "type": "integer",
+                    "example": 3145728
                 }
             }
         },
-        "Session": {
+        "UploadStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "scanning",
+                "ready",
+                "rejected"
+            ],
+            "x-enum-comments": {
+                "PendingUpload": "presigned, the file hasn't arrived yet",
+                "RejectedUpload": "the file was deleted, the reason says why",
+                "ScanningUpload": "arrived and being checked"
+            },
+            "x-enum-varnames": [
+                "PendingUpload",
+                "ScanningUpload",
+                "ReadyUpload",
+                "RejectedUpload"
+            ]
+        },
+        "UploadedPart": {
             "type": "object",
             "required": [
-                "refresh",
-                "token"
+                "etag",
+                "number",
+                "size"
             ],
             "properties": {
-                "refresh": {
-                    "type": "string"
+                "etag": {
+                    "type": "string",
+                    "example": "\"b1946ac92492d2347c6235b4d2611184\""
                 },
-                "token": {
-                    "type": "string"
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8388608
                 }
             }
         },
-        "Share": {
+        "UploadedPartsResponse": {
             "type": "object",
             "required": [
-                "id",
-                "permission"
+                "part_size",
+                "parts",
+                "total"
             ],
             "properties": {
-                "expires": {
-                    "type": "string",
-                    "example": "2024-12-31T23:59:59Z"
-                },
-                "id": {
-                    "type": "string"
+                "part_size": {
+                    "type": "integer",
+                    "example": 8388608
                 },
-                "permission": {
-                    "type": "string",
-                    "example": "read"
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UploadedPart"
+                    }
                 },
-                "with": {
-                    "$ref": "#/definitions/PublicUserOut"
+                "total": {
+                    "description": "number of parts in all",
+                    "type": "integer",
+                    "example": 13
                 }
             }
         },
-        "ShareToUserRequest": {
+        "UsageOut": {
             "type": "object",
             "required": [
-                "permission"
+                "by_note",
+                "by_type",
+                "plan"
             ],
             "properties": {
-                "expires": {
-                    "type": "string",
-                    "example": "2024-12-31T23:59:59Z"
+                "attachments": {
+                    "description": "ready ones",
+                    "type": "integer",
+                    "example": 12
                 },
-                "permission": {
-                    "description": "\"read\" or \"write\"",
-                    "type": "string",
-                    "example": "read"
+                "available": {
+                    "description": "bytes still free to upload",
+                    "type": "integer",
+                    "example": 98566144
                 },
-                "shared_with": {
-                    "type": "string",
-                    "example": "username"
+                "by_note": {
+                    "description": "largest first, at most limit notes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/NoteUsage"
+                    }
+                },
+                "by_type": {
+                    "description": "largest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/TypeUsage"
+                    }
+                },
+                "pending": {
+                    "description": "bytes of uploads under way, reserved against the quota",
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "plan": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Plan"
+                        }
+                    ],
+                    "example": "free"
+                },
+                "quota": {
+                    "description": "bytes",
+                    "type": "integer",
+                    "example": 104857600
+                },
+                "used": {
+                    "description": "bytes of ready attachments",
+                    "type": "integer",
+                    "example": 5242880
                 }
             }
         },
@@ -1497,7 +3725,7 @@ const docTemplate = `{
                 },
                 "avatar_url": {
                     "type": "string",
-                    "example": "https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000"
+                    "example": "https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000-1760832000000/512.jpg"
                 },
                 "created_at": {
                     "type": "string",
@@ -1524,6 +3752,12 @@ const docTemplate = `{
                     "example": "jane_doe"
                 }
             }
+        },
+        "models.NoteFields": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
         }
     }
 }`
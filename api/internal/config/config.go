@@ -17,9 +17,38 @@ type DBConfig struct {
 	AppName    string `env:"APP_NAME" default:"vault-api"`
 }
 
+// AwsConfig is required with S3 storage
 type AwsConfig struct {
-	AwsRegion        string `env:"REGION" required:"true"`
-	AttachmentBucket string `env:"ATTACHMENT_BUCKET" required:"true"`
+	AwsRegion        string `env:"REGION"`
+	AttachmentBucket string `env:"ATTACHMENT_BUCKET"`
+}
+
+// StorageConfig selects where files are kept, see internal/storage
+type StorageConfig struct {
+	Storage       string `env:"STORAGE" default:"s3"`                        // "s3" or "local"
+	StorageDir    string `env:"STORAGE_DIR" default:"storage"`               // Directory of local storage
+	StorageURL    string `env:"STORAGE_URL" default:"http://localhost:8080"` // Where clients reach the API, for local storage URLs
+	StorageSecret string `env:"STORAGE_SECRET"`                              // Signs local storage URLs, JWT_SECRET when not set
+}
+
+// UploadConfig limits uploads per kind and storage per plan, see internal/uploads.
+// Types are comma-separated content types, "image/*" allowing a whole family
+type UploadConfig struct {
+	AttachmentMaxSize          int    `env:"ATTACHMENT_MAX_SIZE" default:"10485760"`             // 10 MB, uploaded in one go
+	AttachmentMultipartMaxSize int    `env:"ATTACHMENT_MULTIPART_MAX_SIZE" default:"1073741824"` // 1 GB, uploaded in parts
+	AttachmentTypes            string `env:"ATTACHMENT_TYPES" default:"application/pdf,application/json,application/zip,application/msword,application/vnd.openxmlformats-officedocument.*,text/plain,text/markdown,text/csv,image/*"`
+	AvatarMaxSize              int    `env:"AVATAR_MAX_SIZE" default:"5242880"` // 5 MB
+	AvatarTypes                string `env:"AVATAR_TYPES" default:"image/png,image/jpeg,image/gif"`
+	FreeQuota                  int    `env:"QUOTA_FREE" default:"104857600"`  // bytes of attachments on the free plan, 100 MB
+	ProQuota                   int    `env:"QUOTA_PRO" default:"10737418240"` // 10 GB
+}
+
+// ScanConfig checks uploads for malware, see internal/scan
+type ScanConfig struct {
+	ClamdAddress string `env:"CLAMD_ADDRESS"`                    // unix:///run/clamav/clamd.ctl or tcp://host:3310, uploads aren't scanned when not set
+	ScanMaxSize  int    `env:"SCAN_MAX_SIZE" default:"26214400"` // larger files are rejected when scanning, clamd's StreamMaxLength, 25 MB
+	ScanTimeout  int    `env:"SCAN_TIMEOUT" default:"60"`        // seconds
+	Infected     string `env:"SCAN_INFECTED" default:"delete"`   // "delete" or "quarantine", which moves infected files under quarantine/
 }
 
 type SentryConfig struct {
@@ -37,17 +66,23 @@ type CloudFrontConfig struct {
 type Config struct {
 	DBConfig
 	AwsConfig
+	StorageConfig
+	UploadConfig
+	ScanConfig
 	SentryConfig
 	FirebaseConfig
 	JWTSecret            string `env:"JWT_SECRET" required:"true"`
 	AuthTokenLifespan    int    `env:"AUTH_TOKEN_LIFESPAN" default:"180" required:"true"`       // 3 hours
 	RefreshTokenLifespan int    `env:"REFRESH_TOKEN_LIFESPAN" default:"100800" required:"true"` // 10 weeks
 	CORSOrigins          string `env:"CORS_ORIGINS" default:"*"`                                // Comma-separated list of allowed origins
+	BreachCorpusDir      string `env:"BREACH_CORPUS_DIR"`                                       // Directory of breached password hash ranges, see cmd/breach-import
 }
 
 type IngestConfig struct {
 	DBConfig
 	AwsConfig
+	UploadConfig
+	ScanConfig
 	CloudFrontConfig
 }
 
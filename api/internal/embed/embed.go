@@ -0,0 +1,131 @@
+// Package embed turns text into vectors whose cosine similarity tells how alike two texts are.
+package embed
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embedder computes embeddings of a fixed size. Name identifies the model, vectors of different
+// models can't be compared and are recomputed when it changes.
+type Embedder interface {
+	Name() string
+	Dimensions() int
+	Embed(text string) []float32
+}
+
+// Dimensions of the Default embedder, also the size of the pgvector column.
+const Dimensions = 256
+
+// Default is the embedder notes are indexed with.
+var Default Embedder = NewHashed(Dimensions)
+
+// Hashed embeds text locally, without a model to download or a service to call, by hashing
+// its words, word pairs and character trigrams into a fixed number of buckets.
+// Trigrams let different forms of a word, like "rotate" and "rotation", land close to each other.
+type Hashed struct {
+	dims int
+}
+
+func NewHashed(dims int) *Hashed {
+	return &Hashed{dims: dims}
+}
+
+func (h *Hashed) Name() string {
+	return "hashed-v1"
+}
+
+func (h *Hashed) Dimensions() int {
+	return h.dims
+}
+
+const (
+	wordWeight    = 1.0
+	bigramWeight  = 0.7
+	trigramWeight = 0.4
+)
+
+// Embed returns the unit-length embedding of text, all zeros when it has no words.
+func (h *Hashed) Embed(text string) []float32 {
+	vector := make([]float32, h.dims)
+	words := Words(text)
+
+	for i, word := range words {
+		h.add(vector, "w:"+word, wordWeight)
+		if i > 0 {
+			h.add(vector, "b:"+words[i-1]+" "+word, bigramWeight)
+		}
+
+		padded := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			h.add(vector, "t:"+string(padded[j:j+3]), trigramWeight)
+		}
+	}
+
+	normalize(vector)
+	return vector
+}
+
+// add hashes a feature into a bucket, the sign taken from the hash too so that collisions
+// cancel out rather than pile up.
+func (h *Hashed) add(vector []float32, feature string, weight float32) {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(feature))
+	sum := hash.Sum64()
+
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[sum%uint64(h.dims)] += weight
+}
+
+func normalize(vector []float32) {
+	var norm float64
+	for _, x := range vector {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+}
+
+// Cosine is the cosine similarity of two vectors of the same size, 0 when either is all zeros.
+func Cosine(a []float32, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "with": true,
+}
+
+// Words lowercases text and splits it into words, leaving out stop words.
+func Words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := fields[:0]
+	for _, word := range fields {
+		if !stopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
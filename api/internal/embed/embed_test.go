@@ -0,0 +1,36 @@
+package embed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashed(t *testing.T) {
+	e := NewHashed(Dimensions)
+
+	t.Run("Unit length and deterministic", func(t *testing.T) {
+		v := e.Embed("Staging database credentials")
+		assert.Len(t, v, Dimensions)
+		assert.InDelta(t, 1.0, Cosine(v, v), 1e-6)
+		assert.Equal(t, v, e.Embed("staging DATABASE credentials!"))
+	})
+
+	t.Run("Empty text", func(t *testing.T) {
+		v := e.Embed("the of and")
+		assert.Equal(t, make([]float32, Dimensions), v)
+		assert.Zero(t, Cosine(v, e.Embed("anything")))
+	})
+
+	t.Run("Similar texts are closer", func(t *testing.T) {
+		query := e.Embed("rotate database passwords")
+		near := e.Embed("Password rotation for the production databases is due monthly")
+		far := e.Embed("Grocery list: apples, oat milk, bread")
+
+		assert.Greater(t, Cosine(query, near), Cosine(query, far))
+	})
+}
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"rotate", "keys", "eu", "west", "1"}, Words("Rotate the keys in eu-west-1."))
+}
@@ -49,6 +49,10 @@ func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
 
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
 type ServerError struct {
 	*baseError
 }
@@ -86,6 +90,14 @@ func NewValidationError(err error) *ValidationError {
 	}
 }
 
+// NewValidationErrorWithDetails is a validation error that tells the client what exactly was wrong,
+// e.g. which search operators weren't understood.
+func NewValidationErrorWithDetails(err error, details map[string]any) *ValidationError {
+	e := NewValidationError(err)
+	e.details = details
+	return e
+}
+
 func NewUnauthorizedError(msg string, err error) *UnauthorizedError {
 	return &UnauthorizedError{
 		&baseError{
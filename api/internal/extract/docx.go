@@ -0,0 +1,108 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxPart caps how much of a single uncompressed part of a DOCX file is read, against zip bombs.
+const maxPart = 32 * 1024 * 1024
+
+// docxText reads the body of a Word document, followed by its headers, footers, footnotes and endnotes.
+func docxText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a DOCX file: %w", err)
+	}
+
+	var (
+		body  *zip.File
+		extra []*zip.File
+	)
+	for _, f := range archive.File {
+		name := f.Name
+		switch {
+		case name == "word/document.xml":
+			body = f
+		case path.Dir(name) == "word" && path.Ext(name) == ".xml" && isExtraPart(path.Base(name)):
+			extra = append(extra, f)
+		}
+	}
+
+	if body == nil {
+		return "", fmt.Errorf("not a DOCX file: word/document.xml is missing")
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Name < extra[j].Name })
+
+	var b strings.Builder
+	for _, f := range append([]*zip.File{body}, extra...) {
+		if err := wordText(f, &b); err != nil {
+			return "", err
+		}
+		if b.Len() >= MaxText {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+func isExtraPart(name string) bool {
+	for _, prefix := range []string{"header", "footer", "footnotes", "endnotes"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordText writes the runs of text in a WordprocessingML part, a paragraph per line.
+func wordText(f *zip.File, b *strings.Builder) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPart))
+	inText := false
+
+	for b.Len() < MaxText {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				b.WriteByte(' ')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			case "tc":
+				b.WriteByte(' ')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package extract pulls plain text out of uploaded documents so it can be searched.
+// Extractors are pure Go and best-effort: what can't be read is skipped rather than failing the document.
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is a document format text can be extracted from.
+type Kind string
+
+const (
+	PDF      Kind = "pdf"
+	DOCX     Kind = "docx"
+	Plain    Kind = "text"
+	Markdown Kind = "markdown"
+	CSV      Kind = "csv"
+)
+
+// MaxText caps the extracted text of a single document, in bytes.
+// The whole note has to fit into a tsvector, which is limited to 1 MB.
+const MaxText = 256 * 1024
+
+var ErrUnsupported = errors.New("unsupported document type")
+
+var mimeKinds = map[string]Kind{
+	"application/pdf": PDF,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": DOCX,
+	"text/plain":                  Plain,
+	"text/markdown":               Markdown,
+	"text/x-markdown":             Markdown,
+	"text/csv":                    CSV,
+	"application/csv":             CSV,
+	"text/comma-separated-values": CSV,
+}
+
+var extensionKinds = map[string]Kind{
+	".pdf":      PDF,
+	".docx":     DOCX,
+	".txt":      Plain,
+	".text":     Plain,
+	".log":      Plain,
+	".md":       Markdown,
+	".markdown": Markdown,
+	".csv":      CSV,
+}
+
+// KindOf tells the format of a document by its MIME type, falling back on the file extension
+// for uploads stored with a generic type like application/octet-stream.
+func KindOf(mimeType string, filename string) (Kind, bool) {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	if kind, ok := mimeKinds[strings.TrimSpace(mimeType)]; ok {
+		return kind, true
+	}
+	kind, ok := extensionKinds[strings.ToLower(path.Ext(filename))]
+	return kind, ok
+}
+
+// Text extracts the text of a document, whitespace collapsed and cut to MaxText.
+func Text(kind Kind, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+
+	switch kind {
+	case PDF:
+		text, err = pdfText(data)
+	case DOCX:
+		text, err = docxText(data)
+	case Plain, Markdown:
+		text = string(data)
+	case CSV:
+		text = csvText(data)
+	default:
+		return "", ErrUnsupported
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return normalize(text), nil
+}
+
+// csvText joins the cells of a CSV file, reading it as plain text if it isn't well-formed.
+func csvText(data []byte) string {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var b strings.Builder
+	for b.Len() < MaxText {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return string(data)
+		}
+		b.WriteString(strings.Join(record, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// normalize makes text safe to store in Postgres: valid UTF-8 without control characters,
+// whitespace collapsed, no longer than MaxText.
+func normalize(text string) string {
+	text = strings.ToValidUTF8(text, " ")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+
+	var b strings.Builder
+	for _, word := range strings.Fields(text) {
+		if b.Len()+len(word)+1 > MaxText {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+	}
+
+	// a single oversized word is cut at a rune boundary
+	if b.Len() == 0 && len(text) > 0 {
+		text = strings.TrimSpace(text)
+		if len(text) > MaxText {
+			text = text[:MaxText]
+			for !utf8.ValidString(text) {
+				text = text[:len(text)-1]
+			}
+		}
+		return text
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		mime     string
+		filename string
+		kind     Kind
+		ok       bool
+	}{
+		{"application/pdf", "report", PDF, true},
+		{"text/plain; charset=utf-8", "notes", Plain, true},
+		{"application/octet-stream", "Minutes.DOCX", DOCX, true},
+		{"", "readme.md", Markdown, true},
+		{"text/csv", "export.bin", CSV, true},
+		{"image/png", "photo.png", "", false},
+	}
+
+	for _, tt := range tests {
+		kind, ok := KindOf(tt.mime, tt.filename)
+		assert.Equal(t, tt.ok, ok, tt.filename)
+		assert.Equal(t, tt.kind, kind, tt.filename)
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Run("Plain text", func(t *testing.T) {
+		text, err := Text(Plain, []byte("rotate the\x00 staging\n\n  keys\xff monthly"))
+		require.NoError(t, err)
+		assert.Equal(t, "rotate the staging keys monthly", text)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		text, err := Text(CSV, []byte("host,user\n\"db.internal, eu\",app\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "host user db.internal, eu app", text)
+	})
+
+	t.Run("DOCX", func(t *testing.T) {
+		text, err := Text(DOCX, docx(t, map[string]string{
+			"word/document.xml": `<w:document xmlns:w="w"><w:body>
+				<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">budget </w:t></w:r></w:p>
+				<w:p><w:r><w:t>for infra</w:t></w:r></w:p>
+			</w:body></w:document>`,
+			"word/footer1.xml": `<w:ftr xmlns:w="w"><w:p><w:r><w:t>Confidential</w:t></w:r></w:p></w:ftr>`,
+			"word/styles.xml":  `<w:styles xmlns:w="w"><w:t>Heading</w:t></w:styles>`,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "Quarterly budget for infra Confidential", text)
+	})
+
+	t.Run("PDF", func(t *testing.T) {
+		page := `BT /F1 12 Tf 72 720 Td (Staging \(eu\) cluster) Tj 0 -14 Td [(pass)-20(word) -300 (rotation)] TJ ET
+			BI /W 1 /H 1 ID ` + "\x00\x01BT (noise) Tj ET" + ` EI
+			BT <FEFF00630061006600E9> Tj (\223quoted\224) ' ET`
+
+		text, err := Text(PDF, pdf(page, false))
+		require.NoError(t, err)
+		assert.Equal(t, "Staging (eu) cluster password rotation café “quoted”", text)
+
+		text, err = Text(PDF, pdf(page, true))
+		require.NoError(t, err)
+		assert.Equal(t, "Staging (eu) cluster password rotation café “quoted”", text)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		_, err := Text(PDF, []byte("hello"))
+		assert.Error(t, err)
+
+		_, err = Text(DOCX, []byte("hello"))
+		assert.Error(t, err)
+
+		_, err = Text("image", []byte("hello"))
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("Caps length", func(t *testing.T) {
+		text, err := Text(Plain, []byte(strings.Repeat("secret ", MaxText)))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(text), MaxText)
+		assert.True(t, strings.HasSuffix(text, "secret"))
+	})
+}
+
+// pdf builds a single-page PDF around a content stream.
+func pdf(content string, compress bool) []byte {
+	stream, filter := []byte(content), ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write(stream)
+		_ = w.Close()
+		stream, filter = buf.Bytes(), " /Filter /FlateDecode"
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&b, "4 0 obj << /Length %d%s >>\nstream\n", len(stream), filter)
+	b.Write(stream)
+	b.WriteString("\nendstream\nendobj\n")
+	b.WriteString("5 0 obj << /Type /XObject /Subtype /Image /Length 12 >>\nstream\nBT (x) Tj ET\nendstream\nendobj\n")
+	b.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func docx(t *testing.T, parts map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,406 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// skippedStreams are streams that never hold page content: images, fonts, object and cross-reference streams.
+var skippedStreams = []string{"/Image", "/ObjStm", "/XRef", "/Length1", "/Length2", "/Length3", "/FontFile", "/Metadata", "/EmbeddedFile"}
+
+var filterPattern = regexp.MustCompile(`/([A-Za-z0-9]+Decode|Fl|AHx|A85|LZW|RL|CCF|DCT)\b`)
+
+// pdfText pulls the text shown by the content streams of a PDF, a text object per line.
+// It reads uncompressed and Flate-compressed streams, and strings in single-byte or UTF-16 encodings.
+// Text set in fonts that need a ToUnicode map to be read, common in CJK documents, comes out
+// as binary and is dropped.
+func pdfText(data []byte) (string, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", fmt.Errorf("encrypted PDF files are not supported")
+	}
+
+	var b strings.Builder
+	PDFStreams(data, func(dict string, raw []byte) bool {
+		if content, ok := decodeStream(dict, raw); ok {
+			showText(content, &b)
+		}
+		return b.Len() < MaxText
+	})
+
+	return b.String(), nil
+}
+
+// PDFStreams calls fn with the dictionary and raw bytes of each stream of a PDF, in order, until it returns false.
+func PDFStreams(data []byte, fn func(dict string, raw []byte) bool) {
+	rest := data
+	for {
+		i := bytes.Index(rest, []byte("stream"))
+		if i < 0 {
+			return
+		}
+
+		start := i + len("stream")
+		// "endstream", or the word in some other context
+		if i >= 3 && string(rest[i-3:i]) == "end" || start >= len(rest) || (rest[start] != '\r' && rest[start] != '\n') {
+			rest = rest[start:]
+			continue
+		}
+		if rest[start] == '\r' {
+			start++
+		}
+		if start < len(rest) && rest[start] == '\n' {
+			start++
+		}
+
+		end := bytes.Index(rest[start:], []byte("endstream"))
+		if end < 0 {
+			return
+		}
+
+		dict := streamDict(rest[:i])
+		raw := rest[start : start+end]
+		rest = rest[start+end+len("endstream"):]
+
+		if !fn(dict, raw) {
+			return
+		}
+	}
+}
+
+// streamDict returns the dictionary of the stream whose keyword ends prefix.
+func streamDict(prefix []byte) string {
+	window := prefix[max(0, len(prefix)-4096):]
+	if i := bytes.LastIndex(window, []byte("obj")); i >= 0 {
+		window = window[i:]
+	}
+	return string(window)
+}
+
+// decodeStream decompresses a stream that may hold page content.
+func decodeStream(dict string, raw []byte) ([]byte, bool) {
+	for _, skipped := range skippedStreams {
+		if strings.Contains(dict, skipped) {
+			return nil, false
+		}
+	}
+
+	filters := filterPattern.FindAllStringSubmatch(dict, -1)
+	if len(filters) == 0 {
+		return raw, true
+	}
+	for _, f := range filters {
+		if f[1] != "FlateDecode" && f[1] != "Fl" {
+			return nil, false
+		}
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+
+	// streams cut short still give what was decompressed
+	content, _ := io.ReadAll(io.LimitReader(r, maxPart))
+	return content, len(content) > 0
+}
+
+type tokenKind int
+
+const (
+	stringToken tokenKind = iota
+	numberToken
+	arrayToken
+	operatorToken
+	otherToken
+)
+
+type token struct {
+	kind   tokenKind
+	text   []byte  // string bytes or operator name
+	number float64 // numberToken
+	items  []token // arrayToken
+}
+
+// showText runs the text operators of a content stream, writing out the strings they show.
+func showText(content []byte, b *strings.Builder) {
+	l := &lexer{data: content}
+	var args []token
+
+	for b.Len() < MaxText {
+		t, ok := l.next()
+		if !ok {
+			return
+		}
+		if t.kind != operatorToken {
+			args = append(args, t)
+			continue
+		}
+
+		switch string(t.text) {
+		case "ET":
+			b.WriteByte('\n')
+		case "Td", "TD", "T*", "Tm":
+			b.WriteByte(' ')
+		case "Tj":
+			writeLastString(args, b)
+		case "'", `"`:
+			b.WriteByte('\n')
+			writeLastString(args, b)
+		case "TJ":
+			if len(args) > 0 && args[len(args)-1].kind == arrayToken {
+				for _, item := range args[len(args)-1].items {
+					switch {
+					case item.kind == stringToken:
+						b.WriteString(decodeString(item.text))
+					// a wide enough gap between glyphs is a space
+					case item.kind == numberToken && item.number < -200:
+						b.WriteByte(' ')
+					}
+				}
+			}
+		case "ID":
+			l.skipInlineImage()
+		}
+		args = args[:0]
+	}
+}
+
+func writeLastString(args []token, b *strings.Builder) {
+	if len(args) > 0 && args[len(args)-1].kind == stringToken {
+		b.WriteString(decodeString(args[len(args)-1].text))
+	}
+}
+
+// winAnsi maps the bytes WinAnsiEncoding places where Latin-1 has control characters.
+var winAnsi = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™',
+}
+
+// decodeString turns the bytes of a PDF string into text, dropping strings that look like glyph IDs.
+func decodeString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	binary := 0
+	for _, c := range s {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			binary++
+		}
+	}
+	if binary*4 > len(s) {
+		return ""
+	}
+
+	runes := make([]rune, 0, len(s))
+	for _, c := range s {
+		if r, ok := winAnsi[c]; ok {
+			runes = append(runes, r)
+		} else {
+			runes = append(runes, rune(c))
+		}
+	}
+	return string(runes)
+}
+
+// lexer splits a content stream into tokens. Names and dictionaries become otherToken,
+// arrays are read whole.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) next() (token, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			l.pos++
+			return token{kind: stringToken, text: l.literal()}, true
+		case c == '<' && l.peek(1) == '<', c == '>' && l.peek(1) == '>':
+			l.pos += 2
+			return token{kind: otherToken}, true
+		case c == '<':
+			l.pos++
+			return token{kind: stringToken, text: l.hex()}, true
+		case c == '[':
+			l.pos++
+			return l.array(), true
+		case c == '/':
+			l.pos++
+			l.word()
+			return token{kind: otherToken}, true
+		case isDelimiter(c):
+			l.pos++
+			return token{kind: otherToken}, true
+		default:
+			word := l.word()
+			if n, err := strconv.ParseFloat(string(word), 64); err == nil {
+				return token{kind: numberToken, number: n}, true
+			}
+			switch string(word) {
+			case "true", "false", "null":
+				return token{kind: otherToken}, true
+			}
+			return token{kind: operatorToken, text: word}, true
+		}
+	}
+	return token{}, false
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) word() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+func (l *lexer) array() token {
+	arr := token{kind: arrayToken}
+	for l.pos < len(l.data) {
+		for l.pos < len(l.data) && isSpace(l.data[l.pos]) {
+			l.pos++
+		}
+		if l.peek(0) == ']' {
+			l.pos++
+			break
+		}
+		t, ok := l.next()
+		if !ok {
+			break
+		}
+		arr.items = append(arr.items, t)
+	}
+	return arr
+}
+
+// literal reads a (string) with its escapes, the opening parenthesis already consumed.
+func (l *lexer) literal() []byte {
+	var out []byte
+	depth := 1
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.peek(0) == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.peek(0) >= '0' && l.peek(0) <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(n)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hex reads a <hex string>, the opening bracket already consumed.
+func (l *lexer) hex() []byte {
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++
+
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		n, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return nil
+		}
+		out = append(out, byte(n))
+	}
+	return out
+}
+
+// skipInlineImage jumps over the binary data of an inline image, up to its EI operator.
+func (l *lexer) skipInlineImage() {
+	for i := l.pos; i+1 < len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && i > 0 && isSpace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isSpace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
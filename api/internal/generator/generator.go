@@ -0,0 +1,304 @@
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	lowercase = "abcdefghijklmnopqrstuvwxyz"
+	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits    = "0123456789"
+	symbols   = "!@#$%^&*()-_=+[]{};:,.<>?/~"
+	ambiguous = "Il1|O0o`'\";:,."
+
+	consonants = "bcdfghjkmnprstvwxz"
+	vowels     = "aeiou"
+
+	MinPasswordLength  = 4
+	MaxPasswordLength  = 256
+	MinPassphraseWords = 3
+	MaxPassphraseWords = 20
+)
+
+// PasswordOptions controls how a random password is built.
+type PasswordOptions struct {
+	Length           int
+	Lowercase        bool
+	Uppercase        bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+	Pronounceable    bool
+}
+
+// PassphraseOptions controls how a diceware passphrase is built.
+type PassphraseOptions struct {
+	Words         int
+	Separator     string
+	Capitalize    bool
+	IncludeNumber bool
+}
+
+// Secret is a generated value along with its estimated entropy in bits.
+type Secret struct {
+	Value   string
+	Entropy float64
+}
+
+// Password generates a random password according to the options.
+// Every enabled character class is guaranteed to appear at least once.
+func Password(opts PasswordOptions) (Secret, error) {
+	if opts.Length < MinPasswordLength || opts.Length > MaxPasswordLength {
+		return Secret{}, fmt.Errorf("length must be between %d and %d", MinPasswordLength, MaxPasswordLength)
+	}
+
+	if opts.Pronounceable {
+		return pronounceable(opts)
+	}
+
+	classes := charClasses(opts)
+	if len(classes) == 0 {
+		return Secret{}, fmt.Errorf("at least one character class must be enabled")
+	}
+
+	pool := strings.Join(classes, "")
+	out := make([]byte, 0, opts.Length)
+
+	for _, class := range classes {
+		c, err := pick(class)
+		if err != nil {
+			return Secret{}, err
+		}
+		out = append(out, c)
+	}
+
+	for len(out) < opts.Length {
+		c, err := pick(pool)
+		if err != nil {
+			return Secret{}, err
+		}
+		out = append(out, c)
+	}
+
+	if err := shuffle(out); err != nil {
+		return Secret{}, err
+	}
+
+	return Secret{
+		Value:   string(out),
+		Entropy: round(float64(opts.Length) * math.Log2(float64(len(pool)))),
+	}, nil
+}
+
+// Passphrase generates a passphrase from the embedded EFF diceware wordlist.
+func Passphrase(opts PassphraseOptions) (Secret, error) {
+	if opts.Words < MinPassphraseWords || opts.Words > MaxPassphraseWords {
+		return Secret{}, fmt.Errorf("words must be between %d and %d", MinPassphraseWords, MaxPassphraseWords)
+	}
+
+	list := wordlist()
+	words := make([]string, opts.Words)
+
+	for i := range words {
+		n, err := randomInt(len(list))
+		if err != nil {
+			return Secret{}, err
+		}
+		word := list[n]
+		if opts.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		words[i] = word
+	}
+
+	entropy := float64(opts.Words) * math.Log2(float64(len(list)))
+
+	if opts.IncludeNumber {
+		at, err := randomInt(len(words))
+		if err != nil {
+			return Secret{}, err
+		}
+		d, err := pick(digits)
+		if err != nil {
+			return Secret{}, err
+		}
+		words[at] += string(d)
+		entropy += math.Log2(float64(len(digits))) + math.Log2(float64(len(words)))
+	}
+
+	return Secret{
+		Value:   strings.Join(words, opts.Separator),
+		Entropy: round(entropy),
+	}, nil
+}
+
+// pronounceable builds a password out of alternating consonants and vowels, in lowercase, uppercase
+// or a random mix of both as enabled, optionally followed by digits and a symbol.
+func pronounceable(opts PasswordOptions) (Secret, error) {
+	if !opts.Lowercase && !opts.Uppercase {
+		return Secret{}, fmt.Errorf("pronounceable passwords need lowercase or uppercase letters")
+	}
+
+	var (
+		cons    = consonants
+		vows    = vowels
+		entropy float64
+		tail    []byte
+	)
+
+	if opts.ExcludeAmbiguous {
+		cons = without(cons, ambiguous)
+		vows = without(vows, ambiguous)
+		if opts.Uppercase {
+			// nor may letters that are ambiguous uppercased, like i
+			cons = without(cons, strings.ToLower(ambiguous))
+			vows = without(vows, strings.ToLower(ambiguous))
+		}
+	}
+
+	if opts.Digits {
+		for i := 0; i < 2; i++ {
+			set := digitSet(opts)
+			c, err := pick(set)
+			if err != nil {
+				return Secret{}, err
+			}
+			tail = append(tail, c)
+			entropy += math.Log2(float64(len(set)))
+		}
+	}
+
+	if opts.Symbols {
+		set := symbolSet(opts)
+		c, err := pick(set)
+		if err != nil {
+			return Secret{}, err
+		}
+		tail = append(tail, c)
+		entropy += math.Log2(float64(len(set)))
+	}
+
+	letters := opts.Length - len(tail)
+	if letters < 2 {
+		return Secret{}, fmt.Errorf("length is too short for a pronounceable password")
+	}
+
+	out := make([]byte, 0, opts.Length)
+	for i := 0; i < letters; i++ {
+		set := cons
+		if i%2 == 1 {
+			set = vows
+		}
+		c, err := pick(set)
+		if err != nil {
+			return Secret{}, err
+		}
+		entropy += math.Log2(float64(len(set)))
+
+		switch {
+		case opts.Lowercase && opts.Uppercase:
+			upper, err := randomInt(2)
+			if err != nil {
+				return Secret{}, err
+			}
+			if upper == 1 {
+				c = c - 'a' + 'A'
+			}
+			entropy++
+		case opts.Uppercase:
+			c = c - 'a' + 'A'
+		}
+		out = append(out, c)
+	}
+
+	return Secret{
+		Value:   string(append(out, tail...)),
+		Entropy: round(entropy),
+	}, nil
+}
+
+func charClasses(opts PasswordOptions) []string {
+	var classes []string
+	if opts.Lowercase {
+		classes = append(classes, lowercase)
+	}
+	if opts.Uppercase {
+		classes = append(classes, uppercase)
+	}
+	if opts.Digits {
+		classes = append(classes, digitSet(opts))
+	}
+	if opts.Symbols {
+		classes = append(classes, symbolSet(opts))
+	}
+
+	if opts.ExcludeAmbiguous {
+		for i, class := range classes {
+			classes[i] = without(class, ambiguous)
+		}
+	}
+	return classes
+}
+
+func digitSet(opts PasswordOptions) string {
+	if opts.ExcludeAmbiguous {
+		return without(digits, ambiguous)
+	}
+	return digits
+}
+
+func symbolSet(opts PasswordOptions) string {
+	if opts.ExcludeAmbiguous {
+		return without(symbols, ambiguous)
+	}
+	return symbols
+}
+
+func without(set string, exclude string) string {
+	return strings.Map(
+		func(r rune) rune {
+			if strings.ContainsRune(exclude, r) {
+				return -1
+			}
+			return r
+		},
+		set,
+	)
+}
+
+// randomInt returns a uniformly distributed integer in [0, n) from crypto/rand.
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return int(v.Int64()), nil
+}
+
+func pick(set string) (byte, error) {
+	i, err := randomInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[i], nil
+}
+
+// shuffle performs an in-place Fisher-Yates shuffle.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+func round(bits float64) float64 {
+	return math.Round(bits*100) / 100
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	t.Run("Contains every enabled class", func(t *testing.T) {
+		secret, err := Password(PasswordOptions{
+			Length:    16,
+			Lowercase: true,
+			Uppercase: true,
+			Digits:    true,
+			Symbols:   true,
+		})
+		require.NoError(t, err)
+		assert.Len(t, secret.Value, 16)
+		assert.True(t, strings.ContainsAny(secret.Value, lowercase))
+		assert.True(t, strings.ContainsAny(secret.Value, uppercase))
+		assert.True(t, strings.ContainsAny(secret.Value, digits))
+		assert.True(t, strings.ContainsAny(secret.Value, symbols))
+		assert.InDelta(t, 103.61, secret.Entropy, 0.01)
+	})
+
+	t.Run("Excludes ambiguous characters", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			secret, err := Password(PasswordOptions{
+				Length:           64,
+				Lowercase:        true,
+				Uppercase:        true,
+				Digits:           true,
+				Symbols:          true,
+				ExcludeAmbiguous: true,
+			})
+			require.NoError(t, err)
+			assert.False(t, strings.ContainsAny(secret.Value, ambiguous), secret.Value)
+		}
+	})
+
+	t.Run("Pronounceable", func(t *testing.T) {
+		secret, err := Password(PasswordOptions{
+			Length:        12,
+			Lowercase:     true,
+			Digits:        true,
+			Pronounceable: true,
+		})
+		require.NoError(t, err)
+		assert.Len(t, secret.Value, 12)
+		assert.True(t, strings.ContainsAny(secret.Value[1:2], vowels))
+		assert.True(t, strings.ContainsAny(secret.Value[10:], digits))
+		assert.Greater(t, secret.Entropy, 0.0)
+	})
+
+	t.Run("Pronounceable excludes ambiguous characters", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			secret, err := Password(PasswordOptions{
+				Length:           64,
+				Lowercase:        true,
+				Uppercase:        true,
+				ExcludeAmbiguous: true,
+				Pronounceable:    true,
+			})
+			require.NoError(t, err)
+			assert.False(t, strings.ContainsAny(secret.Value, ambiguous), secret.Value)
+		}
+	})
+
+	t.Run("Pronounceable letter case", func(t *testing.T) {
+		lower, err := Password(PasswordOptions{Length: 32, Lowercase: true, Pronounceable: true})
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToLower(lower.Value), lower.Value)
+
+		upper, err := Password(PasswordOptions{Length: 32, Uppercase: true, Pronounceable: true})
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(upper.Value), upper.Value)
+		// the case of the letters is no secret when there's one
+		assert.Equal(t, lower.Entropy, upper.Entropy)
+
+		mixed, err := Password(PasswordOptions{Length: 32, Lowercase: true, Uppercase: true, Pronounceable: true})
+		require.NoError(t, err)
+		assert.InDelta(t, lower.Entropy+32, mixed.Entropy, 0.01)
+	})
+
+	t.Run("Rejects invalid options", func(t *testing.T) {
+		_, err := Password(PasswordOptions{Length: 2, Lowercase: true})
+		assert.Error(t, err)
+
+		_, err = Password(PasswordOptions{Length: 12})
+		assert.Error(t, err)
+
+		_, err = Password(PasswordOptions{Length: 12, Digits: true, Pronounceable: true})
+		assert.Error(t, err)
+	})
+}
+
+func TestPassphrase(t *testing.T) {
+	t.Run("Uses the wordlist", func(t *testing.T) {
+		require.Len(t, wordlist(), 7776)
+
+		secret, err := Passphrase(PassphraseOptions{Words: 6, Separator: "-"})
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(secret.Value, "-"), 6)
+		assert.InDelta(t, 77.55, secret.Entropy, 0.01)
+	})
+
+	t.Run("Capitalizes and adds a number", func(t *testing.T) {
+		secret, err := Passphrase(PassphraseOptions{
+			Words:         4,
+			Separator:     " ",
+			Capitalize:    true,
+			IncludeNumber: true,
+		})
+		require.NoError(t, err)
+		for _, word := range strings.Split(secret.Value, " ") {
+			assert.True(t, strings.ContainsAny(word[:1], uppercase), word)
+		}
+		assert.True(t, strings.ContainsAny(secret.Value, digits))
+	})
+
+	t.Run("Rejects invalid word counts", func(t *testing.T) {
+		_, err := Passphrase(PassphraseOptions{Words: 1})
+		assert.Error(t, err)
+	})
+}
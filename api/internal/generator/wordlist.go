@@ -0,0 +1,26 @@
+package generator
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// EFF large wordlist, see
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var wordlist = sync.OnceValue(func() []string {
+	lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+	words := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		// each line is "<dice rolls>\t<word>"
+		if _, word, ok := strings.Cut(line, "\t"); ok {
+			words = append(words, strings.TrimSpace(word))
+		}
+	}
+	return words
+})
@@ -5,12 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"strings"
-	"vault/internal/awsx"
 	"vault/internal/db"
 	"vault/internal/errors"
 	"vault/internal/firebasex"
 	"vault/internal/jwtx"
 	"vault/internal/models"
+	"vault/internal/storage"
+	"vault/internal/uploads"
 )
 
 // Refresh godoc
@@ -41,17 +42,20 @@ func Refresh(c *gin.Context) (any, error) {
 		return nil, errors.NewUnauthorizedError("Invalid refresh token", err)
 	}
 
-	userID, ok := claims["sub"].(uuid.UUID)
-	if !ok {
-		return nil, errors.NewServerError(fmt.Errorf("invalid user ID in token"))
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, errors.NewUnauthorizedError("Invalid user ID in token", err)
 	}
 
-	accessToken, err := jwtx.Generate(userID, true)
+	verified, _ := claims["verified"].(bool)
+
+	accessToken, err := jwtx.Generate(userID, verified)
 	if err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
-	refreshToken, err := jwtx.GenerateRefresh(userID, true)
+	refreshToken, err := jwtx.GenerateRefresh(userID, verified)
 	if err != nil {
 		return nil, errors.NewServerError(err)
 	}
@@ -167,8 +171,9 @@ func SignInWithFirebase(c *gin.Context) (any, error) {
 
 // PresignAvatar godoc
 //
-//	@Summary		Get presigned URL for avatar upload
-//	@Description	Generates a presigned S3 URL for uploading user avatar
+//	@Summary		Get presigned form for avatar upload
+//	@Description	Generates a presigned S3 form for uploading user avatar of the declared image type and size.
+//	@Description	Ingest crops the image square and resizes it without its metadata, then sets avatar_url to a URL of its own.
 //	@Tags			auth
 //	@ID				presign-avatar
 //	@Accept			json
@@ -186,18 +191,20 @@ func PresignAvatar(c *gin.Context, userID uuid.UUID) (any, error) {
 		return nil, errors.NewValidationError(err)
 	}
 
-	if !strings.HasPrefix(req.ContentType, "image/") {
-		return nil, errors.NewValidationError(fmt.Errorf("only image files are allowed"))
+	policy, err := uploads.Policy(uploads.Avatars, req.ContentType, req.Size)
+	if err != nil {
+		return nil, errors.NewValidationError(err)
 	}
 
 	key := fmt.Sprintf("avatars/%s", userID)
-	url, err := awsx.GeneratePresignedPutURL(key, req.ContentType)
+	form, err := storage.Default.PresignPost(c.Request.Context(), key, policy)
 	if err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
 	return models.PresignUploadResponse{
-		URL: url,
-		Key: key,
+		URL:    form.URL,
+		Fields: form.Fields,
+		Key:    key,
 	}, nil
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+	"vault/internal/storage"
+	"vault/internal/uploads"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateMultipartUpload godoc
+//
+//	@Summary		Start uploading a large attachment in parts
+//	@Description	Creates a pending attachment for a file uploaded in parts, which may be larger than getUploadURL takes,
+//	@Description	up to ATTACHMENT_MULTIPART_MAX_SIZE, within the same types and quota.
+//	@Description	Parts are uploaded to URLs from presignParts, in parallel and again after failures,
+//	@Description	then the upload is finished with completeUpload. Uploads left unfinished are aborted after a while.
+//	@Tags			notes
+//	@ID				createMultipartUpload
+//	@Accept			json
+//	@Produce		json
+//	@Param			noteId	path		string					true	"Note ID"
+//	@Param			body	body		PresignUploadRequest	true	"Upload parameters"
+//	@Success		200		{object}	MultipartUploadResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid request, or the quota would be exceeded"
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/notes/{noteId}/attachments/multipart [post]
+//	@Security		BearerAuth
+func CreateMultipartUpload(c *gin.Context, userID uuid.UUID) (any, error) {
+	attachment, policy, err := newUpload(c, userID, uploads.MultipartPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request.Context()
+	attachment.UploadID, err = storage.Default.CreateMultipart(ctx, attachment.UploadKey(), policy.ContentType)
+	if err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	if err := savePending(userID, &attachment); err != nil {
+		if err := storage.Default.AbortMultipart(ctx, attachment.UploadKey(), attachment.UploadID); err != nil {
+			log.Printf("Failed to abort upload of %s: %v", attachment.UploadKey(), err)
+		}
+		return nil, err
+	}
+
+	partSize, parts := uploads.Parts(attachment.Size)
+	return models.MultipartUploadResponse{
+		ID:       attachment.ID,
+		Key:      attachment.UploadKey(),
+		PartSize: partSize,
+		Parts:    parts,
+	}, nil
+}
+
+// PresignParts godoc
+//
+//	@Summary		Get URLs to upload parts to
+//	@Description	Presigns URLs to PUT parts of a multipart upload to, by number. Uploading a part again replaces it.
+//	@Description	URLs expire, so it's best to ask for them as parts are about to be uploaded.
+//	@Tags			notes
+//	@ID				presignParts
+//	@Accept			json
+//	@Produce		json
+//	@Param			noteId			path		string				true	"Note ID"
+//	@Param			attachmentId	path		string				true	"Attachment ID"
+//	@Param			body			body		PresignPartsRequest	true	"Parts to upload"
+//	@Success		200				{object}	PresignPartsResponse
+//	@Failure		400				{object}	ErrorResponse	"Invalid part numbers, or the attachment isn't being uploaded in parts"
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/notes/{noteId}/attachments/{attachmentId}/parts [post]
+//	@Security		BearerAuth
+func PresignParts(c *gin.Context, userID uuid.UUID) (any, error) {
+	var input models.PresignPartsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	attachment, err := findMultipart(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, parts := uploads.Parts(attachment.Size)
+	out := models.PresignPartsResponse{Parts: make([]models.PartURL, 0, len(input.Parts))}
+	for _, n := range input.Parts {
+		if n > parts {
+			return nil, errors.NewValidationError(fmt.Errorf("part %d is out of range, the upload has %d parts", n, parts))
+		}
+		url, err := storage.Default.PresignPart(c.Request.Context(), attachment.UploadKey(), attachment.UploadID, n)
+		if err != nil {
+			return nil, errors.NewServerError(err)
+		}
+		out.Parts = append(out.Parts, models.PartURL{Number: n, URL: url})
+	}
+
+	// the upload is under way, so the sweep leaves it alone
+	if err := db.DB.Model(&attachment).Update("updated_at", time.Now()).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return out, nil
+}
+
+// GetUploadedParts godoc
+//
+//	@Summary		List the uploaded parts
+//	@Description	Lists the parts of a multipart upload that arrived, and how the file is split into parts,
+//	@Description	so an interrupted upload can resume with the rest.
+//	@Tags			notes
+//	@ID				getUploadedParts
+//	@Produce		json
+//	@Param			noteId			path		string	true	"Note ID"
+//	@Param			attachmentId	path		string	true	"Attachment ID"
+//	@Success		200				{object}	UploadedPartsResponse
+//	@Failure		400				{object}	ErrorResponse	"The attachment isn't being uploaded in parts"
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/notes/{noteId}/attachments/{attachmentId}/parts [get]
+//	@Security		BearerAuth
+func GetUploadedParts(c *gin.Context, userID uuid.UUID) (any, error) {
+	attachment, err := findMultipart(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := storage.Default.ListParts(c.Request.Context(), attachment.UploadKey(), attachment.UploadID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errors.NewNotFoundError("Upload not found", err)
+		}
+		return nil, errors.NewServerError(err)
+	}
+
+	partSize, total := uploads.Parts(attachment.Size)
+	out := models.UploadedPartsResponse{PartSize: partSize, Total: total, Parts: make([]models.UploadedPart, 0, len(parts))}
+	for _, p := range parts {
+		out.Parts = append(out.Parts, models.UploadedPart{Number: p.Number, Size: p.Size, ETag: p.ETag})
+	}
+	return out, nil
+}
+
+// findMultipart is the attachment in the request path, which has to be pending a multipart upload.
+func findMultipart(c *gin.Context, userID uuid.UUID) (models.Attachment, error) {
+	attachment, err := findAttachment(c, userID)
+	if err != nil {
+		return attachment, err
+	}
+	if attachment.Status != models.PendingUpload || attachment.UploadID == "" {
+		return attachment, errors.NewValidationError(fmt.Errorf("the attachment isn't being uploaded in parts"))
+	}
+	return attachment, nil
+}
+
+// completeMultipart joins the parts of the attachment's upload, which have to make up the declared size,
+// into its file. The attachment is reloaded, storage may have processed the file by then.
+func completeMultipart(c *gin.Context, attachment *models.Attachment) error {
+	ctx := c.Request.Context()
+	key := attachment.UploadKey()
+
+	parts, err := storage.Default.ListParts(ctx, key, attachment.UploadID)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// completed by another request meanwhile, or aborted
+	case err != nil:
+		return errors.NewServerError(err)
+	default:
+		if err := checkParts(parts, attachment.Size); err != nil {
+			return errors.NewValidationError(err)
+		}
+		err := storage.Default.CompleteMultipart(ctx, key, attachment.UploadID, parts)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return errors.NewServerError(err)
+		}
+	}
+
+	if err := db.DB.Model(attachment).Update("upload_id", "").Error; err != nil {
+		return errors.NewServerError(err)
+	}
+	if err := db.DB.First(attachment, "id = ?", attachment.ID).Error; err != nil {
+		return errors.NewServerError(err)
+	}
+	return nil
+}
+
+// checkParts tells what's wrong with the parts of a file of size, if they aren't all there and of their size.
+func checkParts(parts []storage.Part, size int64) error {
+	partSize, count := uploads.Parts(size)
+
+	var total int64
+	for i, p := range parts {
+		if p.Number != i+1 {
+			return fmt.Errorf("part %d is missing", i+1)
+		}
+		if p.Number < count && p.Size != partSize {
+			return fmt.Errorf("part %d is %d bytes, expected %d", p.Number, p.Size, partSize)
+		}
+		total += p.Size
+	}
+	if len(parts) != count || total != size {
+		return fmt.Errorf("%d of %d parts were uploaded, %d of %d bytes", len(parts), count, total, size)
+	}
+	return nil
+}
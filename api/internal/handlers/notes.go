@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"net/mail"
 	"strconv"
-	"strings"
-	"vault/internal/awsx"
 	"vault/internal/db"
 	"vault/internal/errors"
+	"vault/internal/ingest"
 	"vault/internal/models"
+	"vault/internal/search"
+	"vault/internal/storage"
+	"vault/internal/thumbnail"
+	"vault/internal/uploads"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -39,9 +42,28 @@ func CreateNote(c *gin.Context, userID uuid.UUID) (any, error) {
 		return nil, errors.NewValidationError(err)
 	}
 
-	note := models.NewNote(&input, userID)
+	noteType, err := models.NewNoteType(input.Type)
+	if err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	tags, err := models.NewTagNames(input.Tags)
+	if err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	note := models.NewNote(&input, userID, noteType)
 
-	if err := db.DB.Create(&note).Error; err != nil {
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, &note, tags); err != nil {
+			return err
+		}
+		return saveEmbedding(tx, &note)
+	})
+	if err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
@@ -56,76 +78,150 @@ func CreateNote(c *gin.Context, userID uuid.UUID) (any, error) {
 //	@Accept			json
 //	@Produce		json
 //	@ID				getNotes
-//	@Param			page		query		int		false	"Page number"		default(1)
-//	@Param			limit		query		int		false	"Items per page"	default(10)
-//	@Param			q   		query		string	false	"Search query"
+//	@Param			cursor		query		string	false	"Cursor from next_cursor of the previous page"
+//	@Param			limit		query		int		false	"Items per page, at most 100"	default(10)
+//	@Param			page		query		int		false	"Page number, deprecated in favor of cursor"	default(1)
+//	@Param			q   		query		string	false	"Search query, see searchNotes for the syntax, ranks results when set"
 //	@Param			archived	query		bool	false	"Filter by archived status"
 //	@Param			encrypted	query		bool	false	"Filter by encrypted status"
+//	@Param			sort		query		string	false	"Order of the notes, relevance when q has words and newest otherwise"	Enums(newest, oldest, updated, title, relevance)
+//	@Param			count		query		bool	false	"Count the total, false skips it and returns -1"	default(true)
 //	@Success		200			{object}	NotesResponse
+//	@Failure		400			{object}	ErrorResponse	"Invalid query"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
 //	@Failure		500			{object}	ErrorResponse	"Server error"
 //	@Router			/notes [get]
 //	@Security		BearerAuth
 func GetNotes(c *gin.Context, userID uuid.UUID) (any, error) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
-
-	var (
-		archived, archivedSet   = c.GetQuery("archived")
-		encrypted, encryptedSet = c.GetQuery("encrypted")
-		search                  = c.Query("q")
-	)
-
-	var notes []models.NoteWithCount
+	p, err := newPaging(c)
+	if err != nil {
+		return nil, err
+	}
 
-	query := db.DB.
-		Model(&models.Note{}).
-		Joins("JOIN users ON users.id = notes.user_id").
-		Select("notes.*, users.notes_count").
-		Where("notes.user_id = ?", userID).
-		Preload("Attachments").
-		Order("created_at desc").
-		Limit(limit).
-		Offset(offset)
+	query := filterNotes(c, db.DB.Model(&models.Note{}).Where("notes.user_id = ?", userID))
+	selection, args := "notes.*", []any{}
+	rank := ""
 
-	if archivedSet {
-		if v, err := strconv.ParseBool(archived); err == nil {
-			query = query.Where("archived = ?", v)
+	if text := c.Query("q"); text != "" {
+		q, err := search.Parse(text)
+		if err != nil {
+			return nil, invalidQuery(err)
+		}
+		if query, err = applyFilters(query, userID, q.Filters); err != nil {
+			return nil, invalidQuery(err)
+		}
+		if q.HasText() {
+			query = query.
+				Joins("CROSS JOIN to_tsquery('english', ?) AS tsq", q.TSQuery()).
+				Where("notes.search_vector @@ tsq")
+			rank = "ts_rank_cd(?::float4[], notes.search_vector, tsq)"
+			selection, args = "notes.*, "+rank+" AS rank", []any{search.Weights}
 		}
 	}
 
-	if encryptedSet {
-		if v, err := strconv.ParseBool(encrypted); err == nil {
-			query = query.Where("encrypted = ?", v)
-		}
+	// ranked results are best matches first unless sorted otherwise
+	fallback := newestNotes
+	if rank != "" {
+		fallback = keyset{name: string(models.RelevanceSort), column: "notes.created_at", id: "notes.id", rank: rank, rankArgs: args}
+	}
+	order, err := notesOrder(c, fallback, rank, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	if search != "" {
-		tsquery := strings.ReplaceAll(search, " ", " & ")
-		query = query.Where("search_vector @@ to_tsquery('english', ?)", tsquery)
+	total, err := countTotal(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := p.apply(query.Select(selection, args...).Preload("Attachments", readyAttachments).Preload("Tags"), order)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := query.Find(&notes).Error; err != nil {
+	var notes []models.NoteSearchHit
+	if err := page.Find(&notes).Error; err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
-	var out []models.NoteOut
-	total := 0
+	notes, nextCursor := next(p, order, notes, func(n models.NoteSearchHit) cursor {
+		position := cursor{Key: noteKey(order, &n.Note), ID: n.ID}
+		if order.rank != "" {
+			position.Rank = &n.Rank
+		}
+		return position
+	})
 
+	var out []models.NoteOut
 	for _, n := range notes {
-		if total == 0 {
-			total = n.NotesCount
-		}
 		out = append(out, models.NewNoteOut(&n.Note))
 	}
 
 	return models.NotesResponse{
-		Notes: out,
-		Total: total,
+		Notes:      out,
+		Total:      total,
+		NextCursor: nextCursor,
 	}, nil
 }
 
+// replaceTags sets the note's tags, creating the ones its owner hasn't used before.
+func replaceTags(tx *gorm.DB, note *models.Note, names []string) error {
+	var tags []models.Tag
+
+	if len(names) > 0 {
+		fresh := make([]models.Tag, len(names))
+		for i, name := range names {
+			fresh[i] = models.Tag{UserID: note.UserID, Name: name}
+		}
+
+		err := tx.
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "name"}}, DoNothing: true}).
+			Create(&fresh).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// tags that already existed keep their IDs, fetch them all
+		if err := tx.Where("user_id = ? AND name IN ?", note.UserID, names).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+
+	note.Tags = tags
+	if len(tags) == 0 {
+		return tx.Model(note).Association("Tags").Clear()
+	}
+	return tx.Model(note).Association("Tags").Replace(tags)
+}
+
+// filterNotes narrows a notes query down by the archived and encrypted query parameters, when they're set and valid.
+func filterNotes(c *gin.Context, query *gorm.DB) *gorm.DB {
+	var (
+		archived, archivedSet   = c.GetQuery("archived")
+		encrypted, encryptedSet = c.GetQuery("encrypted")
+	)
+
+	if archivedSet {
+		if v, err := strconv.ParseBool(archived); err == nil {
+			query = query.Where("notes.archived = ?", v)
+		}
+	}
+
+	if encryptedSet {
+		if v, err := strconv.ParseBool(encrypted); err == nil {
+			query = query.Where("notes.encrypted = ?", v)
+		}
+	}
+
+	return query
+}
+
+// readyAttachments leaves out attachments whose upload hasn't arrived, is being checked or was rejected.
+func readyAttachments(query *gorm.DB) *gorm.DB {
+	return query.Where("attachments.status = ?", models.ReadyUpload)
+}
+
 // GetNote godoc
 //
 //	@Summary		Get a single note
@@ -156,7 +252,8 @@ func GetNote(c *gin.Context, userID uuid.UUID) (any, error) {
 			userID,
 			userID,
 		).
-		Preload("Attachments").
+		Preload("Attachments", readyAttachments).
+		Preload("Tags").
 		Preload("User")
 
 	// Check if the user is the owner to determine what shares to load
@@ -230,10 +327,30 @@ func EditNote(c *gin.Context, userID uuid.UUID) (any, error) {
 		return nil, errors.NewServerError(err)
 	}
 
-	note.Title = input.Title
-	note.Content = input.Content
+	tags, err := models.NewTagNames(input.Tags)
+	if err != nil {
+		return nil, errors.NewValidationError(err)
+	}
 
-	if err := db.DB.Save(&note).Error; err != nil {
+	if err := note.Edit(&input); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&note).Error; err != nil {
+			return err
+		}
+		// clients that don't know about tags leave them alone
+		if input.Tags == nil {
+			if err := tx.Model(&note).Association("Tags").Find(&note.Tags); err != nil {
+				return err
+			}
+		} else if err := replaceTags(tx, &note, tags); err != nil {
+			return err
+		}
+		return saveEmbedding(tx, &note)
+	})
+	if err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
@@ -289,8 +406,12 @@ func DeleteNote(c *gin.Context, userID uuid.UUID) (any, error) {
 
 // GetUploadURL handles presigned upload URL generation for a specific note.
 //
-//	@Summary		Generate a presigned S3 upload URL
-//	@Description	Generates a presigned URL for uploading an attachment to a specific note
+//	@Summary		Generate a presigned S3 upload form
+//	@Description	Generates a presigned form for uploading an attachment to a specific note.
+//	@Description	Storage only accepts a file of the declared content type and size, which have to be within the configured limits
+//	@Description	and fit in the user's storage quota.
+//	@Description	The attachment is stored under its ID, the file name is what it's downloaded as.
+//	@Description	It's pending until the upload is confirmed or storage reports it, then listed once ready.
 //	@Tags			notes
 //	@ID				getUploadURL
 //	@Accept			json
@@ -298,47 +419,92 @@ func DeleteNote(c *gin.Context, userID uuid.UUID) (any, error) {
 //	@Param			noteId	path		string					true	"Note ID"
 //	@Param			body	body		PresignUploadRequest	true	"Upload parameters"
 //	@Success		200		{object}	PresignUploadResponse
-//	@Failure		400		{object}	ErrorResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid request, or the quota would be exceeded"
 //	@Failure		401		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/notes/{noteId}/attachments [post]
 func GetUploadURL(c *gin.Context, userID uuid.UUID) (any, error) {
+	attachment, policy, err := newUpload(c, userID, uploads.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := savePending(userID, &attachment); err != nil {
+		return nil, err
+	}
+
+	form, err := storage.Default.PresignPost(c.Request.Context(), attachment.UploadKey(), policy)
+	if err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return models.PresignUploadResponse{
+		URL:    form.URL,
+		Fields: form.Fields,
+		Key:    attachment.UploadKey(),
+		ID:     &attachment.ID,
+	}, nil
+}
+
+// newUpload is the pending attachment for the upload the request declares, to a note of the user,
+// and what storage should accept for it, as policy tells for the way it's uploaded.
+func newUpload(
+	c *gin.Context,
+	userID uuid.UUID,
+	policy func(uploads.Kind, string, int64) (storage.UploadPolicy, error),
+) (models.Attachment, storage.UploadPolicy, error) {
 	var input models.PresignUploadRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		return nil, errors.NewValidationError(err)
+		return models.Attachment{}, storage.UploadPolicy{}, errors.NewValidationError(err)
 	}
 
-	noteIDStr := c.Param("noteId")
-	noteID, err := uuid.Parse(noteIDStr)
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		return models.Attachment{}, storage.UploadPolicy{}, errors.NewValidationError(fmt.Errorf("invalid note ID: %w", err))
+	}
 
 	var count int64
 	if err := db.DB.Model(&models.Note{}).
 		Where("id = ? AND user_id = ?", noteID, userID).
 		Count(&count).Error; err != nil {
-		return nil, errors.NewServerError(err)
+		return models.Attachment{}, storage.UploadPolicy{}, errors.NewServerError(err)
 	}
 
 	if count == 0 {
-		return nil, errors.NewForbiddenError("You do not have access to this note", err)
+		return models.Attachment{}, storage.UploadPolicy{}, errors.NewForbiddenError("You do not have access to this note", err)
 	}
 
-	key := models.AttachmentKey(noteIDStr, input.Filename)
-
-	url, err := awsx.GeneratePresignedPutURL(key, input.ContentType)
+	accepted, err := policy(uploads.Attachments, input.ContentType, input.Size)
 	if err != nil {
-		return nil, errors.NewServerError(err)
+		return models.Attachment{}, storage.UploadPolicy{}, errors.NewValidationError(err)
 	}
 
-	return models.PresignUploadResponse{
-		URL: url,
-		Key: key,
-	}, nil
+	return models.NewPendingAttachment(noteID, input.Filename, input.ContentType, input.Size), accepted, nil
+}
+
+// savePending creates a pending attachment, its size reserved in the user's quota.
+func savePending(userID uuid.UUID, attachment *models.Attachment) error {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reserveQuota(tx, userID, attachment.Size); err != nil {
+			return err
+		}
+		return tx.Create(attachment).Error
+	})
+	var quotaErr *errors.ValidationError
+	if errors.As(err, &quotaErr) {
+		return quotaErr
+	}
+	if err != nil {
+		return errors.NewServerError(err)
+	}
+	return nil
 }
 
 // GetDownloadURL godoc
 //
-//	@Summary		Get presigned download URL for an attachment
-//	@Description	Generates a temporary URL for securely downloading a note's attachment.
+//	@Summary		Get an attachment's upload status and download URL
+//	@Description	Tells whether the attachment's upload is still pending, being scanned, ready or was rejected and why.
+//	@Description	Ready attachments come with a temporary URL for securely downloading them under their file name.
 //	@Tags			notes
 //	@ID				getDownloadURL
 //	@Security		BearerAuth
@@ -351,18 +517,73 @@ func GetUploadURL(c *gin.Context, userID uuid.UUID) (any, error) {
 //	@Failure		500				{object}	ErrorResponse
 //	@Router			/notes/{noteId}/attachments/{attachmentId} [get]
 func GetDownloadURL(c *gin.Context, userID uuid.UUID) (any, error) {
-	noteID, err := uuid.Parse(c.Param("noteId"))
+	attachment, err := findAttachment(c, userID)
 	if err != nil {
-		return nil, errors.NewValidationError(fmt.Errorf("invalid note ID"))
+		return nil, err
 	}
 
-	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	return downloadResponse(c, attachment)
+}
+
+// CompleteUpload godoc
+//
+//	@Summary		Confirm an attachment's upload
+//	@Description	Confirms the file was uploaded to the presigned URL. Multipart uploads are completed from their parts,
+//	@Description	which have to be all there. Answers like getDownloadURL: the attachment is scanning until the file
+//	@Description	has been checked, then ready or rejected and why, which getDownloadURL tells.
+//	@Tags			notes
+//	@ID				completeUpload
+//	@Security		BearerAuth
+//	@Param			noteId			path		string	true	"Note ID (UUID)"
+//	@Param			attachmentId	path		string	true	"Attachment ID (UUID)"
+//	@Success		200				{object}	PresignDownloadResponse
+//	@Failure		400				{object}	ErrorResponse	"Nothing or only some parts were uploaded yet"
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/notes/{noteId}/attachments/{attachmentId}/complete [post]
+func CompleteUpload(c *gin.Context, userID uuid.UUID) (any, error) {
+	attachment, err := findAttachment(c, userID)
 	if err != nil {
-		return nil, errors.NewValidationError(fmt.Errorf("invalid attachment ID"))
+		return nil, err
+	}
+
+	if attachment.Status == models.PendingUpload && attachment.UploadID != "" {
+		if err := completeMultipart(c, &attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if attachment.Status == models.PendingUpload {
+		_, err := storage.Default.Head(c.Request.Context(), attachment.UploadKey())
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, errors.NewValidationError(fmt.Errorf("nothing was uploaded for this attachment yet"))
+			}
+			return nil, errors.NewServerError(err)
+		}
+
+		// the file is there and storage has told ingest, which checks it in its own time
+		attachment.Status = models.ScanningUpload
 	}
 
+	return downloadResponse(c, attachment)
+}
+
+// findAttachment is the attachment in the request path, on a note of the user, whatever its upload status.
+func findAttachment(c *gin.Context, userID uuid.UUID) (models.Attachment, error) {
 	var attachment models.Attachment
 
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		return attachment, errors.NewValidationError(fmt.Errorf("invalid note ID"))
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		return attachment, errors.NewValidationError(fmt.Errorf("invalid attachment ID"))
+	}
+
 	err = db.DB.
 		Joins("JOIN notes ON notes.id = attachments.note_id").
 		Where(
@@ -373,14 +594,27 @@ func GetDownloadURL(c *gin.Context, userID uuid.UUID) (any, error) {
 		).
 		First(&attachment).Error
 
-	key := attachment.Key()
-
-	url, err := awsx.GeneratePresignedGetURL(key)
 	if err != nil {
-		return nil, errors.NewServerError(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return attachment, errors.NewNotFoundError("Attachment not found", err)
+		}
+		return attachment, errors.NewServerError(err)
+	}
+	return attachment, nil
+}
+
+func downloadResponse(c *gin.Context, attachment models.Attachment) (models.PresignDownloadResponse, error) {
+	response := models.PresignDownloadResponse{Status: attachment.Status, Reason: attachment.Reason, SHA256: attachment.SHA256}
+	if attachment.Status != models.ReadyUpload {
+		return response, nil
 	}
 
-	return models.PresignDownloadResponse{URL: url}, nil
+	url, err := storage.Default.PresignGet(c.Request.Context(), attachment.Key(), attachment.FileName)
+	if err != nil {
+		return response, errors.NewServerError(err)
+	}
+	response.URL = url
+	return response, nil
 }
 
 // GetDeletedNotes godoc
@@ -391,45 +625,61 @@ func GetDownloadURL(c *gin.Context, userID uuid.UUID) (any, error) {
 //	@Accept			json
 //	@Produce		json
 //	@ID				getDeletedNotes
-//	@Param			page	query		int	false	"Page number"		default(1)
-//	@Param			limit	query		int	false	"Items per page"	default(10)
+//	@Param			cursor	query		string	false	"Cursor from next_cursor of the previous page"
+//	@Param			limit	query		int		false	"Items per page, at most 100"	default(10)
+//	@Param			page	query		int		false	"Page number, deprecated in favor of cursor"	default(1)
+//	@Param			sort	query		string	false	"Order of the notes, most recently deleted first by default"	Enums(newest, oldest, updated, title)
+//	@Param			count	query		bool	false	"Count the total, false skips it and returns -1"	default(true)
 //	@Success		200		{object}	NotesResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid cursor or sort"
 //	@Failure		401		{object}	ErrorResponse	"Unauthorized"
 //	@Failure		500		{object}	ErrorResponse	"Server error"
 //	@Router			/notes/deleted [get]
 //	@Security		BearerAuth
 func GetDeletedNotes(c *gin.Context, userID uuid.UUID) (any, error) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	p, err := newPaging(c)
+	if err != nil {
+		return nil, err
+	}
 
-	var notes []models.NoteWithCount
-	if err := db.DB.
+	order, err := notesOrder(c, keyset{name: "deleted", column: "notes.deleted_at", id: "notes.id"}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.DB.
 		Unscoped().
-		Joins("JOIN users ON users.id = notes.user_id").
-		Select("notes.*, users.notes_count").
-		Where("notes.user_id = ? AND notes.deleted_at IS NOT NULL", userID).
-		Preload("Attachments").
-		Order("deleted_at desc").
-		Limit(limit).
-		Offset(offset).
-		Find(&notes).Error; err != nil {
+		Model(&models.Note{}).
+		Where("notes.user_id = ? AND notes.deleted_at IS NOT NULL", userID)
+
+	total, err := countTotal(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := p.apply(query.Preload("Attachments", readyAttachments).Preload("Tags"), order)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []models.Note
+	if err := page.Find(&notes).Error; err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
-	var out []models.NoteOut
-	total := 0
+	notes, nextCursor := next(p, order, notes, func(n models.Note) cursor {
+		return cursor{Key: noteKey(order, &n), ID: n.ID}
+	})
 
+	var out []models.NoteOut
 	for _, n := range notes {
-		out = append(out, models.NewNoteOut(&n.Note))
-		if total == 0 {
-			total = n.NotesCount
-		}
+		out = append(out, models.NewNoteOut(&n))
 	}
 
 	return models.NotesResponse{
-		Notes: out,
-		Total: total,
+		Notes:      out,
+		Total:      total,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -479,32 +729,35 @@ func RestoreNote(c *gin.Context, userID uuid.UUID) (any, error) {
 //	@Accept			json
 //	@Produce		json
 //	@ID				getAttachments
-//	@Param			page		query		int		false	"Page number"		default(1)
-//	@Param			limit		query		int		false	"Items per page"	default(10)
+//	@Param			cursor		query		string	false	"Cursor from next_cursor of the previous page"
+//	@Param			limit		query		int		false	"Items per page, at most 100"	default(10)
+//	@Param			page		query		int		false	"Page number, deprecated in favor of cursor"	default(1)
 //	@Param			deleted		query		bool	false	"Filter by deleted notes"
 //	@Param			mime_type	query		string	false	"Filter by MIME type"
 //	@Param			note_id		query		string	false	"Filter by note ID"
 //	@Param			sort		query		string	false	"Sort by creation date (asc/desc)"	default(desc)
+//	@Param			count		query		bool	false	"Count the total, false skips it and returns -1"	default(true)
 //	@Success		200			{object}	AttachmentResponse
+//	@Failure		400			{object}	ErrorResponse	"Invalid cursor"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
 //	@Failure		500			{object}	ErrorResponse	"Server error"
 //	@Router			/notes/attachments [get]
 //	@Security		BearerAuth
 func GetAttachments(c *gin.Context, userID uuid.UUID) (any, error) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	p, err := newPaging(c)
+	if err != nil {
+		return nil, err
+	}
 
 	query := db.DB.
 		Model(&models.Attachment{}).
-		Select("attachments.*, notes.*, users.attachment_count").
 		Joins("JOIN notes ON notes.id = attachments.note_id").
-		Joins("JOIN users ON users.id = notes.user_id").
-		Where("notes.user_id = ?", userID)
+		Where("notes.user_id = ?", userID).
+		Scopes(readyAttachments)
 
 	if deleted, ok := c.GetQuery("deleted"); ok {
 		if v, err := strconv.ParseBool(deleted); err == nil && v {
-			query = query.Unscoped().Where("notes.deleted_at IS NOT NULL")
+			query = query.Where("notes.deleted_at IS NOT NULL")
 		} else {
 			query = query.Where("notes.deleted_at IS NULL")
 		}
@@ -520,34 +773,57 @@ func GetAttachments(c *gin.Context, userID uuid.UUID) (any, error) {
 		}
 	}
 
-	sort := c.DefaultQuery("sort", "desc")
-	if sort == "asc" {
-		query = query.Order("attachments.created_at asc")
-	} else {
-		query = query.Order("attachments.created_at desc")
+	order := keyset{name: "newest", column: "attachments.created_at", id: "attachments.id"}
+	if c.DefaultQuery("sort", "desc") == "asc" {
+		order.name, order.ascending = "oldest", true
 	}
 
-	var attachments []struct {
-		models.Attachment
-		models.Note
-		AttachmentsCount int `gorm:"column:attachments_count"`
+	total, err := countTotal(c, query)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := query.Limit(limit).Offset(offset).Find(&attachments).Error; err != nil {
+	page, err := p.apply(query.Select("attachments.*"), order)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []models.Attachment
+	if err := page.Find(&attachments).Error; err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
+	attachments, nextCursor := next(p, order, attachments, func(a models.Attachment) cursor {
+		return cursor{Key: timeKey(a.CreatedAt), ID: a.ID}
+	})
+
+	noteIDs := make([]uuid.UUID, len(attachments))
+	for i, a := range attachments {
+		noteIDs[i] = a.NoteID
+	}
+
+	var notes []models.Note
+	if len(noteIDs) > 0 {
+		if err := db.DB.Unscoped().Where("id IN ?", noteIDs).Preload("Tags").Find(&notes).Error; err != nil {
+			return nil, errors.NewServerError(err)
+		}
+	}
+
+	byID := make(map[uuid.UUID]*models.Note, len(notes))
+	for i := range notes {
+		byID[notes[i].ID] = &notes[i]
+	}
+
 	response := models.AttachmentResponse{
 		Attachments: make([]models.AttachmentRef, len(attachments)),
+		Total:       total,
+		NextCursor:  nextCursor,
 	}
 
 	for i, a := range attachments {
-		response.Attachments[i] = models.AttachmentRef{
-			AttachmentOut: models.NewAttachmentOut(&a.Attachment),
-			NoteOut:       models.NewNoteOut(&a.Note),
-		}
-		if response.Total == 0 {
-			response.Total = a.AttachmentsCount
+		response.Attachments[i] = models.AttachmentRef{AttachmentOut: models.NewAttachmentOut(&a)}
+		if note, ok := byID[a.NoteID]; ok {
+			response.Attachments[i].NoteOut = models.NewNoteOut(note)
 		}
 	}
 
@@ -556,55 +832,55 @@ func GetAttachments(c *gin.Context, userID uuid.UUID) (any, error) {
 
 // DeleteAttachment godoc
 //
-//	@Summary	Delete an attachment
-//	@Tags		notes
-//	@ID			deleteAttachment
-//	@Param		noteId			path	string	true	"Note ID"
-//	@Param		attachmentId	path	string	true	"Attachment ID"
-//	@Success	204				"No Content"
-//	@Failure	400				{object}	ErrorResponse
-//	@Failure	401				{object}	ErrorResponse
-//	@Failure	404				{object}	ErrorResponse
-//	@Router		/notes/{noteId}/attachments/{attachmentId} [delete]
-//	@Security	BearerAuth
+//	@Summary		Delete an attachment
+//	@Description	Deleting an attachment being uploaded in parts aborts the upload and drops its parts.
+//	@Tags			notes
+//	@ID				deleteAttachment
+//	@Param			noteId			path	string	true	"Note ID"
+//	@Param			attachmentId	path	string	true	"Attachment ID"
+//	@Success		204				"No Content"
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Router			/notes/{noteId}/attachments/{attachmentId} [delete]
+//	@Security		BearerAuth
 func DeleteAttachment(c *gin.Context, userID uuid.UUID) (any, error) {
-	noteID, err := uuid.Parse(c.Param("noteId"))
+	attachment, err := findAttachment(c, userID)
 	if err != nil {
-		return nil, errors.NewValidationError(fmt.Errorf("invalid note ID"))
+		return nil, err
 	}
 
-	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
-	if err != nil {
-		return nil, errors.NewValidationError(fmt.Errorf("invalid attachment ID"))
-	}
-
-	var attachment models.Attachment
-	err = db.DB.
-		Joins("JOIN notes ON notes.id = attachments.note_id").
-		Where(
-			"notes.id = ? AND attachments.id = ? AND notes.user_id = ?",
-			noteID,
-			attachmentID,
-			userID,
-		).
-		First(&attachment).Error
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.NewNotFoundError("Attachment not found", err)
+	// delete from storage, with the parts of an unfinished upload and the thumbnails
+	ctx := c.Request.Context()
+	if attachment.UploadID != "" {
+		err := storage.Default.AbortMultipart(ctx, attachment.UploadKey(), attachment.UploadID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, errors.NewServerError(fmt.Errorf("failed to abort the attachment's upload: %w", err))
 		}
-		return nil, errors.NewServerError(err)
 	}
-
-	// delete from S3
-	if err := awsx.DeleteAttachment(attachment.Key()); err != nil {
-		return nil, errors.NewServerError(fmt.Errorf("failed to delete attachment from S3: %w", err))
+	// a blob goes with the last attachment stored in it, once it's deleted from DB, so only an upload replacing it is left
+	key := attachment.Key()
+	if attachment.SHA256 != "" {
+		key = attachment.UploadKey()
+	}
+	if err := storage.Default.Delete(ctx, key); err != nil {
+		return nil, errors.NewServerError(fmt.Errorf("failed to delete attachment from storage: %w", err))
+	}
+	if attachment.Thumbnails {
+		for _, size := range thumbnail.Sizes {
+			if err := storage.Default.Delete(ctx, attachment.ThumbnailKey(size.Name)); err != nil {
+				return nil, errors.NewServerError(fmt.Errorf("failed to delete thumbnail from storage: %w", err))
+			}
+		}
 	}
 
 	// delete from DB
 	if err := db.DB.Delete(&attachment).Error; err != nil {
 		return nil, errors.NewServerError(err)
 	}
+	if attachment.SHA256 != "" {
+		ingest.DropBlob(ctx, storage.Default, attachment.Key())
+	}
 
 	return models.NoContent, nil
 }
@@ -769,42 +1045,77 @@ func RevokeNoteShare(c *gin.Context, userID uuid.UUID) (any, error) {
 // SharedWithMe godoc
 //
 //	@Summary		List shared notes
-//	@Description	Returns paginated notes that have been shared with the authenticated user
+//	@Description	Returns paginated notes that have been shared with the authenticated user through shares that haven't expired
 //	@Tags			notes
 //	@Accept			json
 //	@Produce		json
 //	@ID				getSharedNotes
-//	@Param			page	query		int	false	"Page number"		default(1)
-//	@Param			limit	query		int	false	"Items per page"	default(10)
+//	@Param			cursor	query		string	false	"Cursor from next_cursor of the previous page"
+//	@Param			limit	query		int		false	"Items per page, at most 100"	default(10)
+//	@Param			page	query		int		false	"Page number, deprecated in favor of cursor"	default(1)
+//	@Param			sort	query		string	false	"Order of the notes, most recently shared first by default"	Enums(newest, oldest, updated, title)
+//	@Param			count	query		bool	false	"Count the total, false skips it and returns -1"	default(true)
 //	@Success		200		{object}	NotesResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid cursor or sort"
 //	@Failure		401		{object}	ErrorResponse	"Unauthorized"
 //	@Failure		500		{object}	ErrorResponse	"Server error"
 //	@Router			/notes/shared-with-me [get]
 //	@Security		BearerAuth
 func SharedWithMe(c *gin.Context, userID uuid.UUID) (any, error) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	p, err := newPaging(c)
+	if err != nil {
+		return nil, err
+	}
 
-	var notes []models.Note
-	if err := db.DB.
+	// most recently shared first unless sorted otherwise
+	recentlyShared := keyset{name: "shared", column: "note_shares.created_at", id: "notes.id"}
+	order, err := notesOrder(c, recentlyShared, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// expired shares are left out of both the total and the page
+	query := db.DB.
+		Model(&models.Note{}).
 		Joins("JOIN note_shares ON notes.id = note_shares.note_id").
-		Where("note_shares.shared_with_user_id = ?", userID).
-		Preload("Attachments").
-		Order("note_shares.created_at desc").
-		Limit(limit).
-		Offset(offset).
-		Find(&notes).Error; err != nil {
+		Where("note_shares.shared_with_user_id = ? AND "+liveNoteShare, userID)
+
+	total, err := countTotal(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := p.apply(
+		query.
+			Select("notes.*, note_shares.created_at AS shared_at").
+			Preload("Attachments", readyAttachments).
+			Preload("Tags"),
+		order,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []models.SharedNote
+	if err := page.Find(&notes).Error; err != nil {
 		return nil, errors.NewServerError(err)
 	}
 
+	notes, nextCursor := next(p, order, notes, func(n models.SharedNote) cursor {
+		if order.name == recentlyShared.name {
+			return cursor{Key: timeKey(n.SharedAt), ID: n.ID}
+		}
+		return cursor{Key: noteKey(order, &n.Note), ID: n.ID}
+	})
+
 	var out []models.NoteOut
 	for _, n := range notes {
-		out = append(out, models.NewNoteOut(&n))
+		out = append(out, models.NewNoteOut(&n.Note))
 	}
 
 	return models.NotesResponse{
-		Notes: out,
-		Total: 0,
+		Notes:      out,
+		Total:      total,
+		NextCursor: nextCursor,
 	}, nil
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"vault/internal/errors"
+	"vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+// cursor is the position of the last item of a page: the sort it was made for, the item's value of
+// the sort column and its ID, and its rank when results are ordered by relevance first.
+// Clients get it as an opaque string.
+type cursor struct {
+	Sort string    `json:"s"`
+	Key  string    `json:"k"`
+	ID   uuid.UUID `json:"id"`
+	Rank *float64  `json:"r,omitempty"`
+}
+
+func (c cursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// timeKey is a time as a cursor key, precise enough for Postgres to parse back the same value.
+func timeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// paging is where a page starts: after a cursor, or at an offset for clients still sending page numbers.
+type paging struct {
+	limit  int
+	offset int
+	after  *cursor
+}
+
+// newPaging reads limit, cursor and the deprecated page query parameters.
+// Limits are capped at maxLimit, page numbers get a Deprecation header.
+func newPaging(c *gin.Context) (paging, error) {
+	p := paging{limit: defaultLimit}
+
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		p.limit = min(limit, maxLimit)
+	}
+
+	if s := c.Query("cursor"); s != "" {
+		after, err := parseCursor(s)
+		if err != nil {
+			return p, errors.NewValidationError(err)
+		}
+		p.after = after
+		return p, nil
+	}
+
+	if s, ok := c.GetQuery("page"); ok {
+		c.Header("Deprecation", "true")
+		if page, err := strconv.Atoi(s); err == nil && page > 1 {
+			p.offset = (page - 1) * p.limit
+		}
+	}
+	return p, nil
+}
+
+// keyset is the order of a listing: by a rank expression first when there is one, selected as rank,
+// then by a column and an ID, descending unless ascending is set. Its name ties cursors to it.
+type keyset struct {
+	name      string
+	column    string
+	id        string
+	ascending bool
+	rank      string
+	rankArgs  []any
+}
+
+// Orders of note listings, the sort query parameter picks one.
+var (
+	newestNotes  = keyset{name: string(models.NewestSort), column: "notes.created_at", id: "notes.id"}
+	oldestNotes  = keyset{name: string(models.OldestSort), column: "notes.created_at", id: "notes.id", ascending: true}
+	updatedNotes = keyset{name: string(models.UpdatedSort), column: "notes.updated_at", id: "notes.id"}
+	titleNotes   = keyset{name: string(models.TitleSort), column: "notes.title", id: "notes.id", ascending: true}
+)
+
+// notesOrder is the keyset for the sort query parameter, or fallback when it isn't set.
+// Relevance needs a rank expression, listings without one are ordered newest first instead.
+func notesOrder(c *gin.Context, fallback keyset, rank string, rankArgs ...any) (keyset, error) {
+	value := c.Query("sort")
+	if value == "" {
+		return fallback, nil
+	}
+
+	sort, err := models.NewSearchSort(value)
+	if err != nil {
+		return keyset{}, errors.NewValidationError(err)
+	}
+
+	switch sort {
+	case models.OldestSort:
+		return oldestNotes, nil
+	case models.UpdatedSort:
+		return updatedNotes, nil
+	case models.TitleSort:
+		return titleNotes, nil
+	case models.RelevanceSort:
+		if rank != "" {
+			k := newestNotes
+			k.name, k.rank, k.rankArgs = string(models.RelevanceSort), rank, rankArgs
+			return k, nil
+		}
+	}
+	return newestNotes, nil
+}
+
+// noteKey is a note's value of the column k sorts notes by.
+func noteKey(k keyset, n *models.Note) string {
+	switch k.column {
+	case "notes.updated_at":
+		return timeKey(n.UpdatedAt)
+	case "notes.title":
+		return n.Title
+	case "notes.deleted_at":
+		return timeKey(n.DeletedAt.Time)
+	default:
+		return timeKey(n.CreatedAt)
+	}
+}
+
+// apply orders query by the keyset and narrows it down to the page.
+// One item more than the limit is fetched to tell whether there's a next page.
+func (p paging) apply(query *gorm.DB, k keyset) (*gorm.DB, error) {
+	op, direction := "<", "desc"
+	if k.ascending {
+		op, direction = ">", "asc"
+	}
+
+	if p.after != nil {
+		if p.after.Sort != k.name {
+			return nil, errors.NewValidationError(fmt.Errorf("cursor belongs to a listing with a different sort"))
+		}
+
+		if k.rank != "" && p.after.Rank != nil {
+			args := append(append([]any{}, k.rankArgs...), *p.after.Rank, p.after.Key, p.after.ID)
+			query = query.Where(fmt.Sprintf("(%s, %s, %s) %s (?, ?, ?)", k.rank, k.column, k.id, op), args...)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", k.column, k.id, op), p.after.Key, p.after.ID)
+		}
+	}
+
+	if k.rank != "" {
+		query = query.Order("rank desc")
+	}
+	return query.
+		Order(k.column + " " + direction).
+		Order(k.id + " " + direction).
+		Limit(p.limit + 1).
+		Offset(p.offset), nil
+}
+
+// countTotal counts the items query matches, unless the client skipped counting with count=false,
+// in which case the total is -1. The query must not select or preload anything yet.
+func countTotal(c *gin.Context, query *gorm.DB) (int, error) {
+	if count, err := strconv.ParseBool(c.Query("count")); err == nil && !count {
+		return -1, nil
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, errors.NewServerError(err)
+	}
+	return int(total), nil
+}
+
+// next trims the extra item fetched by apply, returning the cursor of the next page if there is one.
+func next[T any](p paging, k keyset, items []T, position func(T) cursor) ([]T, string) {
+	if len(items) <= p.limit {
+		return items, ""
+	}
+	items = items[:p.limit]
+
+	last := position(items[len(items)-1])
+	last.Sort = k.name
+	return items, last.String()
+}
+
+// offsetPage reads page and limit for listings ranked on the fly, which are paged by offset.
+func offsetPage(c *gin.Context) (limit int, offset int) {
+	limit = defaultLimit
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxLimit)
+	}
+	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 1 {
+		offset = (page - 1) * limit
+	}
+	return limit, offset
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+	"vault/internal/errors"
+	"vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testContext(url string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", url, nil)
+	return c, w
+}
+
+func TestCursor(t *testing.T) {
+	rank := 0.5
+	id := uuid.New()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, c := range []cursor{
+			{Sort: "newest", Key: "2026-10-19T10:00:00.123456Z", ID: id},
+			{Sort: "title", Key: "Groceries, with a comma", ID: id},
+			{Sort: "relevance", Key: "2026-10-19T10:00:00Z", ID: id, Rank: &rank},
+		} {
+			parsed, err := parseCursor(c.String())
+			require.NoError(t, err, c)
+			assert.Equal(t, c, *parsed)
+		}
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		for name, s := range map[string]string{
+			"NotBase64":  "not a cursor!",
+			"NotJSON":    base64.RawURLEncoding.EncodeToString([]byte("newest")),
+			"WithoutID":  base64.RawURLEncoding.EncodeToString([]byte(`{"s":"newest","k":"x"}`)),
+			"Empty":      "",
+			"InvalidID":  base64.RawURLEncoding.EncodeToString([]byte(`{"s":"newest","k":"x","id":"nope"}`)),
+			"WrongTypes": base64.RawURLEncoding.EncodeToString([]byte(`{"s":1,"k":"x","id":"` + id.String() + `"}`)),
+		} {
+			_, err := parseCursor(s)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestNewPaging(t *testing.T) {
+	after := cursor{Sort: "newest", Key: "2026-10-19T10:00:00Z", ID: uuid.New()}
+
+	for _, c := range []struct {
+		name        string
+		url         string
+		want        paging
+		deprecation bool
+		invalid     bool
+	}{
+		{name: "Defaults", url: "/notes", want: paging{limit: defaultLimit}},
+		{name: "Limit", url: "/notes?limit=25", want: paging{limit: 25}},
+		{name: "LimitCapped", url: "/notes?limit=1000", want: paging{limit: maxLimit}},
+		{name: "LimitIgnored", url: "/notes?limit=-3", want: paging{limit: defaultLimit}},
+		{name: "Cursor", url: "/notes?limit=5&cursor=" + after.String(), want: paging{limit: 5, after: &after}},
+		{name: "CursorOverPage", url: "/notes?page=3&cursor=" + after.String(), want: paging{limit: defaultLimit, after: &after}},
+		{name: "InvalidCursor", url: "/notes?cursor=nope", invalid: true},
+		{name: "Page", url: "/notes?page=3&limit=20", want: paging{limit: 20, offset: 40}, deprecation: true},
+		{name: "FirstPage", url: "/notes?page=1", want: paging{limit: defaultLimit}, deprecation: true},
+		{name: "InvalidPage", url: "/notes?page=x", want: paging{limit: defaultLimit}, deprecation: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, w := testContext(c.url)
+			p, err := newPaging(ctx)
+			if c.invalid {
+				var validation *errors.ValidationError
+				assert.True(t, errors.As(err, &validation), err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.want, p)
+			if c.deprecation {
+				assert.Equal(t, "true", w.Header().Get("Deprecation"))
+			} else {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
+
+func TestPagingApply(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+
+	rank := 0.25
+	id := uuid.New()
+	relevance := newestNotes
+	relevance.name, relevance.rank, relevance.rankArgs = string(models.RelevanceSort), "ts_rank(notes.search_vector, plainto_tsquery(?))", []any{"q"}
+
+	for _, c := range []struct {
+		name  string
+		page  paging
+		order keyset
+		where string
+		sort  string
+		vars  []any
+	}{
+		{
+			name:  "Newest",
+			page:  paging{limit: 10},
+			order: newestNotes,
+			sort:  "ORDER BY notes.created_at desc,notes.id desc LIMIT 11",
+		},
+		{
+			name:  "NewestAfter",
+			page:  paging{limit: 10, after: &cursor{Sort: "newest", Key: "k", ID: id}},
+			order: newestNotes,
+			where: "WHERE (notes.created_at, notes.id) < (?, ?)",
+			sort:  "ORDER BY notes.created_at desc,notes.id desc LIMIT 11",
+			vars:  []any{"k", id},
+		},
+		{
+			name:  "TitleAfter",
+			page:  paging{limit: 5, after: &cursor{Sort: "title", Key: "Groceries", ID: id}},
+			order: titleNotes,
+			where: "WHERE (notes.title, notes.id) > (?, ?)",
+			sort:  "ORDER BY notes.title asc,notes.id asc LIMIT 6",
+			vars:  []any{"Groceries", id},
+		},
+		{
+			name:  "Offset",
+			page:  paging{limit: 20, offset: 40},
+			order: oldestNotes,
+			sort:  "ORDER BY notes.created_at asc,notes.id asc LIMIT 21 OFFSET 40",
+		},
+		{
+			name:  "Relevance",
+			page:  paging{limit: 10},
+			order: relevance,
+			sort:  "ORDER BY rank desc,notes.created_at desc,notes.id desc LIMIT 11",
+		},
+		{
+			name:  "RelevanceAfter",
+			page:  paging{limit: 10, after: &cursor{Sort: "relevance", Key: "k", ID: id, Rank: &rank}},
+			order: relevance,
+			where: "WHERE (ts_rank(notes.search_vector, plainto_tsquery(?)), notes.created_at, notes.id) < (?, ?, ?)",
+			sort:  "ORDER BY rank desc,notes.created_at desc,notes.id desc LIMIT 11",
+			vars:  []any{"q", rank, "k", id},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			query, err := c.page.apply(db.Table("notes"), c.order)
+			require.NoError(t, err)
+
+			stmt := query.Find(&[]models.Note{}).Statement
+			sql := stmt.SQL.String()
+			if c.where != "" {
+				assert.Contains(t, sql, c.where)
+			} else {
+				assert.NotContains(t, sql, "notes.id) ")
+			}
+			assert.Contains(t, sql, c.sort)
+			assert.Equal(t, c.vars, vars(stmt.Vars))
+		})
+	}
+
+	t.Run("RejectsCursorOfAnotherSort", func(t *testing.T) {
+		page := paging{limit: 10, after: &cursor{Sort: "title", Key: "k", ID: id}}
+		_, err := page.apply(db.Table("notes"), newestNotes)
+
+		var validation *errors.ValidationError
+		assert.True(t, errors.As(err, &validation), err)
+	})
+}
+
+// vars are the arguments of a statement, leaving out the limit and offset, which the SQL holds.
+func vars(all []any) []any {
+	var out []any
+	for _, v := range all {
+		if _, ok := v.(int); !ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func TestNext(t *testing.T) {
+	position := func(n int) cursor {
+		return cursor{Key: string(rune('a' + n)), ID: uuid.NewSHA1(uuid.Nil, []byte{byte(n)})}
+	}
+
+	for _, c := range []struct {
+		name  string
+		items []int
+		limit int
+		want  []int
+		last  int // of the page, -1 when there's no next one
+	}{
+		{name: "Empty", items: nil, limit: 3, want: nil, last: -1},
+		{name: "Short", items: []int{0, 1}, limit: 3, want: []int{0, 1}, last: -1},
+		{name: "Full", items: []int{0, 1, 2}, limit: 3, want: []int{0, 1, 2}, last: -1},
+		{name: "More", items: []int{0, 1, 2, 3}, limit: 3, want: []int{0, 1, 2}, last: 2},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			items, next := next(paging{limit: c.limit}, titleNotes, c.items, position)
+			assert.Equal(t, c.want, items)
+			if c.last < 0 {
+				assert.Empty(t, next)
+				return
+			}
+
+			after, err := parseCursor(next)
+			require.NoError(t, err)
+			want := position(c.last)
+			want.Sort = titleNotes.name
+			assert.Equal(t, want, *after)
+		})
+	}
+}
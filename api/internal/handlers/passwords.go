@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+	"vault/internal/passwords"
+)
+
+// GetPasswordHealth godoc
+//
+//	@Summary		Password health report
+//	@Description	Checks passwords stored in the user's login items for breaches, reuse and weak strength.
+//	@Description	Breach checks run against a locally loaded hash corpus, passwords never leave the server.
+//	@Tags			auth
+//	@ID				getPasswordHealth
+//	@Produce		json
+//	@Success		200	{object}	PasswordHealthReport
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	ErrorResponse	"Server error"
+//	@Router			/me/password-health [get]
+//	@Security		BearerAuth
+func GetPasswordHealth(_ *gin.Context, userID uuid.UUID) (any, error) {
+	var notes []models.Note
+	if err := db.DB.
+		Where("user_id = ? AND type = ? AND encrypted = ?", userID, models.LoginNote, false).
+		Order("created_at desc").
+		Find(&notes).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	items := make([]passwords.Item, 0, len(notes))
+	for _, n := range notes {
+		items = append(items, passwords.Item{
+			NoteID:   n.ID,
+			Title:    n.Title,
+			Password: n.Password(),
+		})
+	}
+
+	report, err := passwords.Check(items, passwords.Breaches)
+	if err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return newPasswordHealthReport(report), nil
+}
+
+// newPasswordHealthReport is the report as the API returns it.
+func newPasswordHealthReport(r passwords.Report) models.PasswordHealthReport {
+	items := make([]models.PasswordHealthItem, len(r.Findings))
+	for i, f := range r.Findings {
+		issues := make([]string, len(f.Issues))
+		for j, issue := range f.Issues {
+			issues[j] = string(issue)
+		}
+		items[i] = models.PasswordHealthItem{
+			NoteID:      f.NoteID,
+			Title:       f.Title,
+			Issues:      issues,
+			BreachCount: f.BreachCount,
+			Strength:    int(f.Strength),
+			ReusedWith:  f.ReusedWith,
+		}
+	}
+
+	return models.PasswordHealthReport{
+		Checked:     r.Checked,
+		BreachCheck: r.BreachCheck,
+		Breached:    r.Breached,
+		Reused:      r.Reused,
+		Weak:        r.Weak,
+		Items:       items,
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"vault/internal/db"
+	"vault/internal/embed"
+	"vault/internal/errors"
+	"vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minSimilarity is how alike two notes have to be to count as related.
+// Unrelated texts score around zero with hashed embeddings.
+const minSimilarity = 0.1
+
+// maxSimilar is how many of the notes closest to a vector are looked at, nearest first, which with pgvector
+// its index finds without comparing every embedding. Related notes and semantic results stop there.
+const maxSimilar = 200
+
+// GetRelatedNotes godoc
+//
+//	@Summary		Related notes
+//	@Description	Lists notes about the same things as the given one, most similar first,
+//	@Description	among the user's notes and the ones shared with them. The content of encrypted notes is not compared.
+//	@Tags			notes
+//	@ID				getRelatedNotes
+//	@Produce		json
+//	@Param			noteId	path		string	true	"Note UUID"
+//	@Param			page	query		int		false	"Page number"		default(1)
+//	@Param			limit	query		int		false	"Items per page, at most 100"	default(10)
+//	@Success		200		{object}	SearchResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/notes/{noteId}/related [get]
+//	@Security		BearerAuth
+func GetRelatedNotes(c *gin.Context, userID uuid.UUID) (any, error) {
+	limit, offset := offsetPage(c)
+
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Errorf("invalid note ID: %w", err))
+	}
+
+	var note models.Note
+	if err := db.DB.
+		Where("notes.id = ? AND (notes.user_id = ? OR "+liveShare+")", noteID, userID, userID).
+		Preload("Tags").
+		First(&note).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("Note not found", err)
+		}
+		return nil, errors.NewServerError(err)
+	}
+
+	req := searchRequest{
+		scope:  models.AllScope,
+		sort:   models.RelevanceSort,
+		limit:  limit,
+		offset: offset,
+	}
+
+	base, err := scopeSearch(db.DB.Model(&models.Note{}).Where("notes.id <> ?", noteID), userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	embedding := models.NewNoteEmbedding(&note, embed.Default)
+	out, err := similarNotes(base, userID, req, embedding.Embedding)
+	if err != nil {
+		return nil, errors.NewServerError(err)
+	}
+	return out, nil
+}
+
+// semanticSearch finds the notes in base closest in meaning to the words of the query.
+func semanticSearch(base *gorm.DB, userID uuid.UUID, req searchRequest) (models.SearchResponse, error) {
+	text := strings.Join(req.query.Words(), " ")
+
+	out, err := similarNotes(base, userID, req, embed.Default.Embed(text))
+	if err != nil {
+		return out, errors.NewServerError(err)
+	}
+	return out, nil
+}
+
+// similarNotes pages through the notes in base similar to vector, the similarity being their rank.
+func similarNotes(base *gorm.DB, userID uuid.UUID, req searchRequest, vector models.Vector) (models.SearchResponse, error) {
+	if embed.Cosine(vector, vector) == 0 {
+		return models.SearchResponse{Results: []models.SearchResult{}}, nil
+	}
+
+	// the threshold is applied to the nearest notes, filtering on it first would compare every embedding
+	query := db.DB.
+		Model(&models.Note{}).
+		Joins("JOIN (?) AS similar ON similar.id = notes.id", nearestNotes(base, vector, hasVectorColumn())).
+		Where("similar.similarity >= ?", minSimilarity)
+
+	return searchPage(query, userID, req, "notes.*, similar.similarity AS rank, "+plainHeadlines)
+}
+
+// hasVectorColumn tells whether the pgvector migration could add its indexed column.
+var hasVectorColumn = sync.OnceValue(func() bool {
+	return db.DB.Migrator().HasColumn(&models.NoteEmbedding{}, "vector")
+})
+
+// nearestNotes selects the IDs of the maxSimilar notes in base closest to vector, with their cosine similarity
+// to it: ordered by pgvector's distance so its index is used when indexed, computed over the real[] embedding otherwise.
+func nearestNotes(base *gorm.DB, vector models.Vector, indexed bool) *gorm.DB {
+	query := base.Joins(
+		"JOIN note_embeddings ON note_embeddings.note_id = notes.id AND note_embeddings.model = ?",
+		embed.Default.Name(),
+	)
+
+	if indexed {
+		distance := clause.Expr{SQL: "note_embeddings.vector <=> ?::vector", Vars: []any{vector.Literal()}}
+		query = query.
+			Select("notes.id, 1 - (?) AS similarity", distance).
+			Clauses(clause.OrderBy{Expression: distance})
+	} else {
+		query = query.
+			Select("notes.id, cosine_similarity(note_embeddings.embedding, ?::real[]) AS similarity", vector).
+			Order("similarity desc")
+	}
+	return query.Limit(maxSimilar)
+}
+
+// saveEmbedding embeds a note that was created or changed. Its tags have to be loaded.
+func saveEmbedding(tx *gorm.DB, note *models.Note) error {
+	embedding := models.NewNoteEmbedding(note, embed.Default)
+	return tx.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "note_id"}}, UpdateAll: true}).
+		Create(&embedding).
+		Error
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNearestNotes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+
+	vector := models.Vector{0.6, 0.8}
+	base := db.Model(&models.Note{}).Where("notes.user_id = ?", "me").Session(&gorm.Session{})
+
+	t.Run("Indexed", func(t *testing.T) {
+		stmt := nearestNotes(base, vector, true).Find(&[]models.Note{}).Statement
+		sql := stmt.SQL.String()
+
+		assert.Contains(t, sql, "SELECT notes.id, 1 - (note_embeddings.vector <=> ?::vector) AS similarity")
+		// ordered by the distance itself, which the HNSW index answers, and nothing filters on it
+		assert.Contains(t, sql, "ORDER BY note_embeddings.vector <=> ?::vector LIMIT 200")
+		assert.NotContains(t, sql, ">=")
+		assert.Equal(t, []any{vector.Literal(), "hashed-v1", "me", vector.Literal()}, vars(stmt.Vars))
+	})
+
+	t.Run("Arrays", func(t *testing.T) {
+		stmt := nearestNotes(base, vector, false).Find(&[]models.Note{}).Statement
+		sql := stmt.SQL.String()
+
+		assert.Contains(t, sql, "SELECT notes.id, cosine_similarity(note_embeddings.embedding, ?::real[]) AS similarity")
+		assert.Contains(t, sql, "ORDER BY similarity desc LIMIT 200")
+	})
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+	"vault/internal/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SearchNotes godoc
+//
+//	@Summary		Search notes
+//	@Description	Full-text search over the user's notes and the ones shared with them through live shares,
+//	@Description	best matches first. Each result carries the user's permission on the note.
+//	@Description	Supports "quoted phrases", -exclusion, OR and prefix* terms, and operators that can be negated too:
+//	@Description	tag:name, has:attachment, mime:type/subtype or mime:type/*, is:archived|encrypted|shared, type:note|login,
+//	@Description	owner:me|username|email, before:YYYY-MM-DD, after:YYYY-MM-DD and created: or updated: with a day or a range like 2025-01-01..2025-06-30.
+//	@Description	Invalid operators are listed in the details of the 400 response, other words with a colon are searched as text.
+//	@Description	Snippets are HTML-escaped with matches wrapped in <mark>.
+//	@Description	When nothing matches, a suggestion built from words in the user's notes may be returned,
+//	@Description	and with fuzzy=true notes with similar titles or content are returned instead.
+//	@Description	With mode=semantic notes are ranked by how close they are in meaning to the words of the query,
+//	@Description	operators still apply but quotes, exclusions and OR don't.
+//	@Tags			notes
+//	@Produce		json
+//	@ID				searchNotes
+//	@Param			q			query		string	true	"Search query"
+//	@Param			page		query		int		false	"Page number"		default(1)
+//	@Param			limit		query		int		false	"Items per page, at most 100"	default(10)
+//	@Param			archived	query		bool	false	"Filter by archived status"
+//	@Param			encrypted	query		bool	false	"Filter by encrypted status"
+//	@Param			fuzzy		query		bool	false	"Fall back to similar titles and content when nothing matches"
+//	@Param			scope		query		string	false	"Own notes, notes shared with the user or both"	Enums(all, mine, shared)	default(all)
+//	@Param			sort		query		string	false	"Order of results"	Enums(relevance, newest, oldest, updated, title)	default(relevance)
+//	@Param			mode		query		string	false	"Match keywords or meaning"	Enums(keyword, semantic)	default(keyword)
+//	@Success		200			{object}	SearchResponse
+//	@Failure		400			{object}	ErrorResponse	"Invalid query"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500			{object}	ErrorResponse	"Server error"
+//	@Router			/notes/search [get]
+//	@Security		BearerAuth
+func SearchNotes(c *gin.Context, userID uuid.UUID) (any, error) {
+	limit, offset := offsetPage(c)
+	fuzzy, _ := strconv.ParseBool(c.Query("fuzzy"))
+
+	req, err := newSearchRequest(c.Query("q"), c.Query("scope"), c.Query("sort"))
+	if err != nil {
+		return nil, err
+	}
+	req.fuzzy, req.limit, req.offset = fuzzy, limit, offset
+
+	if req.mode, err = models.NewSearchMode(c.Query("mode")); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	out, err := runSearch(filterNotes(c, db.DB.Model(&models.Note{})), userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// searchRequest is a parsed search, typed into the search box or saved.
+type searchRequest struct {
+	query  search.Query
+	scope  models.SearchScope
+	sort   models.SearchSort
+	mode   models.SearchMode
+	fuzzy  bool
+	limit  int
+	offset int
+}
+
+func newSearchRequest(text string, scope string, sort string) (searchRequest, error) {
+	var (
+		req searchRequest
+		err error
+	)
+
+	if req.query, err = search.Parse(text); err != nil {
+		return req, invalidQuery(err)
+	}
+	if req.scope, err = models.NewSearchScope(scope); err != nil {
+		return req, errors.NewValidationError(err)
+	}
+	if req.sort, err = models.NewSearchSort(sort); err != nil {
+		return req, errors.NewValidationError(err)
+	}
+	return req, nil
+}
+
+// runSearch runs a search over the notes in base, falling back to similar notes when asked to.
+func runSearch(base *gorm.DB, userID uuid.UUID, req searchRequest) (models.SearchResponse, error) {
+	q := req.query
+	if q.Empty() {
+		return models.SearchResponse{Results: []models.SearchResult{}}, nil
+	}
+
+	base, err := scopeSearch(base, userID, req)
+	if err != nil {
+		return models.SearchResponse{}, err
+	}
+
+	if !q.HasText() {
+		out, err := searchPage(base, userID, req, "notes.*, 0 AS rank, "+plainHeadlines)
+		if err != nil {
+			return out, errors.NewServerError(err)
+		}
+		return out, nil
+	}
+
+	if req.mode == models.SemanticMode {
+		return semanticSearch(base, userID, req)
+	}
+
+	out, err := searchPage(
+		matchText(base, q),
+		userID,
+		req,
+		`notes.*,
+		ts_rank_cd(?::float4[], notes.search_vector, tsq) AS rank,
+		ts_headline('english', notes.title, tsq, ?) AS title_headline,
+		CASE WHEN notes.encrypted THEN '' ELSE ts_headline('english', notes.content, tsq, ?) END AS content_headline`,
+		search.Weights,
+		search.TitleHeadline,
+		search.ContentHeadline,
+	)
+	if err != nil {
+		return out, errors.NewServerError(err)
+	}
+
+	if out.Total > 0 {
+		return out, nil
+	}
+
+	// nothing matched, the query may have a typo in it
+	suggestion, err := suggest(userID, q)
+	if err != nil {
+		return out, errors.NewServerError(err)
+	}
+	out.Suggestion = suggestion
+
+	text := strings.Join(q.Words(), " ")
+	if !req.fuzzy || text == "" {
+		return out, nil
+	}
+
+	similar := base.Where("(notes.title % ? OR (NOT notes.encrypted AND ? <% notes.content))", text, text)
+
+	out, err = searchPage(
+		similar,
+		userID,
+		req,
+		`notes.*,
+		GREATEST(similarity(notes.title, ?), CASE WHEN notes.encrypted THEN 0 ELSE word_similarity(?, notes.content) END) AS rank, `+
+			plainHeadlines,
+		text,
+		text,
+	)
+	if err != nil {
+		return out, errors.NewServerError(err)
+	}
+
+	out.Suggestion, out.Fuzzy = suggestion, true
+	return out, nil
+}
+
+// countSearch counts the notes a search matches, without the fuzzy fallback.
+func countSearch(userID uuid.UUID, req searchRequest) (int64, error) {
+	if req.query.Empty() {
+		return 0, nil
+	}
+
+	query, err := scopeSearch(db.DB.Model(&models.Note{}), userID, req)
+	if err != nil {
+		return 0, err
+	}
+	if req.query.HasText() {
+		query = matchText(query, req.query)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, errors.NewServerError(err)
+	}
+	return total, nil
+}
+
+// scopeSearch narrows base down to the notes in the request's scope that pass its operators.
+// The result can be reused for several queries.
+func scopeSearch(base *gorm.DB, userID uuid.UUID, req searchRequest) (*gorm.DB, error) {
+	switch req.scope {
+	case models.MineScope:
+		base = base.Where("notes.user_id = ?", userID)
+	case models.SharedScope:
+		base = base.Where(liveShare, userID)
+	default:
+		base = base.Where("(notes.user_id = ? OR "+liveShare+")", userID, userID)
+	}
+
+	base, err := applyFilters(base, userID, req.query.Filters)
+	if err != nil {
+		return nil, invalidQuery(err)
+	}
+	return base.Session(&gorm.Session{}), nil
+}
+
+// matchText keeps the notes that match the full-text part of q, exposing the tsquery as tsq.
+func matchText(query *gorm.DB, q search.Query) *gorm.DB {
+	return query.
+		Joins("CROSS JOIN to_tsquery('english', ?) AS tsq", q.TSQuery()).
+		Where("notes.search_vector @@ tsq")
+}
+
+// liveShare matches notes shared with a user through a share that hasn't expired.
+const liveShare = `EXISTS (SELECT 1 FROM note_shares WHERE note_shares.note_id = notes.id
+	AND note_shares.shared_with_user_id = ? AND (note_shares.expires IS NULL OR note_shares.expires > NOW()))`
+
+// permissionColumn is the caller's permission on a note, the strongest of their live shares if they don't own it:
+// write over read, ranked rather than compared as text.
+const permissionColumn = `CASE WHEN notes.user_id = ? THEN 'owner' ELSE (
+	SELECT note_shares.permission FROM note_shares WHERE note_shares.note_id = notes.id
+	AND note_shares.shared_with_user_id = ? AND (note_shares.expires IS NULL OR note_shares.expires > NOW())
+	ORDER BY CASE note_shares.permission WHEN 'write' THEN 1 ELSE 0 END DESC LIMIT 1
+) END AS permission`
+
+// plainHeadlines stand in for ts_headline when there's no full-text match to highlight.
+const plainHeadlines = `notes.title AS title_headline,
+		CASE WHEN notes.encrypted THEN '' ELSE left(notes.content, 200) END AS content_headline`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// applyFilters turns search operators into conditions on notes.
+func applyFilters(query *gorm.DB, userID uuid.UUID, filters []search.Filter) (*gorm.DB, error) {
+	for _, f := range filters {
+		var (
+			condition string
+			args      []any
+		)
+
+		switch f.Operator {
+		case search.TagOperator:
+			condition = `EXISTS (SELECT 1 FROM note_tags JOIN tags ON tags.id = note_tags.tag_id
+				WHERE note_tags.note_id = notes.id AND tags.name = ?)`
+			args = []any{f.Value}
+		case search.HasOperator:
+			condition = "EXISTS (SELECT 1 FROM attachments WHERE attachments.note_id = notes.id AND attachments.status = 'ready')"
+		case search.MimeOperator:
+			condition = "EXISTS (SELECT 1 FROM attachments WHERE attachments.note_id = notes.id AND attachments.status = 'ready' AND attachments.mime_type = ?)"
+			args = []any{f.Value}
+			if family, ok := strings.CutSuffix(f.Value, "/*"); ok {
+				condition = "EXISTS (SELECT 1 FROM attachments WHERE attachments.note_id = notes.id AND attachments.status = 'ready' AND attachments.mime_type LIKE ?)"
+				args = []any{likeEscaper.Replace(family) + "/%"}
+			}
+		case search.IsOperator:
+			switch f.Value {
+			case search.IsArchived:
+				condition = "notes.archived = TRUE"
+			case search.IsEncrypted:
+				condition = "notes.encrypted = TRUE"
+			case search.IsShared:
+				condition = `EXISTS (SELECT 1 FROM note_shares WHERE note_shares.note_id = notes.id
+					AND (note_shares.expires IS NULL OR note_shares.expires > NOW()))`
+			}
+		case search.TypeOperator:
+			noteType, err := models.NewNoteType(f.Value)
+			if err != nil {
+				return nil, &search.OperatorError{Invalid: []string{fmt.Sprintf("%s: %s", f, err)}}
+			}
+			condition, args = "notes.type = ?", []any{noteType}
+		case search.OwnerOperator:
+			condition, args = "notes.user_id = ?", []any{userID}
+			if f.Value != search.OwnerMe {
+				condition = "EXISTS (SELECT 1 FROM users WHERE users.id = notes.user_id AND (users.username = ? OR users.email = ?))"
+				args = []any{f.Value, f.Value}
+			}
+		case search.CreatedOperator, search.UpdatedOperator:
+			column := "notes.created_at"
+			if f.Operator == search.UpdatedOperator {
+				column = "notes.updated_at"
+			}
+
+			var bounds []string
+			if !f.From.IsZero() {
+				bounds, args = append(bounds, column+" >= ?"), append(args, f.From)
+			}
+			if !f.To.IsZero() {
+				bounds, args = append(bounds, column+" < ?"), append(args, f.To)
+			}
+			condition = strings.Join(bounds, " AND ")
+		default:
+			continue
+		}
+
+		if f.Negated {
+			condition = "NOT (" + condition + ")"
+		}
+		query = query.Where(condition, args...)
+	}
+
+	return query, nil
+}
+
+// invalidQuery reports a query that couldn't be parsed, listing the operators at fault.
+func invalidQuery(err error) error {
+	var opErr *search.OperatorError
+	if errors.As(err, &opErr) {
+		return errors.NewValidationErrorWithDetails(err, opErr.Details())
+	}
+	return errors.NewValidationError(err)
+}
+
+// searchPage counts the notes matched by query and fetches a page of them in the requested order,
+// along with the user's permission on each. The selection has to provide the rank and headline
+// columns of models.NoteSearchHit.
+func searchPage(query *gorm.DB, userID uuid.UUID, req searchRequest, selection string, args ...any) (models.SearchResponse, error) {
+	out := models.SearchResponse{Results: []models.SearchResult{}}
+
+	// reused for the count and the page
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return out, err
+	}
+	out.Total = int(total)
+
+	if total == 0 {
+		return out, nil
+	}
+
+	query = query.
+		Select(selection+", "+permissionColumn, append(args, userID, userID)...).
+		Preload("User").
+		Preload("Attachments", readyAttachments).
+		Preload("Tags")
+
+	for _, order := range searchOrder(req.sort) {
+		query = query.Order(order)
+	}
+
+	var hits []models.NoteSearchHit
+	err := query.
+		Limit(req.limit).
+		Offset(req.offset).
+		Find(&hits).
+		Error
+
+	if err != nil {
+		return out, err
+	}
+
+	for i := range hits {
+		out.Results = append(out.Results, models.NewSearchResult(&hits[i]))
+	}
+	return out, nil
+}
+
+func searchOrder(sort models.SearchSort) []string {
+	switch sort {
+	case models.NewestSort:
+		return []string{"notes.created_at desc"}
+	case models.OldestSort:
+		return []string{"notes.created_at asc"}
+	case models.UpdatedSort:
+		return []string{"notes.updated_at desc"}
+	case models.TitleSort:
+		return []string{"notes.title asc", "notes.created_at desc"}
+	default:
+		return []string{"rank desc", "notes.created_at desc"}
+	}
+}
+
+// suggest builds a "did you mean" query by replacing words that occur in none of the user's notes
+// with the closest ones that do. Returns an empty string when there's nothing to correct.
+func suggest(userID uuid.UUID, q search.Query) (string, error) {
+	words := q.Words()
+	if len(words) == 0 {
+		return "", nil
+	}
+
+	var rows []struct {
+		Word       string
+		Suggestion *string
+	}
+
+	// sanitized words have no spaces in them
+	err := db.DB.
+		Raw("SELECT word, suggestion FROM suggest_words(?, string_to_array(?, ' '))", userID, strings.Join(words, " ")).
+		Scan(&rows).
+		Error
+
+	if err != nil {
+		return "", err
+	}
+
+	corrections := make(map[string]string)
+	for _, row := range rows {
+		if row.Suggestion != nil && *row.Suggestion != row.Word {
+			corrections[row.Word] = *row.Suggestion
+		}
+	}
+
+	if len(corrections) == 0 {
+		return "", nil
+	}
+	return q.Correct(corrections).String(), nil
+}
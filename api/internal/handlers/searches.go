@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetSavedSearches godoc
+//
+//	@Summary		List saved searches
+//	@Description	Lists the user's saved searches, pinned ones first.
+//	@Description	With counts=true each search is run to count the notes it currently matches, for sidebar badges.
+//	@Tags			searches
+//	@ID				getSavedSearches
+//	@Produce		json
+//	@Param			counts	query		bool	false	"Count the notes each search matches"
+//	@Success		200		{object}	SavedSearchesResponse
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	ErrorResponse	"Server error"
+//	@Router			/me/searches [get]
+//	@Security		BearerAuth
+func GetSavedSearches(c *gin.Context, userID uuid.UUID) (any, error) {
+	counts, _ := strconv.ParseBool(c.Query("counts"))
+
+	var searches []models.SavedSearch
+	if err := db.DB.
+		Where("user_id = ?", userID).
+		Order("pinned desc").
+		Order("name asc").
+		Find(&searches).
+		Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	out := models.SavedSearchesResponse{Searches: make([]models.SavedSearchOut, 0, len(searches))}
+	for i := range searches {
+		s := models.NewSavedSearchOut(&searches[i])
+		if counts {
+			count, err := countSavedSearch(userID, &searches[i])
+			if err != nil {
+				return nil, err
+			}
+			s.Count = count
+		}
+		out.Searches = append(out.Searches, s)
+	}
+
+	return out, nil
+}
+
+// CreateSavedSearch godoc
+//
+//	@Summary		Save a search
+//	@Description	Saves a search query under a name. The query is checked the same way as in note search.
+//	@Tags			searches
+//	@ID				createSavedSearch
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		SavedSearchIn	true	"Saved search"
+//	@Success		200		{object}	SavedSearchOut
+//	@Failure		400		{object}	ErrorResponse	"Invalid query or too many saved searches"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	ErrorResponse	"Server error"
+//	@Router			/me/searches [post]
+//	@Security		BearerAuth
+func CreateSavedSearch(c *gin.Context, userID uuid.UUID) (any, error) {
+	var input models.SavedSearchIn
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	req, err := validateSavedSearch(&input)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := db.DB.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&existing).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+	if existing >= models.MaxSavedSearches {
+		return nil, errors.NewValidationError(fmt.Errorf("you can save at most %d searches", models.MaxSavedSearches))
+	}
+
+	saved := models.NewSavedSearch(&input, userID, req.scope, req.sort)
+	if err := db.DB.Create(&saved).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return models.NewSavedSearchOut(&saved), nil
+}
+
+// GetSavedSearch godoc
+//
+//	@Summary		Get a saved search
+//	@Description	Retrieves a saved search along with the number of notes it currently matches
+//	@Tags			searches
+//	@ID				getSavedSearch
+//	@Produce		json
+//	@Param			searchId	path		string	true	"Saved search UUID"
+//	@Success		200			{object}	SavedSearchOut
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/me/searches/{searchId} [get]
+//	@Security		BearerAuth
+func GetSavedSearch(c *gin.Context, userID uuid.UUID) (any, error) {
+	saved, err := findSavedSearch(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := models.NewSavedSearchOut(saved)
+	if out.Count, err = countSavedSearch(userID, saved); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EditSavedSearch godoc
+//
+//	@Summary		Edit a saved search
+//	@Description	Renames, pins or changes the query of a saved search
+//	@Tags			searches
+//	@ID				editSavedSearch
+//	@Accept			json
+//	@Produce		json
+//	@Param			searchId	path		string			true	"Saved search UUID"
+//	@Param			body		body		SavedSearchIn	true	"Saved search"
+//	@Success		200			{object}	SavedSearchOut
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/me/searches/{searchId} [put]
+//	@Security		BearerAuth
+func EditSavedSearch(c *gin.Context, userID uuid.UUID) (any, error) {
+	saved, err := findSavedSearch(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var input models.SavedSearchIn
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	req, err := validateSavedSearch(&input)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := models.NewSavedSearch(&input, userID, req.scope, req.sort)
+	saved.Name, saved.Query, saved.Scope, saved.Sort, saved.Pinned = edited.Name, edited.Query, edited.Scope, edited.Sort, edited.Pinned
+
+	if err := db.DB.Save(saved).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return models.NewSavedSearchOut(saved), nil
+}
+
+// DeleteSavedSearch godoc
+//
+//	@Summary		Delete a saved search
+//	@Tags			searches
+//	@ID				deleteSavedSearch
+//	@Param			searchId	path	string	true	"Saved search UUID"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/me/searches/{searchId} [delete]
+//	@Security		BearerAuth
+func DeleteSavedSearch(c *gin.Context, userID uuid.UUID) (any, error) {
+	saved, err := findSavedSearch(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Delete(saved).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return models.NoContent, nil
+}
+
+// RunSavedSearch godoc
+//
+//	@Summary		Run a saved search
+//	@Description	Runs a saved search with its own scope and sort, a page at a time
+//	@Tags			searches
+//	@ID				runSavedSearch
+//	@Produce		json
+//	@Param			searchId	path		string	true	"Saved search UUID"
+//	@Param			page		query		int		false	"Page number"		default(1)
+//	@Param			limit		query		int		false	"Items per page, at most 100"	default(10)
+//	@Success		200			{object}	SearchResponse
+//	@Failure		400			{object}	ErrorResponse	"The saved query is no longer valid"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Saved search not found"
+//	@Failure		500			{object}	ErrorResponse	"Server error"
+//	@Router			/me/searches/{searchId}/results [get]
+//	@Security		BearerAuth
+func RunSavedSearch(c *gin.Context, userID uuid.UUID) (any, error) {
+	limit, offset := offsetPage(c)
+
+	saved, err := findSavedSearch(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newSearchRequest(saved.Query, string(saved.Scope), string(saved.Sort))
+	if err != nil {
+		return nil, err
+	}
+	req.limit, req.offset = limit, offset
+
+	out, err := runSearch(db.DB.Model(&models.Note{}), userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func findSavedSearch(c *gin.Context, userID uuid.UUID) (*models.SavedSearch, error) {
+	searchID, err := uuid.Parse(c.Param("searchId"))
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Errorf("invalid search ID: %w", err))
+	}
+
+	var saved models.SavedSearch
+	if err := db.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&saved).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("Saved search not found", err)
+		}
+		return nil, errors.NewServerError(err)
+	}
+	return &saved, nil
+}
+
+// validateSavedSearch checks a saved search the way note search would check its query.
+func validateSavedSearch(in *models.SavedSearchIn) (searchRequest, error) {
+	name, query := strings.TrimSpace(in.Name), strings.TrimSpace(in.Query)
+
+	switch {
+	case name == "":
+		return searchRequest{}, errors.NewValidationError(fmt.Errorf("name is required"))
+	case utf8.RuneCountInString(name) > models.MaxSavedSearchName:
+		return searchRequest{}, errors.NewValidationError(fmt.Errorf("name is longer than %d characters", models.MaxSavedSearchName))
+	case len(query) > models.MaxSavedSearchQuery:
+		return searchRequest{}, errors.NewValidationError(fmt.Errorf("query is longer than %d characters", models.MaxSavedSearchQuery))
+	}
+
+	req, err := newSearchRequest(query, in.Scope, in.Sort)
+	if err != nil {
+		return req, err
+	}
+	if req.query.Empty() {
+		return req, errors.NewValidationError(fmt.Errorf("query has nothing to search for"))
+	}
+	return req, nil
+}
+
+// countSavedSearch counts the notes a saved search matches right now. A query that stopped
+// being valid, e.g. after the note types changed, gets no count rather than failing the list.
+func countSavedSearch(userID uuid.UUID, saved *models.SavedSearch) (*int, error) {
+	req, err := newSearchRequest(saved.Query, string(saved.Scope), string(saved.Sort))
+	if err != nil {
+		return nil, nil
+	}
+
+	total, err := countSearch(userID, req)
+	if err != nil {
+		var invalid *errors.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	count := int(total)
+	return &count, nil
+}
@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// liveNoteShare is the condition for a share that hasn't expired.
+const liveNoteShare = "(note_shares.expires IS NULL OR note_shares.expires > NOW())"
+
+const (
+	defaultSyncLimit = 500
+	maxSyncLimit     = 1000
+	maxSyncUploads   = 100
+)
+
+// GetSync godoc
+//
+//	@Summary		Sync changes
+//	@Description	Lists what changed since a sync token, oldest change first: notes, attachments, shares and tags
+//	@Description	that were created or updated, and tombstones for deletions and revoked or expired shares.
+//	@Description	Notes moved to the bin count as deleted. Without a token, everything current is listed.
+//	@Description	While more is true, sync again with the returned token. Changes may come again in a later sync.
+//	@Tags			sync
+//	@ID				getSync
+//	@Produce		json
+//	@Param			since	query		string	false	"Token of the last sync"
+//	@Param			limit	query		int		false	"Changes per response, at most 1000"	default(500)
+//	@Success		200		{object}	SyncResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid token"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	ErrorResponse	"Server error"
+//	@Router			/sync [get]
+//	@Security		BearerAuth
+func GetSync(c *gin.Context, userID uuid.UUID) (any, error) {
+	since, err := parseSyncToken(c.Query("since"))
+	if err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	return pullChanges(userID, since, syncLimit(c))
+}
+
+// PostSync godoc
+//
+//	@Summary		Upload offline edits and sync
+//	@Description	Applies notes created, edited or deleted offline, each on its own, then lists changes like GET /sync.
+//	@Description	An edit is applied when the note is still at its base version and reported as a conflict,
+//	@Description	along with the note as it is now, when it changed in the meantime.
+//	@Description	Attachments and shares aren't uploaded here, they go through their own endpoints.
+//	@Tags			sync
+//	@ID				postSync
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		SyncRequest	true	"Token of the last sync and offline edits"
+//	@Param			limit	query		int			false	"Changes per response, at most 1000"	default(500)
+//	@Success		200		{object}	SyncResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid token or too many edits"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	ErrorResponse	"Server error"
+//	@Router			/sync [post]
+//	@Security		BearerAuth
+func PostSync(c *gin.Context, userID uuid.UUID) (any, error) {
+	var input models.SyncRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	since, err := parseSyncToken(input.Token)
+	if err != nil {
+		return nil, errors.NewValidationError(err)
+	}
+
+	if len(input.Changes) > maxSyncUploads {
+		return nil, errors.NewValidationError(fmt.Errorf("at most %d edits can be uploaded at once", maxSyncUploads))
+	}
+
+	results := make([]models.SyncResult, len(input.Changes))
+	for i, upload := range input.Changes {
+		if results[i], err = uploadNote(userID, upload); err != nil {
+			return nil, errors.NewServerError(err)
+		}
+	}
+
+	out, err := pullChanges(userID, since, syncLimit(c))
+	if err != nil {
+		return nil, err
+	}
+	out.Results = results
+	return out, nil
+}
+
+// syncPoint is what a client has synced up to: every row at or below Version that was committed
+// in Snapshot, the database snapshot of the last sync, and every share that expired by At.
+// Versions are taken when rows are written, not committed, so rows of transactions that hadn't
+// committed in the snapshot may land below Version and are sent along with the ones above it.
+type syncPoint struct {
+	Version  int64
+	Snapshot string
+	At       time.Time
+}
+
+// pgSnapshot is the text form of a pg_snapshot, xmin:xmax:xip,...
+var pgSnapshot = regexp.MustCompile(`^\d+:\d+:(\d+(,\d+)*)?$`)
+
+// token is the sync point as an opaque string.
+func (p syncPoint) token() string {
+	s := fmt.Sprintf("v2:%d:%d:%s", p.Version, p.At.UnixMicro(), p.Snapshot)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func parseSyncToken(s string) (syncPoint, error) {
+	if s == "" {
+		return syncPoint{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return syncPoint{}, fmt.Errorf("invalid sync token")
+	}
+
+	parts := strings.SplitN(string(data), ":", 4)
+	if len(parts) != 4 || parts[0] != "v2" || !pgSnapshot.MatchString(parts[3]) {
+		return syncPoint{}, fmt.Errorf("invalid sync token")
+	}
+	version, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || version < 0 {
+		return syncPoint{}, fmt.Errorf("invalid sync token")
+	}
+	at, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return syncPoint{}, fmt.Errorf("invalid sync token")
+	}
+	return syncPoint{Version: version, Snapshot: parts[3], At: time.UnixMicro(at).UTC()}, nil
+}
+
+// full tells whether the client has nothing yet.
+func (p syncPoint) full() bool {
+	return p.Snapshot == ""
+}
+
+// unseen is the condition for rows the client doesn't have as they are now: above its version,
+// or written by a transaction that hadn't committed in its snapshot. A shared note has two.
+func (p syncPoint) unseen(version string, xids ...string) clause.Expr {
+	sql := version + " > ?"
+	vars := []any{p.Version}
+	if !p.full() {
+		for _, xid := range xids {
+			sql += " OR (" + xid + " >= pg_snapshot_xmin(?::text::pg_snapshot) AND NOT pg_visible_in_snapshot(" + xid + ", ?::text::pg_snapshot))"
+			vars = append(vars, p.Snapshot, p.Snapshot)
+		}
+	}
+	return gorm.Expr("("+sql+")", vars...)
+}
+
+// next is where the sync after one that sent changes[:cut] of changes, sorted by version, picks up;
+// snapshot and at are of the sync. Whatever it committed at or below the cut was sent, or the client
+// had it already. When nothing is left it's caught up to its old version too, if that was further.
+func (p syncPoint) next(changes []models.SyncChange, cut int, snapshot string, at time.Time) syncPoint {
+	next := syncPoint{Version: p.Version, Snapshot: snapshot, At: at}
+	if cut > 0 && (cut < len(changes) || changes[cut-1].Version > p.Version) {
+		next.Version = changes[cut-1].Version
+	}
+	return next
+}
+
+func syncLimit(c *gin.Context) int {
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		return min(limit, maxSyncLimit)
+	}
+	return defaultSyncLimit
+}
+
+// pullChanges lists up to limit changes visible to the user since the sync point, oldest first.
+// Each kind is read up to limit + 1, enough to know whether the merged list goes on, all in one snapshot.
+func pullChanges(userID uuid.UUID, since syncPoint, limit int) (models.SyncResponse, error) {
+	pulls := []func(*gorm.DB, uuid.UUID, syncPoint, int) ([]models.SyncChange, error){
+		pullOwnNotes,
+		pullSharedNotes,
+		pullAttachments,
+		pullShares,
+		pullTags,
+		pullTombstones,
+	}
+
+	var out models.SyncResponse
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// the first statement takes the snapshot the rest read in
+		var now struct {
+			Snapshot string
+			At       time.Time
+		}
+		if err := tx.Raw("SELECT pg_current_snapshot()::text AS snapshot, NOW() AS at").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		var changes []models.SyncChange
+		for _, pull := range pulls {
+			batch, err := pull(tx, userID, since, limit+1)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, batch...)
+		}
+
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Version < changes[j].Version
+		})
+		cut := cutChanges(changes, limit)
+
+		// shares that ran out have no version to sort by, they all come along
+		expired, err := pullExpiredShares(tx, userID, since, now.At)
+		if err != nil {
+			return err
+		}
+
+		out = models.SyncResponse{
+			Changes: append(changes[:cut:cut], expired...),
+			Token:   since.next(changes, cut, now.Snapshot, now.At).token(),
+			More:    cut < len(changes),
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return models.SyncResponse{}, errors.NewServerError(err)
+	}
+
+	if out.Changes == nil {
+		out.Changes = []models.SyncChange{}
+	}
+	return out, nil
+}
+
+// cutChanges is how many of the changes, sorted by version, fit in a response of limit.
+// Changes sharing the last version stay together, the next sync starts above it.
+func cutChanges(changes []models.SyncChange, limit int) int {
+	cut := len(changes)
+	if cut > limit {
+		cut = limit
+		for cut < len(changes) && changes[cut].Version == changes[cut-1].Version {
+			cut++
+		}
+	}
+	return cut
+}
+
+// pullOwnNotes lists the user's notes changed since the sync point, the ones in the bin as deleted.
+// A full sync leaves those out, the client never had them.
+func pullOwnNotes(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	query := tx.
+		Unscoped().
+		Where("notes.user_id = ?", userID).
+		Where(since.unseen("notes.version", "notes.xid"))
+	if since.full() {
+		query = query.Where("notes.deleted_at IS NULL")
+	}
+
+	var notes []models.Note
+	if err := query.
+		Preload("Attachments", readyAttachments).
+		Preload("Tags").
+		Preload("User").
+		Preload("Shares.SharedWith").
+		Order("notes.version").
+		Limit(limit).
+		Find(&notes).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(notes))
+	for i := range notes {
+		changes[i] = models.NewNoteChange(&notes[i], notes[i].Version)
+	}
+	return changes, nil
+}
+
+// pullSharedNotes lists notes shared with the user that changed, or were shared, since the sync point.
+func pullSharedNotes(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	const version = "GREATEST(notes.version, note_shares.version)"
+
+	query := tx.
+		Unscoped().
+		Model(&models.Note{}).
+		Select("notes.*, "+version+" AS sync_version").
+		Joins("JOIN note_shares ON note_shares.note_id = notes.id").
+		Where("note_shares.shared_with_user_id = ? AND "+liveNoteShare, userID).
+		Where(since.unseen(version, "notes.xid", "note_shares.xid"))
+	if since.full() {
+		query = query.Where("notes.deleted_at IS NULL")
+	}
+
+	var notes []models.SyncedNote
+	if err := query.
+		Preload("Attachments", readyAttachments).
+		Preload("Tags").
+		Preload("User").
+		Preload("Shares", func(db *gorm.DB) *gorm.DB {
+			return db.Where("shared_with_user_id = ?", userID)
+		}).
+		Preload("Shares.SharedWith").
+		Order("sync_version").
+		Limit(limit).
+		Find(&notes).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(notes))
+	for i := range notes {
+		changes[i] = models.NewNoteChange(&notes[i].Note, notes[i].SyncVersion)
+	}
+	return changes, nil
+}
+
+// pullAttachments lists attachments changed since the sync point on notes the user owns or has a live share of.
+func pullAttachments(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	var attachments []models.Attachment
+	if err := tx.
+		Select("attachments.*").
+		Joins("JOIN notes ON notes.id = attachments.note_id").
+		Where("(notes.user_id = ? OR "+liveShare+")", userID, userID).
+		Where(since.unseen("attachments.version", "attachments.xid")).
+		Scopes(readyAttachments).
+		Order("attachments.version").
+		Limit(limit).
+		Find(&attachments).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(attachments))
+	for i := range attachments {
+		changes[i] = models.NewAttachmentChange(&attachments[i])
+	}
+	return changes, nil
+}
+
+// pullShares lists shares changed since the sync point: of the user's notes, and live ones they were given.
+func pullShares(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	var shares []models.NoteShare
+	if err := tx.
+		Select("note_shares.*").
+		Joins("JOIN notes ON notes.id = note_shares.note_id").
+		Where("(notes.user_id = ? OR (note_shares.shared_with_user_id = ? AND "+liveNoteShare+"))", userID, userID).
+		Where(since.unseen("note_shares.version", "note_shares.xid")).
+		Preload("SharedWith").
+		Order("note_shares.version").
+		Limit(limit).
+		Find(&shares).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(shares))
+	for i := range shares {
+		changes[i] = models.NewShareChange(&shares[i])
+	}
+	return changes, nil
+}
+
+func pullTags(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	var tags []models.Tag
+	if err := tx.
+		Where("user_id = ?", userID).
+		Where(since.unseen("version", "xid")).
+		Order("version").
+		Limit(limit).
+		Find(&tags).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(tags))
+	for i := range tags {
+		changes[i] = models.NewTagChange(&tags[i])
+	}
+	return changes, nil
+}
+
+// pullTombstones lists deletions since the sync point. A full sync has nothing to delete.
+func pullTombstones(tx *gorm.DB, userID uuid.UUID, since syncPoint, limit int) ([]models.SyncChange, error) {
+	if since.full() {
+		return nil, nil
+	}
+
+	var tombstones []models.Tombstone
+	if err := tx.
+		Where("user_id = ?", userID).
+		Where(since.unseen("version", "xid")).
+		Order("version").
+		Limit(limit).
+		Find(&tombstones).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, len(tombstones))
+	for i := range tombstones {
+		changes[i] = models.NewTombstoneChange(&tombstones[i])
+	}
+	return changes, nil
+}
+
+// pullExpiredShares lists the removal of notes shared with the user whose share expired since the sync point.
+// Expiring writes nothing, so there's no tombstone to find.
+func pullExpiredShares(tx *gorm.DB, userID uuid.UUID, since syncPoint, now time.Time) ([]models.SyncChange, error) {
+	if since.full() {
+		return nil, nil
+	}
+
+	var shares []models.NoteShare
+	if err := tx.
+		Where("shared_with_user_id = ? AND expires > ? AND expires <= ?", userID, since.At, now).
+		Order("version").
+		Find(&shares).
+		Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.SyncChange, 0, 2*len(shares))
+	for _, share := range shares {
+		changes = append(changes,
+			models.SyncChange{Kind: models.ShareKind, ID: share.ID, Version: share.Version, Deleted: true},
+			models.SyncChange{Kind: models.NoteKind, ID: share.NoteID, Version: share.Version, Deleted: true},
+		)
+	}
+	return changes, nil
+}
+
+// uploadNote applies an offline edit of one of the user's notes in a transaction of its own.
+// Only failures of the database are returned as errors, everything else is reported in the result.
+func uploadNote(userID uuid.UUID, upload models.SyncUpload) (models.SyncResult, error) {
+	result := models.SyncResult{ID: upload.ID}
+	reject := func(err error) (models.SyncResult, error) {
+		result.Status, result.Error = models.RejectedSync, err.Error()
+		return result, nil
+	}
+
+	if upload.ID == uuid.Nil {
+		return reject(fmt.Errorf("missing note ID"))
+	}
+
+	op, err := models.NewSyncOp(upload.Op)
+	if err != nil {
+		return reject(err)
+	}
+
+	var (
+		noteType models.NoteType
+		tags     []string
+	)
+	if op == models.UpsertOp {
+		if upload.Note == nil || upload.Note.Title == "" || upload.Note.Content == "" {
+			return reject(fmt.Errorf("an upsert needs a note with a title and content"))
+		}
+		if noteType, err = models.NewNoteType(upload.Note.Type); err != nil {
+			return reject(err)
+		}
+		if tags, err = models.NewTagNames(upload.Note.Tags); err != nil {
+			return reject(err)
+		}
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var note models.Note
+		err := tx.
+			Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", upload.ID).
+			First(&note).
+			Error
+		found := err == nil
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		switch {
+		case found && note.UserID != userID:
+			result.Status, result.Error = models.RejectedSync, "note not found"
+			return nil
+
+		case !found && op == models.DeleteOp:
+			// deleted for good already, nothing left to do
+			result.Status = models.AppliedSync
+			return nil
+
+		case !found && upload.BaseVersion != 0:
+			result.Status = models.ConflictSync
+			return nil
+
+		case found && note.Version != upload.BaseVersion:
+			result.Status = models.ConflictSync
+			if note.DeletedAt.Valid {
+				return nil
+			}
+			if err := tx.Preload("Attachments", readyAttachments).Preload("Tags").Preload("User").First(&note, "id = ?", note.ID).Error; err != nil {
+				return err
+			}
+			current := models.NewNoteOut(&note)
+			result.Current = &current
+			return nil
+		}
+
+		switch {
+		case op == models.DeleteOp:
+			if err := tx.Delete(&note).Error; err != nil {
+				return err
+			}
+
+		case !found:
+			note = models.NewNote(upload.Note, userID, noteType)
+			note.ID = upload.ID
+			if err := tx.Create(&note).Error; err != nil {
+				return err
+			}
+			if err := replaceTags(tx, &note, tags); err != nil {
+				return err
+			}
+			if err := saveEmbedding(tx, &note); err != nil {
+				return err
+			}
+
+		default:
+			if err := note.Edit(upload.Note); err != nil {
+				return err
+			}
+			note.DeletedAt = gorm.DeletedAt{} // editing a note that went to the bin brings it back
+			if err := tx.Unscoped().Save(&note).Error; err != nil {
+				return err
+			}
+			if upload.Note.Tags == nil {
+				if err := tx.Model(&note).Association("Tags").Find(&note.Tags); err != nil {
+					return err
+				}
+			} else if err := replaceTags(tx, &note, tags); err != nil {
+				return err
+			}
+			if err := saveEmbedding(tx, &note); err != nil {
+				return err
+			}
+		}
+
+		result.Status = models.AppliedSync
+		return tx.
+			Unscoped().
+			Model(&models.Note{}).
+			Select("version").
+			Where("id = ?", note.ID).
+			Scan(&result.Version).
+			Error
+	})
+
+	return result, err
+}
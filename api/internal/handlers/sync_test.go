@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versioned are changes at each version, in order.
+func versioned(versions ...int64) []models.SyncChange {
+	changes := make([]models.SyncChange, len(versions))
+	for i, v := range versions {
+		changes[i] = models.SyncChange{Kind: models.NoteKind, Version: v}
+	}
+	return changes
+}
+
+func TestSyncToken(t *testing.T) {
+	at := time.Date(2026, 10, 19, 10, 0, 0, 123456000, time.UTC)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, p := range []syncPoint{
+			{Version: 42, Snapshot: "780:790:781,785", At: at},
+			{Version: 0, Snapshot: "780:780:", At: at},
+		} {
+			parsed, err := parseSyncToken(p.token())
+			require.NoError(t, err, p)
+			assert.Equal(t, p, parsed)
+		}
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		p, err := parseSyncToken("")
+		require.NoError(t, err)
+		assert.True(t, p.full())
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		encode := func(s string) string {
+			return base64.RawURLEncoding.EncodeToString([]byte(s))
+		}
+		for name, s := range map[string]string{
+			"NotBase64":       "not a token!",
+			"OldVersion":      encode("v1:42"),
+			"MissingSnapshot": encode("v2:42:1760868000000000"),
+			"NegativeVersion": encode("v2:-1:1760868000000000:780:780:"),
+			"InvalidVersion":  encode("v2:x:1760868000000000:780:780:"),
+			"InvalidTime":     encode("v2:42:x:780:780:"),
+			"InvalidSnapshot": encode("v2:42:1760868000000000:780:780:1;DROP TABLE notes"),
+			"EmptySnapshot":   encode("v2:42:1760868000000000:"),
+		} {
+			_, err := parseSyncToken(s)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestCutChanges(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		versions []int64
+		limit    int
+		cut      int
+	}{
+		{name: "Empty", versions: nil, limit: 3, cut: 0},
+		{name: "UnderLimit", versions: []int64{1, 2}, limit: 3, cut: 2},
+		{name: "AtLimit", versions: []int64{1, 2, 3}, limit: 3, cut: 3},
+		{name: "OverLimit", versions: []int64{1, 2, 3, 4}, limit: 3, cut: 3},
+		// a shared note and its share can both be at the share's version
+		{name: "KeepsVersionTogether", versions: []int64{1, 2, 3, 3, 4}, limit: 3, cut: 4},
+		{name: "KeepsVersionTogetherAtEnd", versions: []int64{1, 3, 3, 3}, limit: 2, cut: 4},
+		{name: "SplitsBeforeNextVersion", versions: []int64{1, 2, 2, 3}, limit: 3, cut: 3},
+		{name: "AllAtOneVersion", versions: []int64{5, 5, 5}, limit: 1, cut: 3},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.cut, cutChanges(versioned(c.versions...), c.limit))
+		})
+	}
+}
+
+func TestSyncPointNext(t *testing.T) {
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	const snapshot = "800:805:802"
+
+	for _, c := range []struct {
+		name     string
+		since    int64
+		versions []int64
+		cut      int
+		version  int64
+	}{
+		{name: "Nothing", since: 40, versions: nil, cut: 0, version: 40},
+		{name: "AllSent", since: 40, versions: []int64{41, 45}, cut: 2, version: 45},
+		{name: "More", since: 40, versions: []int64{41, 45, 50}, cut: 2, version: 45},
+		// rows committed late, below the version the client had, come again along with newer ones
+		{name: "LateOnly", since: 40, versions: []int64{12, 30}, cut: 2, version: 40},
+		{name: "LateAndNew", since: 40, versions: []int64{12, 41}, cut: 2, version: 41},
+		// with more to come, the next sync starts right above what was sent, even below the old version
+		{name: "LateWithMore", since: 40, versions: []int64{12, 30, 35}, cut: 2, version: 30},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			since := syncPoint{Version: c.since, Snapshot: "700:700:", At: at.Add(-time.Hour)}
+			next := since.next(versioned(c.versions...), c.cut, snapshot, at)
+			assert.Equal(t, syncPoint{Version: c.version, Snapshot: snapshot, At: at}, next)
+		})
+	}
+}
+
+func TestSyncPointUnseen(t *testing.T) {
+	t.Run("Full", func(t *testing.T) {
+		expr := syncPoint{}.unseen("notes.version", "notes.xid")
+		assert.Equal(t, "(notes.version > ?)", expr.SQL)
+		assert.Equal(t, []any{int64(0)}, expr.Vars)
+	})
+
+	t.Run("Since", func(t *testing.T) {
+		p := syncPoint{Version: 42, Snapshot: "780:790:781"}
+		expr := p.unseen("GREATEST(notes.version, note_shares.version)", "notes.xid", "note_shares.xid")
+		assert.Equal(t, "(GREATEST(notes.version, note_shares.version) > ?"+
+			" OR (notes.xid >= pg_snapshot_xmin(?::text::pg_snapshot) AND NOT pg_visible_in_snapshot(notes.xid, ?::text::pg_snapshot))"+
+			" OR (note_shares.xid >= pg_snapshot_xmin(?::text::pg_snapshot) AND NOT pg_visible_in_snapshot(note_shares.xid, ?::text::pg_snapshot)))",
+			expr.SQL)
+		assert.Equal(t, []any{int64(42), p.Snapshot, p.Snapshot, p.Snapshot, p.Snapshot}, expr.Vars)
+	})
+}
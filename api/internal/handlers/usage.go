@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"vault/internal/db"
+	"vault/internal/errors"
+	"vault/internal/models"
+	"vault/internal/uploads"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUsage godoc
+//
+//	@Summary		Storage usage
+//	@Description	Tells how much storage the user's attachments take against their plan's quota,
+//	@Description	by MIME type and by note, largest first. Notes in the bin count until they're deleted for good.
+//	@Tags			auth
+//	@ID				getUsage
+//	@Produce		json
+//	@Param			limit	query		int	false	"Notes per page, at most 100"	default(10)
+//	@Param			page	query		int	false	"Page of notes"				default(1)
+//	@Success		200		{object}	UsageOut
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	ErrorResponse	"Server error"
+//	@Router			/me/usage [get]
+//	@Security		BearerAuth
+func GetUsage(c *gin.Context, userID uuid.UUID) (any, error) {
+	var user models.User
+	if err := db.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("User not found", err)
+		}
+		return nil, errors.NewServerError(err)
+	}
+
+	pending, err := pendingUploads(db.DB, userID)
+	if err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	byType := []models.TypeUsage{}
+	if err := userAttachments(db.DB, userID).
+		Select("attachments.mime_type, COUNT(*) AS attachments, SUM(attachments.size) AS size").
+		Where("attachments.status = ?", models.ReadyUpload).
+		Group("attachments.mime_type").
+		Order("size DESC, attachments.mime_type").
+		Scan(&byType).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	limit, offset := offsetPage(c)
+	byNote := []models.NoteUsage{}
+	if err := userAttachments(db.DB, userID).
+		Select("notes.id AS note_id, notes.title, notes.deleted_at IS NOT NULL AS deleted, COUNT(*) AS attachments, SUM(attachments.size) AS size").
+		Where("attachments.status = ?", models.ReadyUpload).
+		Group("notes.id").
+		Order("size DESC, notes.id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&byNote).Error; err != nil {
+		return nil, errors.NewServerError(err)
+	}
+
+	return models.NewUsageOut(&user, uploads.Quota(user.Plan), pending, byType, byNote), nil
+}
+
+// userAttachments are the attachments on the user's notes, those in the bin included.
+func userAttachments(tx *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return tx.
+		Model(&models.Attachment{}).
+		Joins("JOIN notes ON notes.id = attachments.note_id").
+		Where("notes.user_id = ?", userID)
+}
+
+// pendingUploads is how many bytes of the user's attachments are on their way, which the quota holds for them.
+func pendingUploads(tx *gorm.DB, userID uuid.UUID) (int64, error) {
+	var pending int64
+	err := userAttachments(tx, userID).
+		Where("attachments.status IN ?", []models.UploadStatus{models.PendingUpload, models.ScanningUpload}).
+		Select("COALESCE(SUM(attachments.size), 0)").
+		Scan(&pending).Error
+	return pending, err
+}
+
+// reserveQuota makes sure the user has room for size more bytes, counting uploads under way.
+// It locks the user until tx ends, so uploads presigned at the same time can't both take the last of it.
+func reserveQuota(tx *gorm.DB, userID uuid.UUID, size int64) error {
+	var user models.User
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	pending, err := pendingUploads(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	quota := uploads.Quota(user.Plan)
+	if user.StorageUsed+pending+size > quota {
+		return errors.NewValidationErrorWithDetails(
+			fmt.Errorf("storage quota exceeded"),
+			map[string]any{"quota": quota, "used": user.StorageUsed, "pending": pending, "size": size},
+		)
+	}
+	return nil
+}
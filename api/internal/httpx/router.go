@@ -9,6 +9,7 @@ import (
 	_ "vault/docs"
 	"vault/internal/handlers"
 	"vault/internal/middleware"
+	"vault/internal/storage"
 )
 
 func Router(origins string) *gin.Engine {
@@ -32,6 +33,11 @@ func Router(origins string) *gin.Engine {
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// presigned URLs of local storage point back at the API
+	if local, ok := storage.Default.(*storage.Local); ok {
+		r.Any(storage.LocalPath+"/*key", gin.WrapH(local))
+	}
+
 	// Public routes
 	r.POST("/refresh", Route(handlers.Refresh))
 	r.POST("/firebase", Route(handlers.SignInWithFirebase))
@@ -41,6 +47,17 @@ func Router(origins string) *gin.Engine {
 	authGroup.Use(middleware.AuthenticationMiddleware())
 	authGroup.GET("/me", Authenticated(handlers.Me))
 	authGroup.POST("/me/avatar", Authenticated(handlers.PresignAvatar))
+	authGroup.GET("/me/password-health", Authenticated(handlers.GetPasswordHealth))
+	authGroup.GET("/me/usage", Authenticated(handlers.GetUsage))
+	authGroup.GET("/me/searches", Authenticated(handlers.GetSavedSearches))
+	authGroup.POST("/me/searches", Authenticated(handlers.CreateSavedSearch))
+	authGroup.GET("/me/searches/:searchId", Authenticated(handlers.GetSavedSearch))
+	authGroup.PUT("/me/searches/:searchId", Authenticated(handlers.EditSavedSearch))
+	authGroup.DELETE("/me/searches/:searchId", Authenticated(handlers.DeleteSavedSearch))
+	authGroup.GET("/me/searches/:searchId/results", Authenticated(handlers.RunSavedSearch))
+	authGroup.GET("/search", Authenticated(handlers.SearchNotes))
+	authGroup.GET("/sync", Authenticated(handlers.GetSync))
+	authGroup.POST("/sync", Authenticated(handlers.PostSync))
 
 	// notes
 	vaultGroup := r.Group("/notes")
@@ -53,9 +70,15 @@ func Router(origins string) *gin.Engine {
 	vaultGroup.DELETE("/:noteId", Authenticated(handlers.DeleteNote))
 	vaultGroup.POST("/:noteId/restore", Authenticated(handlers.RestoreNote))
 	vaultGroup.GET("/shared-with-me", Authenticated(handlers.SharedWithMe))
+	vaultGroup.GET("/search", Authenticated(handlers.SearchNotes))
+	vaultGroup.GET("/:noteId/related", Authenticated(handlers.GetRelatedNotes))
 	// attachments
 	vaultGroup.POST("/:noteId/attachments", Authenticated(handlers.GetUploadURL))
 	vaultGroup.GET("/:noteId/attachments/:attachmentId", Authenticated(handlers.GetDownloadURL))
+	vaultGroup.POST("/:noteId/attachments/multipart", Authenticated(handlers.CreateMultipartUpload))
+	vaultGroup.POST("/:noteId/attachments/:attachmentId/parts", Authenticated(handlers.PresignParts))
+	vaultGroup.GET("/:noteId/attachments/:attachmentId/parts", Authenticated(handlers.GetUploadedParts))
+	vaultGroup.POST("/:noteId/attachments/:attachmentId/complete", Authenticated(handlers.CompleteUpload))
 	vaultGroup.DELETE("/:noteId/attachments/:attachmentId", Authenticated(handlers.DeleteAttachment))
 	vaultGroup.GET("/attachments", Authenticated(handlers.GetAttachments))
 	// share
@@ -63,6 +86,12 @@ func Router(origins string) *gin.Engine {
 	vaultGroup.GET("/:noteId/share", Authenticated(handlers.GetNoteShares))
 	vaultGroup.DELETE("/:noteId/shares/:userId", Authenticated(handlers.RevokeNoteShare))
 
+	// generators
+	generateGroup := r.Group("/generate")
+	generateGroup.Use(middleware.AuthenticationMiddleware())
+	generateGroup.POST("/password", Authenticated(handlers.GeneratePassword))
+	generateGroup.POST("/passphrase", Authenticated(handlers.GeneratePassphrase))
+
 	return r
 }
 
@@ -1,24 +1,34 @@
 package ingest
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
 	"log"
 	"net/url"
+	"os"
+	"slices"
 	"strings"
-	"vault/internal/awsx"
+	"time"
 	"vault/internal/config"
 	"vault/internal/db"
+	"vault/internal/extract"
 	"vault/internal/models"
+	"vault/internal/scan"
+	"vault/internal/storage"
+	"vault/internal/thumbnail"
+	"vault/internal/uploads"
 )
 
-const maxUploadSize = 10 * 1024 * 1024 // 10 MB
-
 func Handle() error {
 	lambda.Start(handler)
 	return nil
@@ -28,25 +38,37 @@ func skips(message string, v ...any) {
 	log.Printf("[INGEST][SKIP]: "+message, v...)
 }
 
+// errors logs a failure to process an upload. It doesn't exit, ingest may run inside the API.
 func errors(message string, v ...any) {
-	log.Fatalf("[INGEST][ERROR]: "+message, v...)
+	log.Printf("[INGEST][ERROR]: "+message, v...)
+}
 
+// event is what ingest is invoked with: S3 notifications, or the schedule that sweeps stale uploads.
+type event struct {
+	events.S3Event
+	Source string `json:"source"` // "aws.events" when scheduled
 }
 
-func handler(_ context.Context, s3Event events.S3Event) error {
+func handler(ctx context.Context, e event) error {
 	cfg, err := config.NewIngestConfig()
 	if err != nil {
 		errors("Failed to load ingest config: %v", err)
 		return err // fatal
 	}
 
-	distribution := cfg.DistributionAlias
+	if e.Source == "aws.events" {
+		Sweep(ctx, storage.Default)
+		return nil
+	}
 
-	for _, record := range s3Event.Records {
-		key := record.S3.Object.Key
-		bucket := record.S3.Bucket.Name
+	avatarURL := func(key string) string {
+		// full public URL using the CloudFront distribution
+		return fmt.Sprintf("https://%s/%s", cfg.DistributionAlias, key)
+	}
 
-		log.Printf("New S3 object: %s (bucket: %s)", key, bucket)
+	for _, record := range e.Records {
+		key := record.S3.Object.Key
+		log.Printf("New S3 object: %s (bucket: %s)", key, record.S3.Bucket.Name)
 
 		decoded, err := url.QueryUnescape(key)
 		if err != nil {
@@ -54,107 +76,656 @@ func handler(_ context.Context, s3Event events.S3Event) error {
 			continue
 		}
 
-		// Key parts determine the upload type.
-		// Attachments: "attachments/{noteId}/{filename}" (3 parts)
-		// Avatars: "avatars/{userId}" (2 parts)
-		parts := strings.Split(decoded, "/")
+		Process(ctx, storage.Default, decoded, avatarURL)
+	}
+
+	return nil
+}
 
-		if len(parts) < 2 { // Must have at least a prefix and an ID
-			skips("Skipping invalid key format: %s", decoded)
-			continue
+// Process handles a file uploaded to store: attachments are checked and indexed, avatars set on their user
+// with the URL avatarURL gives for their key. Failures are logged, the upload stays as it is.
+func Process(ctx context.Context, store storage.Storage, key string, avatarURL func(key string) string) {
+	// Key parts determine the upload type.
+	// Attachments: "attachments/{noteId}/{attachmentId}" (3 parts)
+	// Avatars: "avatars/{userId}" (2 parts), processed into "avatars/{userId}-{version}/{size}.jpg"
+	parts := strings.Split(key, "/")
+
+	if len(parts) < 2 { // Must have at least a prefix and an ID
+		skips("Skipping invalid key format: %s", key)
+		return
+	}
+
+	uploadType := parts[0]
+
+	object, err := store.Head(ctx, key)
+	if err != nil {
+		skips("Failed to fetch metadata for %s: %v", key, err)
+		return
+	}
+
+	switch uploadType {
+	case "attachments":
+		if len(parts) != 3 {
+			skips("Skipping invalid attachment key (expected 3 parts): %s", key)
+			return
+		}
+		handleAttachmentUpload(ctx, store, parts, object)
+	case "avatars":
+		if len(parts) != 2 {
+			// includes the sizes written below, which storage reports too
+			skips("Skipping avatar key that isn't an upload: %s", key)
+			return
+		}
+		if err := uploads.Check(uploads.Avatars, object.ContentType, object.Size); err != nil {
+			log.Printf("File %s is not allowed (%v). Deleting.", key, err)
+			deleteUpload(ctx, store, key)
+			return
+		}
+		// avatars are public as soon as they're uploaded, so ones that can't be checked go too
+		local, err := download(ctx, store, key)
+		if err != nil {
+			errors("Failed to download %s, deleting it: %v", key, err)
+			deleteUpload(ctx, store, key)
+			return
+		}
+		defer local.remove()
+
+		reason, infected, err := inspect(ctx, local, object.ContentType)
+		if err != nil {
+			errors("Failed to inspect %s, deleting it: %v", key, err)
+			deleteUpload(ctx, store, key)
+			return
+		}
+		if reason != "" {
+			log.Printf("File %s is refused (%s).", key, reason)
+			refuse(ctx, store, key, infected)
+			return
+		}
+		handleAvatarUpload(ctx, store, parts, object, local, avatarURL)
+	default:
+		skips("Skipping unrecognized upload type for key: %s", key)
+	}
+}
+
+// handleAttachmentUpload completes the attachment created when the upload was presigned.
+// Uploading again to the same key replaces the file.
+func handleAttachmentUpload(ctx context.Context, store storage.Storage, parts []string, object storage.Object) {
+	key := object.Key
+	noteID, err := uuid.Parse(parts[1])
+	if err != nil {
+		errors("Incorrect note ID format in key %s: %v", key, err)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(parts[2])
+	if err != nil {
+		skips("Skipping attachment key without an attachment ID: %s", key)
+		return
+	}
+
+	var attachment models.Attachment
+	err = db.DB.Where("id = ? AND note_id = ?", attachmentID, noteID).First(&attachment).Error
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		skips("No attachment was presigned for %s, deleting it", key)
+		deleteUpload(ctx, store, key)
+		return
+	}
+	if err != nil {
+		errors("Failed to find attachment for key %s: %v", key, err)
+		return
+	}
+
+	if err := complete(ctx, store, &attachment, object); err != nil {
+		errors("Failed to complete attachment for key %s: %v", key, err)
+	}
+}
+
+// staleUpload is how long after its last change an attachment is taken for abandoned:
+// pending ones won't be uploaded any more, scanning ones were left behind by a crash.
+const staleUpload = storage.PresignTTL + 15*time.Minute
+
+// maxInMemory is the largest attachment text is extracted from and thumbnails are made of,
+// which takes reading it into memory whole. Larger ones, uploaded in parts, go without.
+const maxInMemory = 100 * 1024 * 1024
+
+// keepRejected is how long rejected attachments stay around for clients to see why.
+const keepRejected = 24 * time.Hour
+
+// complete checks the uploaded file of an attachment and makes it ready, kept in the blob of its content, or deletes the file
+// and rejects the attachment with a reason. Rejected attachments stay rejected and attachments
+// another upload is scanning are left alone, attachment holds the outcome either way.
+func complete(ctx context.Context, store storage.Storage, attachment *models.Attachment, object storage.Object) error {
+	key := object.Key
+	if attachment.Status == models.RejectedUpload {
+		skips("Attachment %s was rejected, deleting %s", attachment.ID, key)
+		deleteUpload(ctx, store, key)
+		return nil
+	}
+
+	// claim the attachment, so a repeated S3 notification and the sweep don't both process it
+	claim := db.DB.
+		Model(attachment).
+		Where(
+			"status IN ? OR (status = ? AND updated_at < ?)",
+			[]models.UploadStatus{models.PendingUpload, models.ReadyUpload},
+			models.ScanningUpload,
+			time.Now().Add(-staleUpload),
+		).
+		Updates(map[string]any{"status": models.ScanningUpload, "reason": ""})
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		skips("Attachment %s is already being processed", attachment.ID)
+		return db.DB.First(attachment, "id = ?", attachment.ID).Error
+	}
+
+	// presigned forms only take allowed files, this catches uploads signed under other limits
+	if err := uploads.Check(uploads.Attachments, object.ContentType, object.Size); err != nil {
+		log.Printf("File %s is not allowed (%v). Deleting.", key, err)
+		deleteUpload(ctx, store, key)
+		return reject(attachment, err.Error())
+	}
+
+	// left scanning when it can't be checked, for the sweep to try again
+	local, err := download(ctx, store, key)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer local.remove()
+
+	reason, infected, err := inspect(ctx, local, object.ContentType)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", key, err)
+	}
+	if reason != "" {
+		log.Printf("File %s is refused (%s).", key, reason)
+		refuse(ctx, store, key, infected)
+		return reject(attachment, reason)
+	}
+
+	attachment.MimeType, attachment.Size = object.ContentType, object.Size
+
+	// text and thumbnails are made of the whole file in memory, read once for both
+	var data []byte
+	_, extracted := extract.KindOf(attachment.MimeType, attachment.FileName)
+	if (extracted || thumbnail.Supports(attachment.MimeType)) && local.size <= maxInMemory {
+		if data, err = local.read(maxInMemory); err != nil {
+			skips("Failed to read %s for text and thumbnails: %v", key, err)
 		}
+	}
+	indexAttachment(key, data, attachment)
+	attachment.Thumbnails = makeThumbnails(ctx, store, key, data, attachment)
+
+	// left scanning when it can't be stored, for the sweep to try again
+	replaced := attachment.Key()
+	if err := storeBlob(ctx, store, key, local.sha256, attachment); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	attachment.Status = models.ReadyUpload
+	if err := db.DB.Model(attachment).Updates(map[string]any{
+		"mime_type":  attachment.MimeType,
+		"size":       attachment.Size,
+		"status":     attachment.Status,
+		"thumbnails": attachment.Thumbnails,
+		"object_key": attachment.ObjectKey,
+		"sha256":     attachment.SHA256,
+	}).Error; err != nil {
+		return err
+	}
+	log.Printf("Successfully saved attachment: %s", key)
+
+	deleteUpload(ctx, store, key)
+	if replaced != key && replaced != attachment.ObjectKey {
+		DropBlob(ctx, store, replaced)
+	}
+	return nil
+}
+
+// storeBlob keeps an upload, whose hex SHA-256 is sum, in the blob of its content among those of the note's owner,
+// copying it there unless an attachment with the same content did already, and points the attachment at it.
+func storeBlob(ctx context.Context, store storage.Storage, key string, sum string, attachment *models.Attachment) error {
+	var note models.Note
+	if err := db.DB.Unscoped().Select("user_id").First(&note, "id = ?", attachment.NoteID).Error; err != nil {
+		return err
+	}
 
-		uploadType := parts[0]
+	blob := models.Blob{Key: models.BlobKey(note.UserID, sum), UserID: note.UserID, SHA256: sum, Size: attachment.Size}
+	// taken again, so DropBlob leaves it be until the attachment holds it
+	if err := db.DB.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{"updated_at": time.Now()}),
+		}).
+		Create(&blob).Error; err != nil {
+		return err
+	}
+
+	_, err := store.Head(ctx, blob.Key)
+	if stderrors.Is(err, storage.ErrNotFound) {
+		err = store.Copy(ctx, key, blob.Key)
+	}
+	if err != nil {
+		return err
+	}
+
+	attachment.ObjectKey, attachment.SHA256 = blob.Key, sum
+	return nil
+}
+
+// upload is a file downloaded from storage to be processed, so storage is read once
+// for everything done with it, and hashed on the way.
+type upload struct {
+	file   *os.File
+	size   int64
+	sha256 string // hex
+}
+
+// download copies a stored file to a temporary one, which remove deletes.
+func download(ctx context.Context, store storage.Storage, key string) (*upload, error) {
+	body, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "ingest-*")
+	if err != nil {
+		return nil, err
+	}
+	local := &upload{file: file}
+
+	hash := sha256.New()
+	if local.size, err = io.Copy(io.MultiWriter(file, hash), body); err != nil {
+		local.remove()
+		return nil, err
+	}
+	local.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return local, nil
+}
+
+// reader reads the file from the start.
+func (u *upload) reader() io.Reader {
+	return io.NewSectionReader(u.file, 0, u.size)
+}
+
+// read reads up to limit bytes of the file into memory.
+func (u *upload) read(limit int64) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(u.reader(), limit))
+}
+
+func (u *upload) remove() {
+	_ = u.file.Close()
+	if err := os.Remove(u.file.Name()); err != nil {
+		errors("Failed to remove %s: %v", u.file.Name(), err)
+	}
+}
 
-		input := &s3.HeadObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(decoded),
+// DropBlob deletes the blob stored at key once no attachment is stored in it, unless one took it lately
+// and is about to be. Keys of files that aren't blobs are left alone.
+func DropBlob(ctx context.Context, store storage.Storage, key string) {
+	dropped := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// locked, so storeBlob waits to take it again until it's gone, and checked against the attachments
+		// rather than trusting the count alone
+		var blob models.Blob
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ? AND refs <= 0 AND updated_at < ?", key, time.Now().Add(-staleUpload)).
+			Where("NOT EXISTS (SELECT 1 FROM attachments a WHERE a.object_key = blobs.key)").
+			Take(&blob).Error
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
 		}
-		head, err := awsx.S3.HeadObject(input)
 		if err != nil {
-			skips("Failed to fetch metadata for %s: %v", decoded, err)
-			continue
+			return err
 		}
 
-		size := int64(0)
-		if head.ContentLength != nil {
-			size = *head.ContentLength
+		if err := store.Delete(ctx, key); err != nil {
+			return err
 		}
+		dropped = true
+		return tx.Delete(&blob).Error
+	})
+	if err != nil {
+		errors("Failed to drop blob %s: %v", key, err)
+		return
+	}
+	if dropped {
+		log.Printf("Dropped unused blob %s", key)
+	}
+}
 
-		if size > maxUploadSize {
-			log.Printf("File %s exceeds size limit (%d > %d). Deleting.", decoded, size, maxUploadSize)
-			request := &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(decoded)}
-			if _, err := awsx.S3.DeleteObject(request); err != nil {
-				errors("Failed to delete oversized file %s: %v", decoded, err)
-			} else {
-				log.Printf("Successfully deleted oversized file: %s", decoded)
-			}
-			continue
+// inspect reads a downloaded upload through: its first bytes have to match its content type, and the scanner
+// has to find it clean, which files too large for it never are. It tells why the file is refused, if it is, and whether that's for malware.
+// Errors mean the file couldn't be checked.
+func inspect(ctx context.Context, local *upload, contentType string) (string, bool, error) {
+	body := local.reader()
+	head := make([]byte, uploads.SniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !stderrors.Is(err, io.ErrUnexpectedEOF) && !stderrors.Is(err, io.EOF) {
+		return "", false, err
+	}
+	if err := uploads.Sniff(contentType, head[:n]); err != nil {
+		return err.Error(), false, nil
+	}
+
+	threat, err := scan.Scan(ctx, io.MultiReader(bytes.NewReader(head[:n]), body), local.size)
+	if stderrors.Is(err, scan.ErrTooLarge) {
+		return err.Error(), false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if threat != "" {
+		return fmt.Sprintf("malware found: %s", threat), true, nil
+	}
+	return "", false, nil
+}
+
+// quarantinePrefix is where infected files are kept when configured to, out of reach of clients and ingest.
+const quarantinePrefix = "quarantine/"
+
+// refuse deletes a file that didn't pass inspection, first moving infected ones under quarantinePrefix if configured to.
+func refuse(ctx context.Context, store storage.Storage, key string, infected bool) {
+	if infected && scan.Quarantines() {
+		if err := store.Copy(ctx, key, quarantinePrefix+key); err != nil {
+			errors("Failed to quarantine %s, keeping it: %v", key, err)
+			return
 		}
+		log.Printf("Quarantined %s", key)
+	}
+	deleteUpload(ctx, store, key)
+}
 
-		switch uploadType {
-		case "attachments":
-			if len(parts) != 3 {
-				skips("Skipping invalid attachment key (expected 3 parts): %s", decoded)
-				continue
+func reject(attachment *models.Attachment, reason string) error {
+	attachment.Status, attachment.Reason = models.RejectedUpload, reason
+	return db.DB.Model(attachment).Updates(map[string]any{"status": attachment.Status, "reason": attachment.Reason}).Error
+}
+
+func deleteUpload(ctx context.Context, store storage.Storage, key string) {
+	if err := store.Delete(ctx, key); err != nil {
+		errors("Failed to delete %s: %v", key, err)
+		return
+	}
+	log.Printf("Successfully deleted %s", key)
+}
+
+// Sweep settles attachments stuck on their way to ready: completed if their file did arrive
+// and its notification got lost, deleted if it never did, with the parts of their multipart upload.
+// Rejected ones are deleted once kept long enough, multipart uploads no attachment waits for are aborted
+// and blobs no attachment is stored in are deleted. Notes without an embedding are embedded on the way.
+func Sweep(ctx context.Context, store storage.Storage) {
+	var stale []models.Attachment
+	if err := db.DB.
+		Where(
+			"(status IN ? AND updated_at < ?) OR (status = ? AND updated_at < ?)",
+			[]models.UploadStatus{models.PendingUpload, models.ScanningUpload},
+			time.Now().Add(-staleUpload),
+			models.RejectedUpload,
+			time.Now().Add(-keepRejected),
+		).
+		Find(&stale).Error; err != nil {
+		errors("Failed to find stale attachments: %v", err)
+		return
+	}
+
+	for _, attachment := range stale {
+		object, err := store.Head(ctx, attachment.UploadKey())
+		switch {
+		case err == nil && attachment.Status != models.RejectedUpload:
+			if err := complete(ctx, store, &attachment, object); err != nil {
+				errors("Failed to complete stale attachment %s: %v", attachment.ID, err)
+			}
+		case err == nil || stderrors.Is(err, storage.ErrNotFound):
+			if attachment.UploadID != "" {
+				abortUpload(ctx, store, attachment.UploadKey(), attachment.UploadID)
 			}
-			handleAttachmentUpload(parts, decoded, head)
-		case "avatars":
-			if len(parts) != 2 {
-				skips("Skipping invalid avatar key (expected 2 parts): %s", decoded)
+			deleteUpload(ctx, store, attachment.UploadKey())
+			if attachment.Thumbnails {
+				deleteThumbnails(ctx, store, &attachment)
+			}
+			if err := db.DB.Delete(&attachment).Error; err != nil {
+				errors("Failed to delete stale attachment %s: %v", attachment.ID, err)
 				continue
 			}
-			handleAvatarUpload(parts, decoded, distribution)
+			if attachment.SHA256 != "" {
+				DropBlob(ctx, store, attachment.Key())
+			}
 		default:
-			skips("Skipping unrecognized upload type for key: %s", decoded)
+			errors("Failed to fetch metadata for %s: %v", attachment.UploadKey(), err)
 		}
 	}
 
-	return nil
+	log.Printf("Swept %d stale attachments", len(stale))
+	sweepMultipart(ctx, store)
+	sweepBlobs(ctx, store)
+	sweepEmbeddings(ctx)
 }
 
-// handleAttachmentUpload contains the original logic for note attachments
-func handleAttachmentUpload(parts []string, key string, head *s3.HeadObjectOutput) {
-	noteID, err := uuid.Parse(parts[1])
+// sweepBlobs drops the blobs no attachment is stored in any more, like those of attachments
+// left over from notes deleted for good.
+func sweepBlobs(ctx context.Context, store storage.Storage) {
+	var unused []string
+	if err := db.DB.
+		Model(&models.Blob{}).
+		Where("refs <= 0 AND updated_at < ?", time.Now().Add(-staleUpload)).
+		Pluck("key", &unused).Error; err != nil {
+		errors("Failed to find unused blobs: %v", err)
+		return
+	}
+	for _, key := range unused {
+		DropBlob(ctx, store, key)
+	}
+}
+
+// sweepMultipart aborts stale multipart uploads of attachments no longer pending them,
+// like those deleted before their upload could be aborted.
+func sweepMultipart(ctx context.Context, store storage.Storage) {
+	started, err := store.ListMultipart(ctx, string(uploads.Attachments)+"/")
 	if err != nil {
-		errors("Incorrect note ID format in key %s: %v", key, err)
+		errors("Failed to list multipart uploads: %v", err)
 		return
 	}
 
-	filename := parts[2]
-	mimeType := aws.StringValue(head.ContentType)
-	size := aws.Int64Value(head.ContentLength)
+	var stale []storage.Multipart
+	var ids []string
+	for _, upload := range started {
+		if upload.Started.Before(time.Now().Add(-staleUpload)) {
+			stale = append(stale, upload)
+			ids = append(ids, upload.UploadID)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
 
-	attachment := models.NewAttachment(noteID, filename, mimeType, size)
+	var pending []string
+	if err := db.DB.Model(&models.Attachment{}).Where("upload_id IN ?", ids).Pluck("upload_id", &pending).Error; err != nil {
+		errors("Failed to find attachments of multipart uploads: %v", err)
+		return
+	}
 
-	if err := db.DB.Create(&attachment).Error; err != nil {
-		errors("Failed to save attachment for key %s: %v", key, err)
-	} else {
-		log.Printf("Successfully saved attachment: %s", key)
+	aborted := 0
+	for _, upload := range stale {
+		if !slices.Contains(pending, upload.UploadID) {
+			abortUpload(ctx, store, upload.Key, upload.UploadID)
+			aborted++
+		}
+	}
+	log.Printf("Aborted %d abandoned multipart uploads", aborted)
+}
+
+func abortUpload(ctx context.Context, store storage.Storage, key string, uploadID string) {
+	if err := store.AbortMultipart(ctx, key, uploadID); err != nil && !stderrors.Is(err, storage.ErrNotFound) {
+		errors("Failed to abort upload %s of %s: %v", uploadID, key, err)
+		return
 	}
+	log.Printf("Aborted upload %s of %s", uploadID, key)
 }
 
-func handleAvatarUpload(parts []string, key string, distributionAlias string) {
+// SweepEvery sweeps store every interval until ctx is done, for when ingest runs inside the API.
+func SweepEvery(ctx context.Context, store storage.Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			Sweep(ctx, store)
+		}
+	}
+}
+
+// indexAttachment extracts the text of a document attachment uploaded at key out of its data, which a trigger
+// then folds into the note's search vector. Failures only cost the attachment its searchable text.
+func indexAttachment(key string, data []byte, attachment *models.Attachment) {
+	kind, ok := extract.KindOf(attachment.MimeType, attachment.FileName)
+	if !ok || data == nil {
+		return
+	}
+
+	text, err := extract.Text(kind, data)
+	if err != nil {
+		skips("Failed to extract text from %s: %v", key, err)
+		return
+	}
+	if text == "" {
+		skips("No text found in %s", key)
+		return
+	}
+
+	if err := db.DB.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "attachment_id"}}, UpdateAll: true}).
+		Create(&models.AttachmentText{AttachmentID: attachment.ID, Text: text}).
+		Error; err != nil {
+		skips("Failed to save text of %s: %v", key, err)
+		return
+	}
+	log.Printf("Indexed %d bytes of %s text from %s", len(text), kind, key)
+}
+
+// makeThumbnails stores thumbnails of an image or PDF attachment uploaded at key, made of its data, and tells
+// whether it has them. Those of a file it replaces go, so they don't show the old one. Failures only cost
+// the attachment its thumbnails.
+func makeThumbnails(ctx context.Context, store storage.Storage, key string, data []byte, attachment *models.Attachment) bool {
+	if attachment.Thumbnails {
+		deleteThumbnails(ctx, store, attachment)
+	}
+	if !thumbnail.Supports(attachment.MimeType) || data == nil {
+		return false
+	}
+
+	thumbnails, err := thumbnail.Make(attachment.MimeType, data)
+	if err != nil {
+		skips("No thumbnails of %s: %v", key, err)
+		return false
+	}
+
+	for size, image := range thumbnails {
+		if err := store.Put(ctx, attachment.ThumbnailKey(size), thumbnail.ContentType, bytes.NewReader(image)); err != nil {
+			skips("Failed to save %s thumbnail of %s: %v", size, key, err)
+			deleteThumbnails(ctx, store, attachment)
+			return false
+		}
+	}
+	log.Printf("Made %d thumbnails of %s", len(thumbnails), key)
+	return true
+}
+
+// deleteThumbnails deletes the thumbnails of an attachment, whichever of them there are.
+func deleteThumbnails(ctx context.Context, store storage.Storage, attachment *models.Attachment) {
+	for _, size := range thumbnail.Sizes {
+		if err := store.Delete(ctx, attachment.ThumbnailKey(size.Name)); err != nil {
+			errors("Failed to delete %s thumbnail of %s: %v", size.Name, attachment.Key(), err)
+		}
+	}
+}
+
+// avatarSizes are the sides of the square avatars made of an upload, in pixels, largest first.
+// The user's avatar URL is of the largest.
+var avatarSizes = []int{512, 128}
+
+// avatarKey is where an avatar of a size is kept: every upload makes a new version, so its URL
+// changes and caches never serve the previous one.
+func avatarKey(userID uuid.UUID, version string, size int) string {
+	return fmt.Sprintf("avatars/%s-%s/%d.jpg", userID, version, size)
+}
+
+// handleAvatarUpload makes square avatars of an uploaded image, downloaded to local, without its metadata, and sets the
+// user's avatar to them. The upload and previous versions are deleted, as are uploads that aren't images.
+func handleAvatarUpload(ctx context.Context, store storage.Storage, parts []string, object storage.Object, local *upload, avatarURL func(key string) string) {
+	key := object.Key
 	userID, err := uuid.Parse(parts[1])
 	if err != nil {
 		errors("Incorrect user ID format in key %s: %v", key, err)
 		return
 	}
+	defer func() {
+		// unless it was replaced meanwhile, the new upload having its own notification
+		if current, err := store.Head(ctx, key); err == nil && current.Modified.After(object.Modified) {
+			return
+		}
+		deleteUpload(ctx, store, key)
+	}()
+
+	data, err := local.read(uploads.MaxSize(uploads.Avatars))
+	if err != nil {
+		errors("Failed to read avatar %s: %v", key, err)
+		return
+	}
 
-	// full public URL using the CloudFront distribution
-	finalURL := fmt.Sprintf("https://%s/%s", distributionAlias, key)
+	avatars, err := thumbnail.Avatar(data, avatarSizes...)
+	if err != nil {
+		log.Printf("File %s is not an image avatars can be made of (%v). Deleting.", key, err)
+		return
+	}
 
+	// fixed width, so versions sort by when they were made
+	version := fmt.Sprintf("%013d", time.Now().UnixMilli())
+	for i, size := range avatarSizes {
+		if err := store.Put(ctx, avatarKey(userID, version, size), thumbnail.ContentType, bytes.NewReader(avatars[i])); err != nil {
+			errors("Failed to save avatar of user %s: %v", userID, err)
+			deleteAvatars(ctx, store, userID, func(v string) bool { return v == version })
+			return
+		}
+	}
+
+	finalURL := avatarURL(avatarKey(userID, version, avatarSizes[0]))
 	result := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("avatar_url", finalURL)
 
 	if result.Error != nil {
 		errors("Failed to update avatar URL for user %s: %v", userID, result.Error)
+		deleteAvatars(ctx, store, userID, func(v string) bool { return v == version })
 		return
 	}
 
 	if result.RowsAffected == 0 {
 		errors("Attempted to update avatar for user %s, but user was not found.", userID)
+		deleteAvatars(ctx, store, userID, func(v string) bool { return v == version })
 		return
 	}
 
 	log.Printf("Successfully updated avatar for user %s. New URL: %s", userID, finalURL)
+	// versions made since by another upload stay
+	deleteAvatars(ctx, store, userID, func(v string) bool { return v < version })
+}
+
+// deleteAvatars deletes the versions of a user's avatars which tells to.
+func deleteAvatars(ctx context.Context, store storage.Storage, userID uuid.UUID, which func(version string) bool) {
+	prefix := fmt.Sprintf("avatars/%s-", userID)
+	objects, err := store.List(ctx, prefix)
+	if err != nil {
+		errors("Failed to list avatars of user %s: %v", userID, err)
+		return
+	}
+	for _, object := range objects {
+		version, _, _ := strings.Cut(strings.TrimPrefix(object.Key, prefix), "/")
+		if which(version) {
+			deleteUpload(ctx, store, object.Key)
+		}
+	}
 }
@@ -0,0 +1,68 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"vault/internal/config"
+	"vault/internal/scan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clean finds every file clean, counting the files it read.
+type clean struct{ scanned int }
+
+func (c *clean) Scan(_ context.Context, file io.Reader) (string, error) {
+	c.scanned++
+	_, err := io.Copy(io.Discard, file)
+	return "", err
+}
+
+// local is an upload of data, as download leaves it.
+func local(t *testing.T, data string) *upload {
+	file, err := os.CreateTemp(t.TempDir(), "ingest-*")
+	require.NoError(t, err)
+	_, err = file.WriteString(data)
+	require.NoError(t, err)
+	u := &upload{file: file, size: int64(len(data))}
+	t.Cleanup(u.remove)
+	return u
+}
+
+func TestInspect(t *testing.T) {
+	require.NoError(t, scan.Init(config.ScanConfig{ScanMaxSize: 16, Infected: "delete"}))
+	t.Cleanup(func() { scan.Default = scan.Nop{} })
+
+	t.Run("Clean", func(t *testing.T) {
+		scanner := &clean{}
+		scan.Default = scanner
+
+		reason, infected, err := inspect(context.Background(), local(t, "a short note"), "text/plain")
+		require.NoError(t, err)
+		assert.Empty(t, reason)
+		assert.False(t, infected)
+		assert.Equal(t, 1, scanner.scanned)
+	})
+
+	t.Run("OverScanLimit", func(t *testing.T) {
+		scanner := &clean{}
+		scan.Default = scanner
+
+		reason, infected, err := inspect(context.Background(), local(t, "a note longer than the scanner takes"), "text/plain")
+		require.NoError(t, err)
+		assert.Contains(t, reason, "too large to be scanned")
+		assert.False(t, infected)
+		assert.Zero(t, scanner.scanned)
+	})
+
+	t.Run("OverScanLimitWithoutScanner", func(t *testing.T) {
+		scan.Default = scan.Nop{}
+
+		reason, _, err := inspect(context.Background(), local(t, "a note longer than the scanner takes"), "text/plain")
+		require.NoError(t, err)
+		assert.Empty(t, reason)
+	})
+}
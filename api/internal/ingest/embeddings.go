@@ -0,0 +1,54 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"vault/internal/db"
+	"vault/internal/embed"
+	"vault/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// embedBatch is how many notes missing an embedding are embedded at a time.
+const embedBatch = 500
+
+// sweepEmbeddings embeds the notes written before embeddings existed, or with an older model, which
+// semantic search and related notes leave out until then. Notes are embedded by the API when saved.
+func sweepEmbeddings(ctx context.Context) {
+	embedded := 0
+	for ctx.Err() == nil {
+		var notes []models.Note
+		if err := db.DB.
+			Where(
+				"NOT EXISTS (SELECT 1 FROM note_embeddings WHERE note_embeddings.note_id = notes.id AND note_embeddings.model = ?)",
+				embed.Default.Name(),
+			).
+			Preload("Tags").
+			Limit(embedBatch).
+			Find(&notes).Error; err != nil {
+			errors("Failed to find notes to embed: %v", err)
+			break
+		}
+		if len(notes) == 0 {
+			break
+		}
+
+		embeddings := make([]models.NoteEmbedding, len(notes))
+		for i := range notes {
+			embeddings[i] = models.NewNoteEmbedding(&notes[i], embed.Default)
+		}
+		if err := db.DB.
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "note_id"}}, UpdateAll: true}).
+			Create(&embeddings).Error; err != nil {
+			errors("Failed to save embeddings: %v", err)
+			break
+		}
+
+		embedded += len(notes)
+		if len(notes) < embedBatch {
+			break
+		}
+	}
+	log.Printf("Embedded %d notes", embedded)
+}
@@ -3,6 +3,8 @@ package jwtx
 import (
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestJWTGenerateAndParse(t *testing.T) {
@@ -14,10 +16,10 @@ func TestJWTGenerateAndParse(t *testing.T) {
 	Init(testSecret, testAuthLifespan, testRefreshLifespan)
 
 	// Test user ID
-	var userID uint = 123
+	userID := uuid.New()
 
 	// Generate a token
-	token, err := Generate(userID)
+	token, err := Generate(userID, true)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -29,7 +31,7 @@ func TestJWTGenerateAndParse(t *testing.T) {
 	}
 
 	// Verify the claims
-	if claims["sub"] != float64(userID) {
+	if claims["sub"] != userID.String() {
 		t.Errorf("Expected user ID %v, got %v", userID, claims["sub"])
 	}
 
@@ -55,10 +57,10 @@ func TestRefreshToken(t *testing.T) {
 	Init(testSecret, testAuthLifespan, testRefreshLifespan)
 
 	// Test user ID
-	var userID uint = 123
+	userID := uuid.New()
 
 	// Generate a refresh token
-	token, err := GenerateRefresh(userID)
+	token, err := GenerateRefresh(userID, true)
 	if err != nil {
 		t.Fatalf("Failed to generate refresh token: %v", err)
 	}
@@ -70,7 +72,7 @@ func TestRefreshToken(t *testing.T) {
 	}
 
 	// Verify the claims
-	if claims["sub"] != float64(userID) {
+	if claims["sub"] != userID.String() {
 		t.Errorf("Expected user ID %v, got %v", userID, claims["sub"])
 	}
 
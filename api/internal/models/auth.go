@@ -8,14 +8,14 @@ import (
 type PublicUserOut struct {
 	ID        uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
 	Username  string    `json:"username" example:"jane_doe" binding:"required"`
-	AvatarUrl string    `json:"avatar_url" example:"https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000"`
+	AvatarUrl string    `json:"avatar_url" example:"https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000-1760832000000/512.jpg"`
 } // @name PublicUserOut
 
 type UserOut struct {
 	ID                uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
 	Email             string    `json:"email" example:"jane@mail.com"`
 	Username          string    `json:"username" example:"jane_doe" binding:"required"`
-	AvatarUrl         string    `json:"avatar_url" example:"https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000"`
+	AvatarUrl         string    `json:"avatar_url" example:"https://vault.awry.me/avatars/123e4567-e89b-12d3-a456-426614174000-1760832000000/512.jpg"`
 	NotesCount        int       `json:"notes_count" example:"42"`
 	DeletedNotesCount int       `json:"deleted_notes_count" example:"5"`
 	AttachmentsCount  int       `json:"attachments_count" example:"10"`
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Blob is an attachment file stored once for all of a user's attachments with the same content,
+// which point their ObjectKey at it. A trigger counts them in Refs, and the file is deleted once none are left.
+type Blob struct {
+	Key       string    `gorm:"primaryKey"` // where the file is kept in storage, see BlobKey
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	SHA256    string    `gorm:"column:sha256;not null"` // of the content, in hex
+	Size      int64     `gorm:"not null"`
+	Refs      int       `gorm:"not null;default:0;index:idx_blobs_unused,priority:1"` // attachments stored in it
+	CreatedAt time.Time
+	UpdatedAt time.Time `gorm:"index:idx_blobs_unused,priority:2"` // last taken by an attachment
+}
+
+// BlobKey is where a user's file with the content of a SHA-256 is stored, "blobs/{userId}/{sha256}".
+func BlobKey(userID uuid.UUID, sha256 string) string {
+	return fmt.Sprintf("blobs/%s/%s", userID, sha256)
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlob_DB(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&Blob{}))
+
+	userID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	key := BlobKey(userID, sum)
+	assert.Equal(t, "blobs/123e4567-e89b-12d3-a456-426614174000/"+sum, key)
+
+	require.NoError(t, db.Create(&Blob{Key: key, UserID: userID, SHA256: sum, Size: 4}).Error)
+	note := Note{UserID: userID, Title: "Note"}
+	require.NoError(t, db.Create(&note).Error)
+	for range 2 {
+		a := Attachment{NoteID: note.ID, FileName: "test.txt", ObjectKey: key, SHA256: sum}
+		require.NoError(t, db.Create(&a).Error)
+	}
+
+	var stored Blob
+	require.NoError(t, db.First(&stored, "key = ?", key).Error)
+	assert.Equal(t, sum, stored.SHA256)
+	assert.Equal(t, int64(4), stored.Size)
+
+	var holders int64
+	require.NoError(t, db.Model(&Attachment{}).Where("object_key = ? AND sha256 = ?", key, sum).Count(&holders).Error)
+	assert.Equal(t, int64(2), holders)
+}
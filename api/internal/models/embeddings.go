@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"vault/internal/embed"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Vector is an embedding, stored as real[] in Postgres and as text elsewhere.
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	return "{" + v.join() + "}", nil
+}
+
+// Literal is the vector in pgvector's input format.
+func (v Vector) Literal() string {
+	return "[" + v.join() + "]"
+}
+
+func (v Vector) join() string {
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = strconv.FormatFloat(float64(x), 'g', -1, 32)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v *Vector) Scan(src any) error {
+	var text string
+	switch s := src.(type) {
+	case string:
+		text = s
+	case []byte:
+		text = string(s)
+	case nil:
+		*v = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into a vector", src)
+	}
+
+	text = strings.Trim(text, "{}[]")
+	if text == "" {
+		*v = Vector{}
+		return nil
+	}
+
+	parts := strings.Split(text, ",")
+	out := make(Vector, len(parts))
+	for i, part := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector: %w", err)
+		}
+		out[i] = float32(x)
+	}
+	*v = out
+	return nil
+}
+
+func (Vector) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "real[]"
+	}
+	return "text"
+}
+
+// NoteEmbedding is the embedding of a note's title, tags and content, used to find notes alike.
+// When pgvector is installed a migration mirrors it into an indexed vector column.
+type NoteEmbedding struct {
+	NoteID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Note      Note      `gorm:"constraint:OnDelete:CASCADE"`
+	Model     string    `gorm:"not null;index"`
+	Embedding Vector    `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// NewNoteEmbedding embeds a note, leaving out the content of encrypted ones which is ciphertext.
+func NewNoteEmbedding(note *Note, e embed.Embedder) NoteEmbedding {
+	parts := []string{note.Title}
+	for _, tag := range note.Tags {
+		parts = append(parts, tag.Name)
+	}
+	if !note.Encrypted {
+		parts = append(parts, note.Content)
+	}
+
+	return NoteEmbedding{
+		NoteID:    note.ID,
+		Model:     e.Name(),
+		Embedding: e.Embed(strings.Join(parts, "\n")),
+	}
+}
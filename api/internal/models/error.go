@@ -1,6 +1,7 @@
 package models
 
 type ErrorResponse struct {
-	Error string `json:"error" example:"An unexpected error occurred"`
-	Code  string `json:"code" example:"InternalError"`
+	Error   string         `json:"error" example:"An unexpected error occurred"`
+	Code    string         `json:"code" example:"InternalError"`
+	Details map[string]any `json:"details,omitempty"`
 } // @name ErrorResponse
@@ -0,0 +1,23 @@
+package models
+
+type PasswordRequest struct {
+	Length           int   `json:"length" example:"20"`
+	Lowercase        *bool `json:"lowercase,omitempty" example:"true"`
+	Uppercase        *bool `json:"uppercase,omitempty" example:"true"`
+	Digits           *bool `json:"digits,omitempty" example:"true"`
+	Symbols          *bool `json:"symbols,omitempty" example:"true"`
+	ExcludeAmbiguous bool  `json:"exclude_ambiguous" example:"false"`
+	Pronounceable    bool  `json:"pronounceable" example:"false"`
+} // @name PasswordRequest
+
+type PassphraseRequest struct {
+	Words         int     `json:"words" example:"6"`
+	Separator     *string `json:"separator,omitempty" example:"-"`
+	Capitalize    bool    `json:"capitalize" example:"false"`
+	IncludeNumber bool    `json:"include_number" example:"false"`
+} // @name PassphraseRequest
+
+type GeneratedSecretOut struct {
+	Value   string  `json:"value" example:"correct-horse-battery-staple" binding:"required"`
+	Entropy float64 `json:"entropy" example:"51.7" binding:"required"`
+} // @name GeneratedSecretOut
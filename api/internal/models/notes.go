@@ -1,9 +1,14 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/uuid"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+	"vault/internal/storage"
 )
 
 // Note represents a secure user note
@@ -15,44 +20,172 @@ type Note struct {
 	Content      string       `json:"content" binding:"required"`
 	Encrypted    bool         `json:"encrypted"`
 	Archived     bool         `json:"archived"`
+	Type         NoteType     `json:"type" gorm:"type:varchar(16);default:note;not null;index"`
+	Fields       NoteFields   `json:"fields" gorm:"serializer:json"`
 	Attachments  []Attachment `json:"attachments" gorm:"foreignKey:NoteID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Shares       []NoteShare  `json:"shares" gorm:"foreignKey:NoteID"`
-	SearchVector string       `json:"-" gorm:"type:tsvector;"` // index created in migration not to break tests
+	Tags         []Tag        `json:"tags" gorm:"many2many:note_tags;constraint:OnDelete:CASCADE"`
+	SearchVector string       `json:"-" gorm:"type:tsvector;"`     // index created in migration not to break tests
+	Version      int64        `json:"-" gorm:"not null;default:0"` // set by a trigger, see SyncChange
 }
 
 func (n *Note) String() string {
 	return fmt.Sprintf("Note #%d: %s", n.ID, n.Title)
 }
 
-// Attachment represents a file attached to a note
+// Password returns the stored password of a login item, if any
+func (n *Note) Password() string {
+	if n.Type != LoginNote {
+		return ""
+	}
+	return n.Fields[PasswordField]
+}
+
+type NoteType string // @name NoteType
+
+const (
+	PlainNote NoteType = "note"
+	LoginNote NoteType = "login"
+)
+
+func NewNoteType(v string) (NoteType, error) {
+	switch v {
+	case "", "note":
+		return PlainNote, nil
+	case "login":
+		return LoginNote, nil
+	default:
+		return "", fmt.Errorf("invalid note type: %s", v)
+	}
+}
+
+// NoteFields holds the structured fields of an item, e.g. username and password of a login
+type NoteFields map[string]string
+
+const (
+	UsernameField = "username"
+	PasswordField = "password"
+	URLField      = "url"
+)
+
+// Attachment represents a file attached to a note.
+// It's uploaded under its own ID, then kept in the Blob of its content, the file name is only what it's downloaded as.
 type Attachment struct {
 	Model
-	NoteID    uuid.UUID `json:"note_id"`
-	FileName  string    `json:"file_name"`
-	MimeType  string    `json:"mime_type"`
-	Size      int64     `json:"size"`
-	Shared    bool      `json:"shared"`
-	CreatedAt time.Time `json:"created_at"`
+	NoteID     uuid.UUID    `json:"note_id"`
+	FileName   string       `json:"file_name"`
+	MimeType   string       `json:"mime_type"`
+	Size       int64        `json:"size"`
+	Shared     bool         `json:"shared"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" gorm:"index:idx_attachments_status,priority:2"`
+	ObjectKey  string       `json:"-" gorm:"index"`                                            // where the file is kept in storage
+	SHA256     string       `json:"sha256,omitempty" gorm:"column:sha256;not null;default:''"` // of the content, in hex, once ready
+	Status     UploadStatus `json:"status" gorm:"not null;default:'ready';index:idx_attachments_status,priority:1"`
+	Reason     string       `json:"reason,omitempty" gorm:"not null;default:''"` // why the upload was rejected
+	UploadID   string       `json:"-" gorm:"not null;default:''"`                // of the multipart upload under way
+	Thumbnails bool         `json:"-" gorm:"not null;default:false"`             // whether ingest made thumbnails of it
+	Version    int64        `json:"-" gorm:"not null;default:0"`
 }
 
+// UploadStatus is where an attachment's upload stands.
+type UploadStatus string // @name UploadStatus
+
+const (
+	PendingUpload  UploadStatus = "pending"  // presigned, the file hasn't arrived yet
+	ScanningUpload UploadStatus = "scanning" // arrived and being checked
+	ReadyUpload    UploadStatus = "ready"
+	RejectedUpload UploadStatus = "rejected" // the file was deleted, the reason says why
+)
+
 func (a *Attachment) String() string {
 	return fmt.Sprintf("Attachment #%s of type %s to note #%s", a.ID, a.MimeType, a.NoteID)
 }
 
-func AttachmentKey(noteId string, filename string) string {
-	return fmt.Sprintf("attachments/%s/%s", noteId, filename)
+// AttachmentKey is where an attachment is uploaded, "attachments/{noteId}/{attachmentId}".
+func AttachmentKey(noteID uuid.UUID, attachmentID uuid.UUID) string {
+	return fmt.Sprintf("attachments/%s/%s", noteID, attachmentID)
+}
+
+// UploadKey is where files are uploaded to the attachment, see AttachmentKey.
+func (a *Attachment) UploadKey() string {
+	return AttachmentKey(a.NoteID, a.ID)
 }
 
+// Key is where the attachment is stored: its blob once ready, its upload before. Attachments from before
+// they were keyed by ID keep their file name key.
 func (a *Attachment) Key() string {
-	return AttachmentKey(a.NoteID.String(), a.FileName)
+	if a.ObjectKey != "" {
+		return a.ObjectKey
+	}
+	return fmt.Sprintf("attachments/%s/%s", a.NoteID, a.FileName)
+}
+
+// ThumbnailSize names a thumbnail of an attachment, see internal/thumbnail for their dimensions.
+type ThumbnailSize string
+
+const (
+	SmallThumbnail  ThumbnailSize = "small"
+	MediumThumbnail ThumbnailSize = "medium"
+	LargeThumbnail  ThumbnailSize = "large"
+)
+
+// ThumbnailKey is where a thumbnail of an attachment is stored, "thumbnails/{noteId}/{attachmentId}/{size}.jpg".
+func (a *Attachment) ThumbnailKey(size ThumbnailSize) string {
+	return fmt.Sprintf("thumbnails/%s/%s/%s.jpg", a.NoteID, a.ID, size)
+}
+
+// maxFileName is the longest file name kept, in bytes, which is what most file systems allow.
+const maxFileName = 255
+
+// SanitizeFileName makes an uploaded file name safe to keep and send back: the last path element,
+// without control characters, quotes or leading dots, at most maxFileName bytes.
+func SanitizeFileName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case r == utf8.RuneError, unicode.IsControl(r), r == '"':
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+
+	for len(name) > maxFileName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	if name == "" {
+		return "file"
+	}
+	return name
 }
 
-func NewAttachment(noteId uuid.UUID, fileName string, mimeType string, size int64) Attachment {
+// AttachmentText is the text extracted from an attachment, folded into its note's search vector.
+type AttachmentText struct {
+	AttachmentID uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Attachment   Attachment `gorm:"constraint:OnDelete:CASCADE"`
+	Text         string     `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// NewPendingAttachment is an attachment about to be uploaded, keyed by its ID, of the size the client declared.
+func NewPendingAttachment(noteID uuid.UUID, fileName string, mimeType string, size int64) Attachment {
+	id := uuid.New()
 	return Attachment{
-		NoteID:    noteId,
-		FileName:  fileName,
+		Model:     Model{ID: id},
+		NoteID:    noteID,
+		FileName:  SanitizeFileName(fileName),
 		MimeType:  mimeType,
 		Size:      size,
+		ObjectKey: AttachmentKey(noteID, id),
+		Status:    PendingUpload,
 		CreatedAt: time.Now(),
 	}
 }
@@ -62,6 +195,8 @@ type Permission string // @name Permission
 const (
 	ReadPermission  Permission = "read"
 	WritePermission Permission = "write"
+	// OwnerPermission is what owners have on their own notes, it can't be granted through a share
+	OwnerPermission Permission = "owner"
 )
 
 func NewPermission(v string) (Permission, error) {
@@ -83,27 +218,64 @@ type NoteShare struct {
 	SharedWith       User       `json:"shared_with" gorm:"foreignKey:SharedWithUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Permission       Permission `json:"permission"` // "read", "write"
 	Expires          *time.Time `json:"expires,omitempty"`
+	Version          int64      `json:"-" gorm:"not null;default:0"`
 }
 
 type NoteIn struct {
-	Title   string `json:"title" binding:"required" example:"Meeting Notes"`
-	Content string `json:"content" binding:"required" example:"Notes from the meeting with the client."`
+	Title   string            `json:"title" binding:"required" example:"Meeting Notes"`
+	Content string            `json:"content" binding:"required" example:"Notes from the meeting with the client."`
+	Type    string            `json:"type,omitempty" example:"login"` // "note" or "login", an edit leaves it as is when omitted
+	Fields  map[string]string `json:"fields,omitempty"`               // an edit leaves them as they are when omitted or null
+	Tags    []string          `json:"tags" example:"infra,aws"`       // replaces the note's tags, left as is when omitted or null
 } // @name NoteIn
 
 type AttachmentOut struct {
-	ID       uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
-	Filename string    `json:"filename" example:"document.pdf"`
-	MimeType string    `json:"mime_type" example:"application/pdf"`
-	Size     int64     `json:"size" example:"123456"`
+	ID           uuid.UUID      `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
+	Filename     string         `json:"filename" example:"document.pdf"`
+	MimeType     string         `json:"mime_type" example:"application/pdf"`
+	Size         int64          `json:"size" example:"123456"`
+	SHA256       string         `json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // of the content, to check downloads against
+	ThumbnailURL *ThumbnailURLs `json:"thumbnail_url,omitempty"`                                                                     // of images and some PDFs
 } // @name AttachmentOut
 
+// ThumbnailURLs are temporary URLs of an attachment's JPEG thumbnails, which fit in squares of 160, 480
+// and 1200 pixels and are never larger than the original.
+type ThumbnailURLs struct {
+	Small  string `json:"small" binding:"required" example:"https://bucket.s3.amazonaws.com/thumbnails/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d/small.jpg"`
+	Medium string `json:"medium" binding:"required"`
+	Large  string `json:"large" binding:"required"`
+} // @name ThumbnailURLs
+
 func NewAttachmentOut(a *Attachment) AttachmentOut {
 	return AttachmentOut{
-		ID:       a.ID,
-		Filename: a.FileName,
-		MimeType: a.MimeType,
-		Size:     a.Size,
+		ID:           a.ID,
+		Filename:     a.FileName,
+		MimeType:     a.MimeType,
+		Size:         a.Size,
+		SHA256:       a.SHA256,
+		ThumbnailURL: newThumbnailURLs(a),
+	}
+}
+
+// newThumbnailURLs presigns the downloads of an attachment's thumbnails, if it has any and storage is set up.
+func newThumbnailURLs(a *Attachment) *ThumbnailURLs {
+	if !a.Thumbnails || storage.Default == nil {
+		return nil
 	}
+
+	var urls ThumbnailURLs
+	for size, url := range map[ThumbnailSize]*string{
+		SmallThumbnail:  &urls.Small,
+		MediumThumbnail: &urls.Medium,
+		LargeThumbnail:  &urls.Large,
+	} {
+		presigned, err := storage.Default.PresignGet(context.Background(), a.ThumbnailKey(size), "")
+		if err != nil {
+			return nil
+		}
+		*url = presigned
+	}
+	return &urls
 }
 
 type NoteOut struct {
@@ -113,20 +285,43 @@ type NoteOut struct {
 	Author      PublicUserOut   `json:"author"  binding:"required"`
 	Encrypted   bool            `json:"encrypted"`
 	Archived    bool            `json:"archived"`
+	Type        NoteType        `json:"type" example:"note"`
+	Fields      NoteFields      `json:"fields,omitempty"`
+	Tags        []string        `json:"tags" example:"infra,aws"`
 	CreatedAt   time.Time       `json:"created_at" binding:"required"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 	Attachments []AttachmentOut `json:"attachments"`
 	Shares      []NoteShareOut  `json:"shares"`
 } // @name NoteOut
 
-func NewNote(n *NoteIn, userID uuid.UUID) Note {
+func NewNote(n *NoteIn, userID uuid.UUID, noteType NoteType) Note {
 	return Note{
 		Title:   n.Title,
 		Content: n.Content,
+		Type:    noteType,
+		Fields:  n.Fields,
 		UserID:  userID,
 	}
 }
 
+// Edit applies an edit to a note. Its type and fields stay as they are when the edit leaves them out,
+// so clients that only know about title and content don't turn a login into a plain note.
+func (n *Note) Edit(in *NoteIn) error {
+	if in.Type != "" {
+		noteType, err := NewNoteType(in.Type)
+		if err != nil {
+			return err
+		}
+		n.Type = noteType
+	}
+	if in.Fields != nil {
+		n.Fields = in.Fields
+	}
+	n.Title = in.Title
+	n.Content = in.Content
+	return nil
+}
+
 func NewNoteOut(n *Note) NoteOut {
 	attachments := make([]AttachmentOut, len(n.Attachments))
 	for i, att := range n.Attachments {
@@ -143,6 +338,9 @@ func NewNoteOut(n *Note) NoteOut {
 		Content:     n.Content,
 		Encrypted:   n.Encrypted,
 		Archived:    n.Archived,
+		Type:        n.Type,
+		Fields:      n.Fields,
+		Tags:        NewTagNamesOut(n.Tags),
 		CreatedAt:   n.CreatedAt,
 		UpdatedAt:   n.UpdatedAt,
 		Author:      NewPublicUserOut(n.User),
@@ -161,16 +359,18 @@ func NewNoteShare(noteId uuid.UUID, with User, permission Permission, expires *t
 }
 
 type NotesResponse struct {
-	Notes []NoteOut `json:"notes" binding:"required"`
-	Total int       `json:"total" example:"10" binding:"required"`
+	Notes      []NoteOut `json:"notes" binding:"required"`
+	Total      int       `json:"total" example:"10" binding:"required"`                                                // -1 when not counted
+	NextCursor string    `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNi0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"` // absent on the last page
 } // @name NotesResponse
 
-type NoteWithCount struct {
+// SharedNote is a note shared with the user, along with when it was.
+type SharedNote struct {
 	Note
-	NotesCount int `gorm:"column:notes_count"`
+	SharedAt time.Time `gorm:"column:shared_at"`
 }
 
-func (NoteWithCount) TableName() string {
+func (SharedNote) TableName() string {
 	return "notes"
 }
 
@@ -181,7 +381,8 @@ type AttachmentRef struct {
 
 type AttachmentResponse struct {
 	Attachments []AttachmentRef `json:"attachments" binding:"required"`
-	Total       int             `json:"total" example:"10" binding:"required"`
+	Total       int             `json:"total" example:"10" binding:"required"`                                                // -1 when not counted
+	NextCursor  string          `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNi0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"` // absent on the last page
 } // @name AttachmentResponse
 
 type NoteShareOut struct {
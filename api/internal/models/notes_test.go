@@ -1,19 +1,23 @@
 package models
 
 import (
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"strings"
 	"testing"
+	"unicode/utf8"
+	"vault/internal/embed"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&Note{}, &Attachment{}, &NoteShare{})
+	err = db.AutoMigrate(&Note{}, &Attachment{}, &AttachmentText{}, &NoteShare{}, &Tag{})
 	require.NoError(t, err)
 
 	return db
@@ -69,6 +73,59 @@ func TestNote_DB(t *testing.T) {
 		assert.NoError(t, result.Error)
 		assert.Len(t, retrieved.Attachments, 1)
 		assert.Equal(t, attachment.FileName, retrieved.Attachments[0].FileName)
+
+		text := AttachmentText{AttachmentID: retrieved.Attachments[0].ID, Text: "quarterly budget"}
+		assert.NoError(t, db.Create(&text).Error)
+
+		var stored AttachmentText
+		assert.NoError(t, db.First(&stored, "attachment_id = ?", text.AttachmentID).Error)
+		assert.Equal(t, "quarterly budget", stored.Text)
+	})
+
+	t.Run("Login item fields", func(t *testing.T) {
+		note := Note{
+			UserID:  uuid.New(),
+			Title:   "Email",
+			Content: "Personal mailbox",
+			Type:    LoginNote,
+			Fields: NoteFields{
+				UsernameField: "jane",
+				PasswordField: "hunter2",
+			},
+		}
+
+		result := db.Create(&note)
+		assert.NoError(t, result.Error)
+
+		var retrieved Note
+		result = db.First(&retrieved, note.ID)
+		assert.NoError(t, result.Error)
+		assert.Equal(t, LoginNote, retrieved.Type)
+		assert.Equal(t, "jane", retrieved.Fields[UsernameField])
+		assert.Equal(t, "hunter2", retrieved.Password())
+
+		retrieved.Type = PlainNote
+		assert.Empty(t, retrieved.Password())
+	})
+
+	t.Run("Note tags", func(t *testing.T) {
+		userID := uuid.New()
+		infra, aws := Tag{UserID: userID, Name: "infra"}, Tag{UserID: userID, Name: "aws"}
+		note := Note{UserID: userID, Title: "Keys", Content: "Deploy keys", Tags: []Tag{infra, aws}}
+
+		result := db.Create(&note)
+		assert.NoError(t, result.Error)
+
+		var retrieved Note
+		result = db.Preload("Tags").First(&retrieved, note.ID)
+		assert.NoError(t, result.Error)
+		assert.Equal(t, []string{"aws", "infra"}, NewNoteOut(&retrieved).Tags)
+
+		// names are unique per user
+		result = db.Create(&Tag{UserID: userID, Name: "infra"})
+		assert.Error(t, result.Error)
+		result = db.Create(&Tag{UserID: uuid.New(), Name: "infra"})
+		assert.NoError(t, result.Error)
 	})
 
 	t.Run("Note sharing", func(t *testing.T) {
@@ -120,3 +177,220 @@ func TestNote_DB(t *testing.T) {
 		assert.NotNil(t, retrieved.DeletedAt)
 	})
 }
+
+func TestNote_Edit(t *testing.T) {
+	login := func() Note {
+		return Note{
+			Title:  "Bank",
+			Type:   LoginNote,
+			Fields: NoteFields{UsernameField: "jane", PasswordField: "correct horse"},
+		}
+	}
+
+	t.Run("KeepsTypeAndFields", func(t *testing.T) {
+		// what the web client sends
+		note := login()
+		require.NoError(t, note.Edit(&NoteIn{Title: "My bank", Content: "Checking account"}))
+		assert.Equal(t, "My bank", note.Title)
+		assert.Equal(t, "Checking account", note.Content)
+		assert.Equal(t, LoginNote, note.Type)
+		assert.Equal(t, "correct horse", note.Password())
+	})
+
+	t.Run("Replaces", func(t *testing.T) {
+		note := login()
+		require.NoError(t, note.Edit(&NoteIn{Title: "Bank", Content: "x", Fields: map[string]string{PasswordField: "new"}}))
+		assert.Equal(t, NoteFields{PasswordField: "new"}, note.Fields)
+
+		require.NoError(t, note.Edit(&NoteIn{Title: "Bank", Content: "x", Type: "note"}))
+		assert.Equal(t, PlainNote, note.Type)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		note := login()
+		assert.Error(t, note.Edit(&NoteIn{Title: "Bank", Content: "x", Type: "card"}))
+		assert.Equal(t, LoginNote, note.Type)
+	})
+}
+
+func TestNewTagNames(t *testing.T) {
+	t.Run("Normalizes", func(t *testing.T) {
+		names, err := NewTagNames([]string{" Infra ", "aws", "infra", "", "team/ops", "café"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "café", "infra", "team/ops"}, names)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		for _, name := range []string{"two words", "tag:infra", "a,b", strings.Repeat("x", MaxTagLength+1)} {
+			_, err := NewTagNames([]string{name})
+			assert.Error(t, err, name)
+		}
+
+		many := make([]string, MaxTags+1)
+		for i := range many {
+			many[i] = fmt.Sprintf("t%d", i)
+		}
+		_, err := NewTagNames(many)
+		assert.Error(t, err)
+	})
+}
+
+func TestPendingAttachment(t *testing.T) {
+	t.Run("Sanitizes file names", func(t *testing.T) {
+		cases := map[string]string{
+			"keys.txt":                   "keys.txt",
+			"../../etc/passwd":           "passwd",
+			`C:\Users\me\keys.txt`:       "keys.txt",
+			"..hidden":                   "hidden",
+			"line\nbreak \"quoted\".pdf": "line break quoted.pdf",
+			"tab\tname":                  "tab name",
+			"":                           "file",
+			"/":                          "file",
+		}
+		for in, want := range cases {
+			assert.Equal(t, want, SanitizeFileName(in), in)
+		}
+
+		long := SanitizeFileName(strings.Repeat("é", maxFileName))
+		assert.LessOrEqual(t, len(long), maxFileName)
+		assert.True(t, utf8.ValidString(long))
+	})
+
+	t.Run("Keyed by ID", func(t *testing.T) {
+		noteID := uuid.New()
+		first := NewPendingAttachment(noteID, "keys.txt", "text/plain", 12)
+		second := NewPendingAttachment(noteID, "keys.txt", "text/plain", 12)
+
+		assert.Equal(t, PendingUpload, first.Status)
+		assert.Equal(t, "attachments/"+noteID.String()+"/"+first.ID.String(), first.Key())
+		assert.NotEqual(t, first.Key(), second.Key())
+
+		legacy := Attachment{NoteID: noteID, FileName: "keys.txt"}
+		assert.Equal(t, "attachments/"+noteID.String()+"/keys.txt", legacy.Key())
+	})
+}
+
+func TestSavedSearch(t *testing.T) {
+	t.Run("Sort", func(t *testing.T) {
+		sort, err := NewSearchSort("")
+		require.NoError(t, err)
+		assert.Equal(t, RelevanceSort, sort)
+
+		sort, err = NewSearchSort("updated")
+		require.NoError(t, err)
+		assert.Equal(t, UpdatedSort, sort)
+
+		_, err = NewSearchSort("random")
+		assert.Error(t, err)
+	})
+
+	t.Run("Create and retrieve", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		require.NoError(t, db.AutoMigrate(&SavedSearch{}))
+
+		in := SavedSearchIn{Name: " Infra keys ", Query: "tag:infra type:login ", Pinned: true}
+		saved := NewSavedSearch(&in, uuid.New(), MineScope, TitleSort)
+		require.NoError(t, db.Create(&saved).Error)
+
+		var retrieved SavedSearch
+		require.NoError(t, db.First(&retrieved, "id = ?", saved.ID).Error)
+
+		out := NewSavedSearchOut(&retrieved)
+		assert.Equal(t, "Infra keys", out.Name)
+		assert.Equal(t, "tag:infra type:login", out.Query)
+		assert.Equal(t, MineScope, out.Scope)
+		assert.Equal(t, TitleSort, out.Sort)
+		assert.True(t, out.Pinned)
+		assert.Nil(t, out.Count)
+	})
+}
+
+func TestNoteEmbedding(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&NoteEmbedding{}))
+
+	t.Run("Stores the vector", func(t *testing.T) {
+		note := Note{UserID: uuid.New(), Title: "Staging database", Content: "rotate monthly"}
+		require.NoError(t, db.Create(&note).Error)
+
+		embedding := NewNoteEmbedding(&note, embed.Default)
+		require.NoError(t, db.Create(&embedding).Error)
+
+		var stored NoteEmbedding
+		require.NoError(t, db.First(&stored, "note_id = ?", note.ID).Error)
+		assert.Equal(t, embed.Default.Name(), stored.Model)
+		assert.InDeltaSlice(t, embedding.Embedding, stored.Embedding, 1e-6)
+	})
+
+	t.Run("Leaves out encrypted content", func(t *testing.T) {
+		plain := Note{Title: "Keys", Content: "ciphertext"}
+		encrypted := Note{Title: "Keys", Content: "ciphertext", Encrypted: true}
+		titleOnly := Note{Title: "Keys"}
+
+		assert.NotEqual(t, NewNoteEmbedding(&titleOnly, embed.Default).Embedding, NewNoteEmbedding(&plain, embed.Default).Embedding)
+		assert.Equal(t, NewNoteEmbedding(&titleOnly, embed.Default).Embedding, NewNoteEmbedding(&encrypted, embed.Default).Embedding)
+	})
+
+	t.Run("Vector literals", func(t *testing.T) {
+		v := Vector{0.5, -1, 0.25}
+		assert.Equal(t, "[0.5,-1,0.25]", v.Literal())
+
+		var scanned Vector
+		require.NoError(t, scanned.Scan("{0.5,-1,0.25}"))
+		assert.Equal(t, v, scanned)
+		assert.Error(t, scanned.Scan("{a,b}"))
+	})
+}
+
+func TestSyncChange(t *testing.T) {
+	db := setupTestDB(t)
+
+	t.Run("Notes in the bin are deleted", func(t *testing.T) {
+		note := Note{UserID: uuid.New(), Title: "Staging database", Content: "rotate monthly"}
+		require.NoError(t, db.Create(&note).Error)
+
+		change := NewNoteChange(&note, 7)
+		assert.Equal(t, NoteKind, change.Kind)
+		assert.Equal(t, int64(7), change.Version)
+		require.NotNil(t, change.Note)
+		assert.Equal(t, "Staging database", change.Note.Title)
+
+		require.NoError(t, db.Delete(&note).Error)
+		var deleted Note
+		require.NoError(t, db.Unscoped().First(&deleted, "id = ?", note.ID).Error)
+
+		change = NewNoteChange(&deleted, 8)
+		assert.True(t, change.Deleted)
+		assert.Nil(t, change.Note)
+		assert.Equal(t, note.ID, change.ID)
+	})
+
+	t.Run("Children point at their note", func(t *testing.T) {
+		noteID := uuid.New()
+		attachment := Attachment{Model: Model{ID: uuid.New()}, NoteID: noteID, FileName: "keys.pdf", Version: 3}
+
+		change := NewAttachmentChange(&attachment)
+		assert.Equal(t, AttachmentKind, change.Kind)
+		assert.Equal(t, int64(3), change.Version)
+		require.NotNil(t, change.NoteID)
+		assert.Equal(t, noteID, *change.NoteID)
+		assert.Equal(t, "keys.pdf", change.Attachment.Filename)
+	})
+
+	t.Run("Tombstones", func(t *testing.T) {
+		tombstone := Tombstone{Version: 9, Kind: ShareKind, EntityID: uuid.New()}
+
+		change := NewTombstoneChange(&tombstone)
+		assert.Equal(t, SyncChange{Kind: ShareKind, ID: tombstone.EntityID, Version: 9, Deleted: true}, change)
+	})
+
+	t.Run("Ops", func(t *testing.T) {
+		op, err := NewSyncOp("delete")
+		assert.NoError(t, err)
+		assert.Equal(t, DeleteOp, op)
+
+		_, err = NewSyncOp("move")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type PasswordHealthItem struct {
+	NoteID      uuid.UUID   `json:"note_id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
+	Title       string      `json:"title" example:"Email"`
+	Issues      []string    `json:"issues" example:"breached,reused" binding:"required"`
+	BreachCount int         `json:"breach_count,omitempty" example:"1024"`
+	Strength    int         `json:"strength" example:"1"` // 0 (very weak) to 4 (very strong)
+	ReusedWith  []uuid.UUID `json:"reused_with,omitempty"`
+} // @name PasswordHealthItem
+
+type PasswordHealthReport struct {
+	Checked     int                  `json:"checked" example:"12" binding:"required"`
+	BreachCheck bool                 `json:"breach_check" example:"true" binding:"required"`
+	Breached    int                  `json:"breached" example:"1"`
+	Reused      int                  `json:"reused" example:"2"`
+	Weak        int                  `json:"weak" example:"3"`
+	Items       []PasswordHealthItem `json:"items" binding:"required"`
+} // @name PasswordHealthReport
@@ -2,20 +2,68 @@ package models
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type PresignUploadRequest struct {
 	Filename    string `json:"filename" binding:"required" example:"example.txt"`
 	ContentType string `json:"content_type" binding:"required" example:"text/plain"`
+	Size        int64  `json:"size" binding:"required" example:"1024"` // in bytes, the upload has to be exactly this size
 } // @name PresignUploadRequest
 
+// PresignUploadResponse is a form to upload a file with: POST the fields, then the file as "file",
+// as multipart/form-data to the URL.
 type PresignUploadResponse struct {
-	URL string `json:"url" example:"https://s3.com/upload?key=example.txt" binding:"required"`
-	Key string `json:"key" example:"attachments/123e4567-e89b-12d3-a456-426614174000/example.txt" binding:"required"`
+	URL    string            `json:"url" example:"https://bucket.s3.amazonaws.com/" binding:"required"`
+	Fields map[string]string `json:"fields" binding:"required"`
+	Key    string            `json:"key" example:"attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d" binding:"required"`
+	ID     *uuid.UUID        `json:"id,omitempty" example:"0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"` // of the attachment being uploaded
 } // @name PresignUploadResponse
 
+// MultipartUploadResponse is an attachment being uploaded in parts: the file is split into parts of PartSize,
+// but for the last, each PUT to a URL from presignParts. Parts can be uploaded in parallel and again after failures.
+type MultipartUploadResponse struct {
+	ID       uuid.UUID `json:"id" binding:"required" example:"0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"` // of the attachment being uploaded
+	Key      string    `json:"key" binding:"required" example:"attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d"`
+	PartSize int64     `json:"part_size" binding:"required" example:"8388608"`
+	Parts    int       `json:"parts" binding:"required" example:"13"` // numbered from 1
+} // @name MultipartUploadResponse
+
+type PresignPartsRequest struct {
+	Parts []int `json:"parts" binding:"required,min=1,max=1000,dive,min=1" example:"1,2,3"` // numbers of the parts to upload
+} // @name PresignPartsRequest
+
+// PartURL is where to PUT a part of a multipart upload.
+type PartURL struct {
+	Number int    `json:"number" binding:"required" example:"1"`
+	URL    string `json:"url" binding:"required" example:"https://bucket.s3.amazonaws.com/attachments/123e4567-e89b-12d3-a456-426614174000/0b6f1c3e-8d2a-4f7e-9c11-2f4d5a6b7c8d?partNumber=1&uploadId=abc"`
+} // @name PartURL
+
+type PresignPartsResponse struct {
+	Parts []PartURL `json:"parts" binding:"required"`
+} // @name PresignPartsResponse
+
+// UploadedPart is a part of a multipart upload that arrived, which doesn't need uploading again.
+type UploadedPart struct {
+	Number int    `json:"number" binding:"required" example:"1"`
+	Size   int64  `json:"size" binding:"required" example:"8388608"`
+	ETag   string `json:"etag" binding:"required" example:"\"b1946ac92492d2347c6235b4d2611184\""`
+} // @name UploadedPart
+
+// UploadedPartsResponse is how far a multipart upload got, and how it's split into parts to resume it.
+type UploadedPartsResponse struct {
+	PartSize int64          `json:"part_size" binding:"required" example:"8388608"`
+	Total    int            `json:"total" binding:"required" example:"13"` // number of parts in all
+	Parts    []UploadedPart `json:"parts" binding:"required"`
+} // @name UploadedPartsResponse
+
+// PresignDownloadResponse tells where an attachment's upload stands, with a URL to download it once it's ready.
 type PresignDownloadResponse struct {
-	URL string `json:"url" binding:"required" example:"https://s3.com/download?key=example.txt"`
+	URL    string       `json:"url,omitempty" example:"https://s3.com/download?key=example.txt"`
+	Status UploadStatus `json:"status" binding:"required" example:"ready"`
+	Reason string       `json:"reason,omitempty" example:"file exceeds the 10 MB limit"`                                     // why the upload was rejected
+	SHA256 string       `json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // of the content, to check the download against
 } // @name PresignDownloadResponse
 
 type ShareToUserRequest struct {
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"vault/internal/search"
+
+	"github.com/google/uuid"
+)
+
+// SearchScope selects whose notes a search looks at.
+type SearchScope string // @name SearchScope
+
+const (
+	AllScope    SearchScope = "all"    // own notes and the ones shared with the user
+	MineScope   SearchScope = "mine"   // own notes only
+	SharedScope SearchScope = "shared" // notes shared with the user only
+)
+
+func NewSearchScope(v string) (SearchScope, error) {
+	switch v {
+	case "", "all":
+		return AllScope, nil
+	case "mine":
+		return MineScope, nil
+	case "shared":
+		return SharedScope, nil
+	default:
+		return "", fmt.Errorf("invalid scope: %s", v)
+	}
+}
+
+// SearchMode selects how a search matches notes.
+type SearchMode string // @name SearchMode
+
+const (
+	KeywordMode  SearchMode = "keyword"  // full-text search on the words of the query
+	SemanticMode SearchMode = "semantic" // notes about the same things, worded differently
+)
+
+func NewSearchMode(v string) (SearchMode, error) {
+	switch SearchMode(v) {
+	case "", KeywordMode:
+		return KeywordMode, nil
+	case SemanticMode:
+		return SemanticMode, nil
+	default:
+		return "", fmt.Errorf("invalid mode: %s", v)
+	}
+}
+
+// SearchSort orders search results.
+type SearchSort string // @name SearchSort
+
+const (
+	RelevanceSort SearchSort = "relevance" // best matches first
+	NewestSort    SearchSort = "newest"
+	OldestSort    SearchSort = "oldest"
+	UpdatedSort   SearchSort = "updated" // recently updated first
+	TitleSort     SearchSort = "title"
+)
+
+func NewSearchSort(v string) (SearchSort, error) {
+	switch SearchSort(v) {
+	case "", RelevanceSort:
+		return RelevanceSort, nil
+	case NewestSort, OldestSort, UpdatedSort, TitleSort:
+		return SearchSort(v), nil
+	default:
+		return "", fmt.Errorf("invalid sort: %s", v)
+	}
+}
+
+// NoteSearchHit is a note matched by a full-text query, with its rank and raw ts_headline output.
+type NoteSearchHit struct {
+	Note
+	Rank            float64 `gorm:"column:rank"`
+	Permission      string  `gorm:"column:permission"`
+	TitleHeadline   string  `gorm:"column:title_headline"`
+	ContentHeadline string  `gorm:"column:content_headline"`
+}
+
+func (NoteSearchHit) TableName() string {
+	return "notes"
+}
+
+type SearchSnippet struct {
+	Title   string `json:"title" example:"<mark>Staging</mark> database"`
+	Content string `json:"content" example:"credentials for the <mark>staging</mark> cluster … rotated monthly"`
+} // @name SearchSnippet
+
+type SearchResult struct {
+	Note       NoteOut       `json:"note" binding:"required"`
+	Rank       float64       `json:"rank" example:"0.42" binding:"required"`
+	Permission Permission    `json:"permission" example:"owner" binding:"required"` // "owner", "read" or "write"
+	Snippet    SearchSnippet `json:"snippet" binding:"required"`                    // HTML-escaped, matches wrapped in <mark>
+} // @name SearchResult
+
+type SearchResponse struct {
+	Results    []SearchResult `json:"results" binding:"required"`
+	Total      int            `json:"total" example:"10" binding:"required"`
+	Fuzzy      bool           `json:"fuzzy" example:"false"`                           // results are similar, not exact matches
+	Suggestion string         `json:"suggestion,omitempty" example:"staging database"` // did you mean
+} // @name SearchResponse
+
+func NewSearchResult(hit *NoteSearchHit) SearchResult {
+	return SearchResult{
+		Note:       NewNoteOut(&hit.Note),
+		Rank:       hit.Rank,
+		Permission: Permission(hit.Permission),
+		Snippet: SearchSnippet{
+			Title:   search.Highlight(hit.TitleHeadline),
+			Content: search.Highlight(hit.ContentHeadline),
+		},
+	}
+}
+
+const (
+	MaxSavedSearches    = 50
+	MaxSavedSearchName  = 64
+	MaxSavedSearchQuery = 512
+)
+
+// SavedSearch is a search a user runs often, shown as a smart collection in the sidebar.
+type SavedSearch struct {
+	ModifiableModel
+	UserID uuid.UUID   `json:"-" gorm:"type:uuid;not null;index"`
+	Name   string      `json:"name" gorm:"not null"`
+	Query  string      `json:"query" gorm:"not null"`
+	Scope  SearchScope `json:"scope" gorm:"type:varchar(16);default:all;not null"`
+	Sort   SearchSort  `json:"sort" gorm:"type:varchar(16);default:relevance;not null"`
+	Pinned bool        `json:"pinned" gorm:"not null;default:false"`
+}
+
+type SavedSearchIn struct {
+	Name   string `json:"name" binding:"required" example:"Infra keys"`
+	Query  string `json:"query" binding:"required" example:"tag:infra type:login -is:archived"`
+	Scope  string `json:"scope,omitempty" example:"all"`      // "all", "mine" or "shared"
+	Sort   string `json:"sort,omitempty" example:"relevance"` // "relevance", "newest", "oldest", "updated" or "title"
+	Pinned bool   `json:"pinned" example:"true"`
+} // @name SavedSearchIn
+
+type SavedSearchOut struct {
+	ID        uuid.UUID   `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
+	Name      string      `json:"name" example:"Infra keys" binding:"required"`
+	Query     string      `json:"query" example:"tag:infra type:login -is:archived" binding:"required"`
+	Scope     SearchScope `json:"scope" example:"all" binding:"required"`
+	Sort      SearchSort  `json:"sort" example:"relevance" binding:"required"`
+	Pinned    bool        `json:"pinned" example:"true" binding:"required"`
+	Count     *int        `json:"count,omitempty" example:"12"` // number of matching notes, when asked for
+	CreatedAt time.Time   `json:"created_at" binding:"required"`
+	UpdatedAt time.Time   `json:"updated_at"`
+} // @name SavedSearchOut
+
+type SavedSearchesResponse struct {
+	Searches []SavedSearchOut `json:"searches" binding:"required"`
+} // @name SavedSearchesResponse
+
+func NewSavedSearch(in *SavedSearchIn, userID uuid.UUID, scope SearchScope, sort SearchSort) SavedSearch {
+	return SavedSearch{
+		UserID: userID,
+		Name:   strings.TrimSpace(in.Name),
+		Query:  strings.TrimSpace(in.Query),
+		Scope:  scope,
+		Sort:   sort,
+		Pinned: in.Pinned,
+	}
+}
+
+func NewSavedSearchOut(s *SavedSearch) SavedSearchOut {
+	return SavedSearchOut{
+		ID:        s.ID,
+		Name:      s.Name,
+		Query:     s.Query,
+		Scope:     s.Scope,
+		Sort:      s.Sort,
+		Pinned:    s.Pinned,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyncKind is what a sync change is about.
+type SyncKind string // @name SyncKind
+
+const (
+	NoteKind       SyncKind = "note"
+	AttachmentKind SyncKind = "attachment"
+	ShareKind      SyncKind = "share"
+	TagKind        SyncKind = "tag"
+)
+
+// Tombstone records something deleted for good, or a share revoked, for one user to sync.
+// Rows are written by triggers, see the sync migration.
+type Tombstone struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	Kind      SyncKind  `gorm:"not null"`
+	EntityID  uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+}
+
+func (Tombstone) TableName() string {
+	return "sync_tombstones"
+}
+
+// SyncChange is an entity created, updated or deleted since the client last synced.
+// Deleted ones only carry their kind, ID and version, so do notes whose share was revoked or expired.
+type SyncChange struct {
+	Kind       SyncKind       `json:"kind" binding:"required" example:"note"`
+	ID         uuid.UUID      `json:"id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Version    int64          `json:"version" binding:"required" example:"42"`
+	Deleted    bool           `json:"deleted,omitempty"`
+	NoteID     *uuid.UUID     `json:"note_id,omitempty"` // the note of an attachment or share
+	Note       *NoteOut       `json:"note,omitempty"`
+	Attachment *AttachmentOut `json:"attachment,omitempty"`
+	Share      *NoteShareOut  `json:"share,omitempty"`
+	Tag        string         `json:"tag,omitempty" example:"infra"`
+} // @name SyncChange
+
+func NewNoteChange(n *Note, version int64) SyncChange {
+	if n.DeletedAt.Valid {
+		return SyncChange{Kind: NoteKind, ID: n.ID, Version: version, Deleted: true}
+	}
+	out := NewNoteOut(n)
+	return SyncChange{Kind: NoteKind, ID: n.ID, Version: version, Note: &out}
+}
+
+func NewAttachmentChange(a *Attachment) SyncChange {
+	out := NewAttachmentOut(a)
+	return SyncChange{Kind: AttachmentKind, ID: a.ID, Version: a.Version, NoteID: &a.NoteID, Attachment: &out}
+}
+
+func NewShareChange(s *NoteShare) SyncChange {
+	out := NewNoteShareOut(s)
+	return SyncChange{Kind: ShareKind, ID: s.ID, Version: s.Version, NoteID: &s.NoteID, Share: &out}
+}
+
+func NewTagChange(t *Tag) SyncChange {
+	return SyncChange{Kind: TagKind, ID: t.ID, Version: t.Version, Tag: t.Name}
+}
+
+func NewTombstoneChange(t *Tombstone) SyncChange {
+	return SyncChange{Kind: t.Kind, ID: t.EntityID, Version: t.Version, Deleted: true}
+}
+
+// SyncOp is what an offline edit does to a note.
+type SyncOp string // @name SyncOp
+
+const (
+	UpsertOp SyncOp = "upsert"
+	DeleteOp SyncOp = "delete" // moves the note to the bin
+)
+
+func NewSyncOp(v string) (SyncOp, error) {
+	switch SyncOp(v) {
+	case UpsertOp:
+		return UpsertOp, nil
+	case DeleteOp:
+		return DeleteOp, nil
+	default:
+		return "", fmt.Errorf("invalid op: %s", v)
+	}
+}
+
+// SyncUpload is a note edited offline. BaseVersion is the version the client last synced,
+// 0 for notes it created, whose ID it picks.
+type SyncUpload struct {
+	Op          string    `json:"op" binding:"required" example:"upsert"` // "upsert" or "delete"
+	ID          uuid.UUID `json:"id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	BaseVersion int64     `json:"base_version" example:"42"`
+	Note        *NoteIn   `json:"note,omitempty"` // required for upserts
+} // @name SyncUpload
+
+type SyncRequest struct {
+	Token   string       `json:"token" example:"djI6NDI6MTc2MDgzMjAwMDAwMDAwMDo3ODA6NzgwOg"` // of the last sync, empty for a full one
+	Changes []SyncUpload `json:"changes"`
+} // @name SyncRequest
+
+// SyncStatus tells how an offline edit went.
+type SyncStatus string // @name SyncStatus
+
+const (
+	AppliedSync  SyncStatus = "applied"
+	ConflictSync SyncStatus = "conflict" // the note changed since the base version
+	RejectedSync SyncStatus = "rejected" // the edit is invalid, see the error
+)
+
+type SyncResult struct {
+	ID      uuid.UUID  `json:"id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Status  SyncStatus `json:"status" binding:"required" example:"applied"`
+	Version int64      `json:"version,omitempty" example:"43"` // of the note once the edit is applied
+	Error   string     `json:"error,omitempty"`
+	Current *NoteOut   `json:"current,omitempty"` // the note as it is on a conflict, absent when it was deleted
+} // @name SyncResult
+
+type SyncResponse struct {
+	Changes []SyncChange `json:"changes" binding:"required"`
+	Token   string       `json:"token" binding:"required" example:"djI6NDI6MTc2MDgzMjAwMDAwMDAwMDo3ODA6NzgwOg"`
+	More    bool         `json:"more"` // more changes are waiting, sync again with the token
+	Results []SyncResult `json:"results,omitempty"`
+} // @name SyncResponse
+
+// SyncedNote is a note along with the version it syncs at, which for a shared note is
+// the later of the note's and the share's, so notes shared after the last sync come along.
+type SyncedNote struct {
+	Note
+	SyncVersion int64 `gorm:"column:sync_version"`
+}
+
+func (SyncedNote) TableName() string {
+	return "notes"
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 32
+)
+
+// Tag labels notes. Names are unique per user and shared by all of their notes.
+type Tag struct {
+	Model
+	UserID  uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_tags_user_name"`
+	Name    string    `json:"name" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Version int64     `json:"-" gorm:"not null;default:0"`
+}
+
+// NewTagNames normalizes tag names: trimmed, lowercased and deduplicated.
+// A name may hold letters, digits and -_./ so it can be typed as tag:name into search.
+func NewTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(name) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", name, MaxTagLength)
+		}
+		if strings.IndexFunc(name, invalidTagRune) >= 0 {
+			return nil, fmt.Errorf("tag %q may only contain letters, digits and -_./", name)
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+
+	if len(out) > MaxTags {
+		return nil, fmt.Errorf("a note can have at most %d tags", MaxTags)
+	}
+
+	sort.Strings(out)
+	return out, nil
+}
+
+func invalidTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_./", r)
+}
+
+func NewTagNamesOut(tags []Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// UsageOut is how much storage a user's attachments take, against their plan's quota.
+type UsageOut struct {
+	Plan        Plan        `json:"plan" binding:"required" example:"free"`
+	Quota       int64       `json:"quota" example:"104857600"`    // bytes
+	Used        int64       `json:"used" example:"5242880"`       // bytes of ready attachments
+	Pending     int64       `json:"pending" example:"1048576"`    // bytes of uploads under way, reserved against the quota
+	Available   int64       `json:"available" example:"98566144"` // bytes still free to upload
+	Attachments int         `json:"attachments" example:"12"`     // ready ones
+	ByType      []TypeUsage `json:"by_type" binding:"required"`   // largest first
+	ByNote      []NoteUsage `json:"by_note" binding:"required"`   // largest first, at most limit notes
+} // @name UsageOut
+
+// TypeUsage is the storage taken by attachments of a MIME type.
+type TypeUsage struct {
+	MimeType    string `json:"mime_type" example:"application/pdf"`
+	Attachments int    `json:"attachments" example:"3"`
+	Size        int64  `json:"size" example:"3145728"`
+} // @name TypeUsage
+
+// NoteUsage is the storage taken by the attachments of a note, including notes in the bin.
+type NoteUsage struct {
+	NoteID      uuid.UUID `json:"note_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Title       string    `json:"title" example:"Meeting Notes"`
+	Deleted     bool      `json:"deleted"`
+	Attachments int       `json:"attachments" example:"2"`
+	Size        int64     `json:"size" example:"2097152"`
+} // @name NoteUsage
+
+func NewUsageOut(user *User, quota int64, pending int64, byType []TypeUsage, byNote []NoteUsage) UsageOut {
+	return UsageOut{
+		Plan:        user.Plan,
+		Quota:       quota,
+		Used:        user.StorageUsed,
+		Pending:     pending,
+		Available:   max(quota-user.StorageUsed-pending, 0),
+		Attachments: user.AttachmentsCount,
+		ByType:      byType,
+		ByNote:      byNote,
+	}
+}
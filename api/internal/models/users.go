@@ -12,9 +12,19 @@ type User struct {
 	NotesCount        int    `gorm:"type:integer;default:0;not null"`
 	DeletedNotesCount int    `gorm:"type:integer;default:0;not null"`
 	AttachmentsCount  int    `gorm:"type:integer;default:0;not null"`
+	StorageUsed       int64  `gorm:"default:0;not null"` // bytes of ready attachments
+	Plan              Plan   `gorm:"default:'free';not null"`
 	AvatarUrl         string `gorm:"type:varchar(255);"`
 }
 
+// Plan is what a user's storage quota comes from.
+type Plan string // @name Plan
+
+const (
+	FreePlan Plan = "free"
+	ProPlan  Plan = "pro"
+)
+
 func (u User) String() string {
 	return fmt.Sprintf("%s, #%d", u.Username, u.ID)
 }
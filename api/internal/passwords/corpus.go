@@ -0,0 +1,99 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength is the number of leading hex characters of a SHA-1 hash
+// that select a range file, same as the k-anonymity range API of Have I Been Pwned.
+const PrefixLength = 5
+
+// Corpus is a directory of breached password hash ranges.
+// Each file is named after an upper-case 5 character SHA-1 prefix
+// and contains "SUFFIX:COUNT" lines, one per breached hash.
+type Corpus struct {
+	dir string
+}
+
+// Breaches is the corpus loaded at startup, nil when no corpus is configured.
+var Breaches *Corpus
+
+// Init loads the breach corpus from a directory. An empty directory name disables breach checks.
+func Init(dir string) error {
+	if dir == "" {
+		log.Println("Breach corpus not configured, skipping breach checks")
+		return nil
+	}
+
+	corpus, err := Open(dir)
+	if err != nil {
+		return err
+	}
+
+	Breaches = corpus
+	return nil
+}
+
+// Open returns a corpus backed by a directory of range files.
+func Open(dir string) (*Corpus, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breach corpus %s is not a directory", dir)
+	}
+	return &Corpus{dir: dir}, nil
+}
+
+// Lookup returns how many times a password has been seen in breaches.
+// Zero means the password is not in the corpus.
+func (c *Corpus) Lookup(password string) (int, error) {
+	prefix, suffix := split(Hash(password))
+	return c.lookup(prefix, suffix)
+}
+
+func (c *Corpus) lookup(prefix string, suffix string) (int, error) {
+	file, err := os.Open(filepath.Join(c.dir, prefix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open range %s: %w", prefix, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(hash, suffix) {
+			continue
+		}
+		// Import writes valid counts only, a range edited by hand may not have one
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			continue
+		}
+		return n, nil
+	}
+
+	return 0, scanner.Err()
+}
+
+// Hash returns the upper-case hex SHA-1 of a password.
+func Hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func split(hash string) (string, string) {
+	return hash[:PrefixLength], hash[PrefixLength:]
+}
@@ -0,0 +1,102 @@
+package passwords
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportStats summarizes an import run.
+type ImportStats struct {
+	Hashes  int
+	Ranges  int
+	Skipped int
+}
+
+// Import reads "SHA1:COUNT" lines, e.g. the ordered-by-hash dump of Have I Been Pwned,
+// and writes them into range files under dir.
+// Sorted input is written in a single pass; unsorted input works too, just slower.
+func Import(r io.Reader, dir string) (ImportStats, error) {
+	var stats ImportStats
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return stats, fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+
+	var (
+		current string
+		file    *os.File
+		writer  *bufio.Writer
+		seen    = make(map[string]bool)
+	)
+
+	closeRange := func() error {
+		if file == nil {
+			return nil
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		return file.Close()
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		hash, field, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || len(hash) != 40 || !isHex(hash) {
+			stats.Skipped++
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || count < 0 {
+			stats.Skipped++
+			continue
+		}
+
+		prefix, suffix := split(strings.ToUpper(hash))
+
+		if prefix != current {
+			if err := closeRange(); err != nil {
+				return stats, err
+			}
+
+			// truncate a range the first time this run sees it, append afterwards
+			flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			if !seen[prefix] {
+				flags |= os.O_TRUNC
+				seen[prefix] = true
+				stats.Ranges++
+			}
+
+			f, err := os.OpenFile(filepath.Join(dir, prefix), flags, 0o644)
+			if err != nil {
+				return stats, fmt.Errorf("failed to open range %s: %w", prefix, err)
+			}
+			file, writer, current = f, bufio.NewWriter(f), prefix
+		}
+
+		if _, err := fmt.Fprintf(writer, "%s:%d\n", suffix, count); err != nil {
+			return stats, err
+		}
+		stats.Hashes++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+
+	return stats, closeRange()
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
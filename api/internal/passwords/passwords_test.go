@@ -0,0 +1,86 @@
+package passwords
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorpus(t *testing.T) {
+	dir := t.TempDir()
+
+	// "password" and "letmein"
+	input := strings.Join([]string{
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"b7a875fc1ea228b9061041b7cec4bd3c52ab3ce3:1",
+		"not a hash",
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B:lots",
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B:-5",
+	}, "\n")
+
+	stats, err := Import(strings.NewReader(input), dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Hashes)
+	assert.Equal(t, 2, stats.Ranges)
+	assert.Equal(t, 3, stats.Skipped)
+
+	corpus, err := Open(dir)
+	require.NoError(t, err)
+
+	count, err := corpus.Lookup("password")
+	require.NoError(t, err)
+	assert.Equal(t, 9545824, count)
+
+	count, err = corpus.Lookup("letmein")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = corpus.Lookup("correct-horse-battery-staple")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// "123456", in a range written by hand with a broken line
+	prefix, suffix := split(Hash("123456"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, prefix), []byte(suffix+":many\n"+suffix+":37359195\n"), 0o644))
+	count, err = corpus.Lookup("123456")
+	require.NoError(t, err)
+	assert.Equal(t, 37359195, count)
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, VeryWeak, Score(""))
+	assert.Equal(t, VeryWeak, Score("Password123!"))
+	assert.Equal(t, VeryWeak, Score("aaaaaaaa"))
+	assert.Equal(t, VeryStrong, Score("k9#Vq2!zLm8@Tr5$Wx"))
+}
+
+func TestCheck(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	report, err := Check(
+		[]Item{
+			{NoteID: first, Title: "Mail", Password: "k9#Vq2!zLm8@Tr5$Wx"},
+			{NoteID: second, Title: "Bank", Password: "k9#Vq2!zLm8@Tr5$Wx"},
+			{NoteID: third, Title: "Forum", Password: "qwerty"},
+			{NoteID: uuid.New(), Title: "Empty"},
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Checked)
+	assert.False(t, report.BreachCheck)
+	assert.Equal(t, 2, report.Reused)
+	assert.Equal(t, 1, report.Weak)
+	require.Len(t, report.Findings, 3)
+	assert.Equal(t, []uuid.UUID{second}, report.Findings[0].ReusedWith)
+	assert.Equal(t, []Issue{IssueWeak}, report.Findings[2].Issues)
+	for _, finding := range report.Findings {
+		assert.Empty(t, finding.Password)
+	}
+}
@@ -0,0 +1,102 @@
+package passwords
+
+import (
+	"github.com/google/uuid"
+)
+
+// Issue is a problem found with a stored password.
+type Issue string
+
+const (
+	IssueBreached Issue = "breached"
+	IssueReused   Issue = "reused"
+	IssueWeak     Issue = "weak"
+)
+
+// Item is a stored password to check, along with the note that holds it.
+type Item struct {
+	NoteID   uuid.UUID
+	Title    string
+	Password string
+}
+
+// Finding is an item with at least one issue.
+type Finding struct {
+	Item
+	Issues      []Issue
+	BreachCount int
+	Strength    Strength
+	ReusedWith  []uuid.UUID
+}
+
+// Report is the outcome of checking a set of items.
+type Report struct {
+	Checked     int
+	BreachCheck bool // whether the breach corpus was available
+	Breached    int
+	Reused      int
+	Weak        int
+	Findings    []Finding
+}
+
+// Check looks for breached, reused and weak passwords among items.
+// Breach checks are skipped when corpus is nil.
+func Check(items []Item, corpus *Corpus) (Report, error) {
+	report := Report{BreachCheck: corpus != nil}
+
+	// group notes by password hash to find reuse without comparing plaintexts pairwise
+	byHash := make(map[string][]uuid.UUID)
+	for _, item := range items {
+		if item.Password == "" {
+			continue
+		}
+		hash := Hash(item.Password)
+		byHash[hash] = append(byHash[hash], item.NoteID)
+	}
+
+	for _, item := range items {
+		if item.Password == "" {
+			continue
+		}
+		report.Checked++
+
+		finding := Finding{
+			Item:     item,
+			Strength: Score(item.Password),
+		}
+
+		if corpus != nil {
+			count, err := corpus.Lookup(item.Password)
+			if err != nil {
+				return report, err
+			}
+			if count > 0 {
+				finding.BreachCount = count
+				finding.Issues = append(finding.Issues, IssueBreached)
+				report.Breached++
+			}
+		}
+
+		for _, id := range byHash[Hash(item.Password)] {
+			if id != item.NoteID {
+				finding.ReusedWith = append(finding.ReusedWith, id)
+			}
+		}
+		if len(finding.ReusedWith) > 0 {
+			finding.Issues = append(finding.Issues, IssueReused)
+			report.Reused++
+		}
+
+		if finding.Strength < Fair {
+			finding.Issues = append(finding.Issues, IssueWeak)
+			report.Weak++
+		}
+
+		if len(finding.Issues) > 0 {
+			finding.Password = "" // never echo the secret back
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,123 @@
+package passwords
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength is a coarse 0-4 score of how hard a password is to guess.
+type Strength int
+
+const (
+	VeryWeak Strength = iota
+	Weak
+	Fair
+	Strong
+	VeryStrong
+)
+
+// common passwords and keyboard walks that make any password trivially guessable
+var common = []string{
+	"password", "passw0rd", "qwerty", "qwertyuiop", "asdfgh", "zxcvbn", "letmein",
+	"welcome", "admin", "login", "iloveyou", "monkey", "dragon", "master", "sunshine",
+	"princess", "football", "baseball", "shadow", "superman", "trustno1", "secret",
+	"abc123", "123456", "12345678", "123456789", "111111", "000000",
+}
+
+// Entropy estimates the entropy of a password in bits from the character classes it uses,
+// discounting repeated characters, sequences and common passwords.
+func Entropy(password string) float64 {
+	if password == "" {
+		return 0
+	}
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r > unicode.MaxASCII:
+			other = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{lower, 26},
+		{upper, 26},
+		{digit, 10},
+		{symbol, 33},
+		{other, 100},
+	} {
+		if class.used {
+			pool += class.size
+		}
+	}
+
+	bits := float64(effectiveLength(password)) * math.Log2(float64(pool))
+
+	if isCommon(password) {
+		bits = math.Min(bits, 10)
+	}
+
+	return math.Round(bits*100) / 100
+}
+
+// Score maps the entropy estimate of a password onto a Strength.
+func Score(password string) Strength {
+	bits := Entropy(password)
+	switch {
+	case bits < 28:
+		return VeryWeak
+	case bits < 36:
+		return Weak
+	case bits < 60:
+		return Fair
+	case bits < 80:
+		return Strong
+	default:
+		return VeryStrong
+	}
+}
+
+// effectiveLength counts characters that continue a repeat or a sequence
+// (as in "aaaa" or "1234") as half a character.
+func effectiveLength(password string) int {
+	runes := []rune(password)
+	penalty := 0
+
+	for i := 1; i < len(runes); i++ {
+		diff := runes[i] - runes[i-1]
+		if diff >= -1 && diff <= 1 {
+			penalty++
+		}
+	}
+
+	return len(runes) - penalty/2
+}
+
+// isCommon reports whether a password is a common one, ignoring case
+// and any digits or symbols tacked on at the end.
+func isCommon(password string) bool {
+	lowered := strings.ToLower(password)
+	stripped := strings.TrimRightFunc(lowered, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, c := range common {
+		if lowered == c || stripped == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// chunkSize is how much of a file is sent to clamd at a time.
+const chunkSize = 64 * 1024
+
+// Clamd scans with ClamAV's daemon, streaming files to it over its socket with the INSTREAM command.
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd connects to clamd at address, unix:///path/to/clamd.ctl or tcp://host:port,
+// giving up on a scan after timeout.
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok || addr == "" || (network != "unix" && network != "tcp") {
+		return nil, fmt.Errorf("invalid clamd address %q, expected unix:///path or tcp://host:port", address)
+	}
+	return &Clamd{network: network, address: addr, timeout: timeout}, nil
+}
+
+func (c *Clamd) Scan(ctx context.Context, file io.Reader) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	// clamd stops reading when the stream is over its limit and says so, which the reply tells
+	if err := stream(conn, file); err != nil && !errors.Is(err, syscall.ECONNRESET) && !errors.Is(err, syscall.EPIPE) {
+		return "", fmt.Errorf("failed to send the file to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return "", fmt.Errorf("failed to read clamd's reply: %w", err)
+	}
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// stream sends a file as INSTREAM chunks, each prefixed with its length, ending with an empty one.
+func stream(conn net.Conn, file io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(file, chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			if _, err := conn.Write(chunk[:4+n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the file: %w", err)
+		}
+	}
+
+	_, err := conn.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseReply reads clamd's verdict on a stream: "stream: OK", "stream: {threat} FOUND", or an error.
+func parseReply(reply string) (string, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return "", nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return strings.TrimSuffix(verdict, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", reply)
+	}
+}
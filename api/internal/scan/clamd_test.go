@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd answers INSTREAM like clamd, finding the EICAR test string, and refusing streams over limit bytes.
+func fakeClamd(t *testing.T, limit int) string {
+	socket := filepath.Join(t.TempDir(), "clamd.ctl")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				command, err := reader.ReadString(0)
+				if err != nil || command != "zINSTREAM\x00" {
+					_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, reader, int64(size)); err != nil {
+						return
+					}
+					if data.Len() > limit {
+						_, _ = conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+						return
+					}
+				}
+
+				if strings.Contains(data.String(), eicar) {
+					_, _ = conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				_, _ = conn.Write([]byte("stream: OK\x00"))
+			}()
+		}
+	}()
+	return "unix://" + socket
+}
+
+func TestClamd(t *testing.T) {
+	ctx := context.Background()
+	clamd, err := NewClamd(fakeClamd(t, 1024*1024), time.Second)
+	require.NoError(t, err)
+
+	t.Run("Clean", func(t *testing.T) {
+		threat, err := clamd.Scan(ctx, strings.NewReader(strings.Repeat("staging keys\n", 10000)))
+		require.NoError(t, err)
+		assert.Empty(t, threat)
+	})
+
+	t.Run("Infected", func(t *testing.T) {
+		threat, err := clamd.Scan(ctx, strings.NewReader(eicar))
+		require.NoError(t, err)
+		assert.Equal(t, "Eicar-Test-Signature", threat)
+	})
+
+	t.Run("Over the limit", func(t *testing.T) {
+		_, err := clamd.Scan(ctx, bytes.NewReader(make([]byte, 2*1024*1024)))
+		assert.ErrorContains(t, err, "size limit exceeded")
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		unreachable, err := NewClamd("unix://"+filepath.Join(t.TempDir(), "none.ctl"), time.Second)
+		require.NoError(t, err)
+		_, err = unreachable.Scan(ctx, strings.NewReader("keys"))
+		assert.ErrorContains(t, err, "failed to reach clamd")
+	})
+
+	t.Run("Addresses", func(t *testing.T) {
+		for _, address := range []string{"", "localhost:3310", "udp://localhost:3310", "tcp://"} {
+			_, err := NewClamd(address, time.Second)
+			assert.Error(t, err, address)
+		}
+	})
+}
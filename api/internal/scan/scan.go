@@ -0,0 +1,73 @@
+// Package scan checks uploaded files for malware, with ClamAV in production and not at all in development.
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+	"vault/internal/config"
+)
+
+// Scanner looks for malware in files.
+type Scanner interface {
+	// Scan reads a file through and tells the threat found in it, empty when it's clean.
+	Scan(ctx context.Context, file io.Reader) (string, error)
+}
+
+// Nop finds every file clean, for running without a scanner.
+type Nop struct{}
+
+func (Nop) Scan(context.Context, io.Reader) (string, error) {
+	return "", nil
+}
+
+// Default scans uploads, set by Init.
+var Default Scanner = Nop{}
+
+var (
+	maxSize    int64 = 25 * 1024 * 1024
+	quarantine bool
+)
+
+// Init sets the scanner up from config: clamd when it has an address, Nop otherwise.
+func Init(cfg config.ScanConfig) error {
+	switch cfg.Infected {
+	case "delete", "quarantine":
+		quarantine = cfg.Infected == "quarantine"
+	default:
+		return fmt.Errorf("unknown SCAN_INFECTED %q, expected delete or quarantine", cfg.Infected)
+	}
+	maxSize = int64(cfg.ScanMaxSize)
+
+	if cfg.ClamdAddress == "" {
+		Default = Nop{}
+		log.Println("Clamd not configured, uploads won't be scanned")
+		return nil
+	}
+	clamd, err := NewClamd(cfg.ClamdAddress, time.Duration(cfg.ScanTimeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	Default = clamd
+	return nil
+}
+
+// ErrTooLarge is returned by Scan for files larger than the configured limit, which clamd would refuse.
+var ErrTooLarge = errors.New("file is too large to be scanned for malware")
+
+// Scan scans a file of size bytes with the Default scanner. Files larger than the configured limit
+// can't be scanned and fail with ErrTooLarge, rather than passing as clean, unless there's no scanner.
+func Scan(ctx context.Context, file io.Reader, size int64) (string, error) {
+	if _, nop := Default.(Nop); !nop && size > maxSize {
+		return "", fmt.Errorf("%w, it is %d bytes and the limit is %d", ErrTooLarge, size, maxSize)
+	}
+	return Default.Scan(ctx, file)
+}
+
+// Quarantines tells whether infected files are kept under quarantine/ rather than deleted.
+func Quarantines() bool {
+	return quarantine
+}
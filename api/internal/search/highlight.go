@@ -0,0 +1,61 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ts_headline marks matches with private use characters rather than HTML,
+// so the note text around them can still be escaped afterwards.
+const (
+	startSel = "\uE000"
+	stopSel  = "\uE001"
+)
+
+// Weights passed to ts_rank_cd for D, C, B and A labelled lexemes,
+// i.e. attachment names (C) count less than content (B) and content less than titles (A).
+const Weights = "{0.1, 0.2, 0.4, 1.0}"
+
+var (
+	// TitleHeadline highlights every match in a title, which is short enough to be shown in full.
+	TitleHeadline = headlineOptions("HighlightAll=true")
+	// ContentHeadline picks up to two fragments around matches from note content.
+	ContentHeadline = headlineOptions(`MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`)
+)
+
+func headlineOptions(extra string) string {
+	return fmt.Sprintf(`StartSel="%s", StopSel="%s", %s`, startSel, stopSel, extra)
+}
+
+// Highlight turns a ts_headline result into HTML, escaping the text and wrapping matches in <mark>.
+// Markers that happen to be in the note itself can't be told apart from ours,
+// so unbalanced ones are dropped to keep the output well-formed.
+func Highlight(headline string) string {
+	var (
+		b    strings.Builder
+		open bool
+	)
+
+	for _, r := range html.EscapeString(headline) {
+		switch string(r) {
+		case startSel:
+			if !open {
+				b.WriteString("<mark>")
+				open = true
+			}
+		case stopSel:
+			if open {
+				b.WriteString("</mark>")
+				open = false
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	if open {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Operator narrows a search down by note metadata, e.g. tag:infra or before:2026-01-01.
+type Operator string
+
+const (
+	TagOperator     Operator = "tag"     // tag:infra
+	HasOperator     Operator = "has"     // has:attachment
+	MimeOperator    Operator = "mime"    // mime:application/pdf, mime:image/*
+	IsOperator      Operator = "is"      // is:archived, is:encrypted, is:shared
+	TypeOperator    Operator = "type"    // type:login
+	OwnerOperator   Operator = "owner"   // owner:me, owner:jane_doe
+	CreatedOperator Operator = "created" // created:2025-06-01, created:2025-01-01..2025-06-30, before:, after:
+	UpdatedOperator Operator = "updated" // updated:2025-06-01.., same ranges as created
+)
+
+// Values has:, is: and owner: understand.
+const (
+	HasAttachment = "attachment"
+	IsArchived    = "archived"
+	IsEncrypted   = "encrypted"
+	IsShared      = "shared"
+	OwnerMe       = "me"
+)
+
+// dateLayout is the only date format operators accept.
+const dateLayout = "2006-01-02"
+
+var operatorPattern = regexp.MustCompile(`^([A-Za-z]+):(.+)$`)
+
+// operators are the keys parseFilter understands, before: and after: being shorthands for created:.
+var operators = map[Operator]bool{
+	TagOperator: true, HasOperator: true, MimeOperator: true, IsOperator: true, TypeOperator: true,
+	OwnerOperator: true, CreatedOperator: true, UpdatedOperator: true, "before": true, "after": true,
+}
+
+// Filter is a parsed operator. Date operators become a half-open [From, To) range
+// with either end possibly zero, others keep their value.
+type Filter struct {
+	Operator Operator
+	Value    string
+	Negated  bool
+	From     time.Time
+	To       time.Time
+	raw      string
+}
+
+func (f Filter) String() string {
+	if f.Negated {
+		return "-" + f.raw
+	}
+	return f.raw
+}
+
+// OperatorError lists every operator in a query that couldn't be understood.
+type OperatorError struct {
+	Invalid []string // e.g. "is:pinned: expected archived, encrypted or shared"
+}
+
+func (e *OperatorError) Error() string {
+	return "invalid operators: " + strings.Join(e.Invalid, "; ")
+}
+
+// Details is what a client gets to show next to the search box.
+func (e *OperatorError) Details() map[string]any {
+	return map[string]any{"invalid_operators": e.Invalid}
+}
+
+func (e *OperatorError) empty() bool {
+	return len(e.Invalid) == 0
+}
+
+// isOperator tells operator tokens apart from words that happen to contain a colon,
+// like "12:30", a trailing "Re:", "Re:invoice" or a URL: only known operators count.
+func isOperator(text string) bool {
+	m := operatorPattern.FindStringSubmatch(text)
+	return m != nil && operators[Operator(strings.ToLower(m[1]))]
+}
+
+// parseFilter parses "key:value", reporting problems into errs.
+func parseFilter(text string, negated bool, errs *OperatorError) (Filter, bool) {
+	m := operatorPattern.FindStringSubmatch(text)
+	key, value := strings.ToLower(m[1]), strings.Trim(m[2], `"`)
+
+	f := Filter{Operator: Operator(key), Value: value, Negated: negated, raw: text}
+	invalid := func(expected string) (Filter, bool) {
+		errs.Invalid = append(errs.Invalid, fmt.Sprintf("%s: expected %s", text, expected))
+		return Filter{}, false
+	}
+
+	switch f.Operator {
+	case TagOperator, TypeOperator:
+		f.Value = strings.ToLower(value)
+	case OwnerOperator:
+		// usernames and emails are matched as typed
+	case HasOperator:
+		if f.Value = strings.ToLower(value); f.Value != HasAttachment && f.Value != HasAttachment+"s" {
+			return invalid(HasAttachment)
+		}
+		f.Value = HasAttachment
+	case IsOperator:
+		switch f.Value = strings.ToLower(value); f.Value {
+		case IsArchived, IsEncrypted, IsShared:
+		default:
+			return invalid(strings.Join([]string{IsArchived, IsEncrypted, IsShared}, ", "))
+		}
+	case MimeOperator:
+		if f.Value = strings.ToLower(value); !strings.Contains(f.Value, "/") {
+			return invalid("a MIME type like application/pdf or image/*")
+		}
+	case "before", "after":
+		day, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return invalid("a date like " + dateLayout)
+		}
+		if key == "before" {
+			f.To = day
+		} else {
+			f.From = day
+		}
+		f.Operator = CreatedOperator
+	case CreatedOperator, UpdatedOperator:
+		from, to, err := parseRange(value)
+		if err != nil {
+			return invalid("a date like " + dateLayout + " or a range like 2025-01-01..2025-06-30")
+		}
+		f.From, f.To = from, to
+	}
+
+	return f, true
+}
+
+// parseRange parses a day or an inclusive range of days, either end of which may be left open.
+func parseRange(value string) (time.Time, time.Time, error) {
+	start, end, isRange := strings.Cut(value, "..")
+	if !isRange {
+		end = start
+	}
+	if start == "" && end == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("empty range")
+	}
+
+	var from, to time.Time
+	if start != "" {
+		day, err := time.Parse(dateLayout, start)
+		if err != nil {
+			return from, to, err
+		}
+		from = day
+	}
+	if end != "" {
+		day, err := time.Parse(dateLayout, end)
+		if err != nil {
+			return from, to, err
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("range ends before it starts")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,316 @@
+// Package search turns what users type into a search box into PostgreSQL full-text queries.
+//
+// The syntax is the one people know from web search engines:
+//
+//	invoice 2024          both words
+//	"staging database"    an exact phrase
+//	-draft                notes without the word
+//	aws OR gcp            either word, OR binds tighter than the implicit AND
+//	pass*                 any word starting with "pass"
+//
+// Operators filter by metadata and can be negated too, see Operator for the full list:
+//
+//	tag:infra has:attachment is:shared -is:archived before:2026-01-01 type:login
+//
+// Input is never handed to to_tsquery as is: every word is stripped down to letters, digits
+// and a few joiners and then quoted, so the output is always a valid tsquery.
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxTerms caps the number of terms in a query, a search box is not a place for essays.
+const MaxTerms = 32
+
+// Term is a single word or a quoted phrase.
+type Term struct {
+	Words   []string
+	Prefix  bool // the last word matches as a prefix
+	Negated bool
+}
+
+// Query is a conjunction of full-text clauses, each a disjunction of terms, and filters.
+type Query struct {
+	Clauses [][]Term
+	Filters []Filter
+}
+
+// Parse parses a search box query. Words that are left empty after sanitizing are dropped,
+// so the result may be empty, e.g. for "!!!", which is not an error.
+// Operators that can't be understood are reported together as an *OperatorError.
+func Parse(input string) (Query, error) {
+	var (
+		q       Query
+		clause  []Term
+		pending bool // an OR is waiting for its right-hand side
+		count   int
+		errs    OperatorError
+	)
+
+	flush := func() {
+		if len(clause) > 0 {
+			q.Clauses = append(q.Clauses, clause)
+		}
+		clause = nil
+	}
+
+	for _, tok := range tokenize(input) {
+		if tok.or {
+			pending = len(clause) > 0
+			continue
+		}
+
+		if !tok.phrase && isOperator(tok.text) {
+			if f, ok := parseFilter(tok.text, tok.negated, &errs); ok {
+				q.Filters = append(q.Filters, f)
+			}
+			// operators are always ANDed
+			pending = false
+			continue
+		}
+
+		term, ok := tok.term()
+		if !ok {
+			continue
+		}
+
+		if count++; count > MaxTerms {
+			return Query{}, fmt.Errorf("query has more than %d terms", MaxTerms)
+		}
+
+		if !pending {
+			flush()
+		}
+		clause = append(clause, term)
+		pending = false
+	}
+	flush()
+
+	if !errs.empty() {
+		return Query{}, &errs
+	}
+	return q, nil
+}
+
+// Empty reports whether the query has neither text nor filters.
+func (q Query) Empty() bool {
+	return len(q.Clauses) == 0 && len(q.Filters) == 0
+}
+
+// HasText reports whether the query has a full-text part.
+func (q Query) HasText() bool {
+	return len(q.Clauses) > 0
+}
+
+// TSQuery renders the query in to_tsquery syntax, to be passed as a bound parameter.
+func (q Query) TSQuery() string {
+	clauses := make([]string, len(q.Clauses))
+	for i, clause := range q.Clauses {
+		terms := make([]string, len(clause))
+		for j, t := range clause {
+			terms[j] = t.tsquery()
+		}
+		if len(terms) == 1 {
+			clauses[i] = terms[0]
+		} else {
+			clauses[i] = "(" + strings.Join(terms, " | ") + ")"
+		}
+	}
+	return strings.Join(clauses, " & ")
+}
+
+// Words returns every word the query looks for, leaving out excluded ones.
+func (q Query) Words() []string {
+	var words []string
+	for _, clause := range q.Clauses {
+		for _, t := range clause {
+			if !t.Negated {
+				words = append(words, t.Words...)
+			}
+		}
+	}
+	return words
+}
+
+// String renders the query back in search box syntax.
+func (q Query) String() string {
+	clauses := make([]string, len(q.Clauses))
+	for i, clause := range q.Clauses {
+		terms := make([]string, len(clause))
+		for j, t := range clause {
+			terms[j] = t.String()
+		}
+		clauses[i] = strings.Join(terms, " OR ")
+	}
+	for _, f := range q.Filters {
+		clauses = append(clauses, f.String())
+	}
+	return strings.Join(clauses, " ")
+}
+
+// Correct returns a copy of the query with words replaced as per corrections.
+func (q Query) Correct(corrections map[string]string) Query {
+	out := Query{Clauses: make([][]Term, len(q.Clauses)), Filters: q.Filters}
+	for i, clause := range q.Clauses {
+		out.Clauses[i] = make([]Term, len(clause))
+		for j, t := range clause {
+			words := make([]string, len(t.Words))
+			for k, w := range t.Words {
+				if c, ok := corrections[w]; ok {
+					w = c
+				}
+				words[k] = w
+			}
+			t.Words = words
+			out.Clauses[i][j] = t
+		}
+	}
+	return out
+}
+
+func (t Term) String() string {
+	s := strings.Join(t.Words, " ")
+	if len(t.Words) > 1 {
+		s = `"` + s + `"`
+	} else if t.Prefix {
+		s += "*"
+	}
+	if t.Negated {
+		s = "-" + s
+	}
+	return s
+}
+
+func (t Term) tsquery() string {
+	quoted := make([]string, len(t.Words))
+	for i, w := range t.Words {
+		quoted[i] = "'" + w + "'"
+	}
+	if t.Prefix {
+		quoted[len(quoted)-1] += ":*"
+	}
+
+	s := strings.Join(quoted, " <-> ")
+	if len(quoted) > 1 {
+		s = "(" + s + ")"
+	}
+	if t.Negated {
+		s = "!" + s
+	}
+	return s
+}
+
+type token struct {
+	text    string
+	phrase  bool
+	negated bool
+	or      bool
+}
+
+func (tok token) term() (Term, bool) {
+	t := Term{Negated: tok.negated}
+
+	text := tok.text
+	if !tok.phrase && strings.HasSuffix(text, "*") {
+		t.Prefix = true
+		text = strings.TrimRight(text, "*")
+	}
+
+	t.Words = sanitize(text)
+	if len(t.Words) == 0 {
+		return Term{}, false
+	}
+	return t, true
+}
+
+func tokenize(input string) []token {
+	var (
+		tokens []token
+		runes  = []rune(input)
+	)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var tok token
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			tok.negated = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			// an unterminated phrase runs to the end of the input
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			tok.text, tok.phrase = string(runes[i+1:end]), true
+			tokens = append(tokens, tok)
+			i = end + 1
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+		// an operator with a quoted value, e.g. owner:"jane doe"
+		if end > i && end < len(runes) && runes[end] == '"' && runes[end-1] == ':' {
+			if closing := indexRune(runes[end+1:], '"'); closing >= 0 {
+				end += closing + 2
+			} else {
+				end = len(runes)
+			}
+		}
+		tok.text = string(runes[i:end])
+		i = end
+
+		if !tok.negated && (tok.text == "OR" || tok.text == "|") {
+			tok.or = true
+		}
+		tokens = append(tokens, tok)
+	}
+
+	return tokens
+}
+
+func indexRune(runes []rune, r rune) int {
+	for i, c := range runes {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// sanitize splits text into words of letters, digits and the characters the text search
+// parser treats as part of words, such as in e-mail addresses or file names.
+func sanitize(text string) []string {
+	text = strings.Map(
+		func(r rune) rune {
+			switch {
+			case unicode.IsLetter(r), unicode.IsDigit(r):
+				return unicode.ToLower(r)
+			case r == '.', r == '-', r == '_', r == '@':
+				return r
+			default:
+				return ' '
+			}
+		},
+		text,
+	)
+
+	var words []string
+	for _, w := range strings.Fields(text) {
+		if w = strings.Trim(w, ".-_@"); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
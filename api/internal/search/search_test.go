@@ -0,0 +1,199 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Words", "invoice 2024", "'invoice' & '2024'"},
+		{"Case folded", "Invoice", "'invoice'"},
+		{"Phrase", `"staging database" password`, "('staging' <-> 'database') & 'password'"},
+		{"Unterminated phrase", `"staging database`, "('staging' <-> 'database')"},
+		{"Exclusion", "report -draft", "'report' & !'draft'"},
+		{"Excluded phrase", `report -"first draft"`, "'report' & !('first' <-> 'draft')"},
+		{"OR", "aws OR gcp", "('aws' | 'gcp')"},
+		{"OR binds tighter than AND", "keys aws OR gcp OR azure", "'keys' & ('aws' | 'gcp' | 'azure')"},
+		{"Pipe", "aws | gcp", "('aws' | 'gcp')"},
+		{"Dangling OR", "OR aws OR", "'aws'"},
+		{"Lowercase or is a word", "this or that", "'this' & 'or' & 'that'"},
+		{"Prefix", "pass*", "'pass':*"},
+		{"Excluded prefix", "-tmp*", "!'tmp':*"},
+		{"Hyphen alone", "a - b", "'a' & 'b'"},
+		{"Kept joiners", "jane.doe@mail.com e-mail", "'jane.doe@mail.com' & 'e-mail'"},
+		{"Operators stripped", "12:30 & !c | (d)", "('12' <-> '30') & ('c' | 'd')"},
+		{"Quotes can't escape", `it's '); drop table notes; --`, "('it' <-> 's') & 'drop' & 'table' & 'notes'"},
+		{"Unicode", "Grüße café", "'grüße' & 'café'"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := Parse(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, q.TSQuery())
+		})
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		for _, input := range []string{"", "   ", "!!! ???", `""`, "-", "OR"} {
+			q, err := Parse(input)
+			require.NoError(t, err)
+			assert.True(t, q.Empty(), input)
+		}
+	})
+
+	t.Run("Too many terms", func(t *testing.T) {
+		words := make([]string, MaxTerms+1)
+		for i := range words {
+			words[i] = fmt.Sprintf("w%d", i)
+		}
+		_, err := Parse(strings.Join(words, " "))
+		assert.Error(t, err)
+	})
+
+	t.Run("Words", func(t *testing.T) {
+		q, err := Parse(`"staging db" -prod aws OR gcp`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"staging", "db", "aws", "gcp"}, q.Words())
+	})
+}
+
+func TestOperators(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return d
+	}
+
+	t.Run("Mixed with text", func(t *testing.T) {
+		q, err := Parse(`tag:Infra has:attachment aws OR gcp -is:archived type:login`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "('aws' | 'gcp')", q.TSQuery())
+		assert.Equal(
+			t,
+			[]Filter{
+				{Operator: TagOperator, Value: "infra", raw: "tag:Infra"},
+				{Operator: HasOperator, Value: HasAttachment, raw: "has:attachment"},
+				{Operator: IsOperator, Value: IsArchived, Negated: true, raw: "is:archived"},
+				{Operator: TypeOperator, Value: "login", raw: "type:login"},
+			},
+			q.Filters,
+		)
+	})
+
+	t.Run("Only operators", func(t *testing.T) {
+		q, err := Parse("is:shared")
+		require.NoError(t, err)
+		assert.False(t, q.Empty())
+		assert.False(t, q.HasText())
+	})
+
+	t.Run("Quoted value", func(t *testing.T) {
+		q, err := Parse(`owner:"Jane Doe" report`)
+		require.NoError(t, err)
+		require.Len(t, q.Filters, 1)
+		assert.Equal(t, "Jane Doe", q.Filters[0].Value)
+		assert.Equal(t, "'report'", q.TSQuery())
+	})
+
+	t.Run("Dates", func(t *testing.T) {
+		q, err := Parse("before:2026-01-01 after:2025-06-01 updated:2025-03-01..2025-03-31 created:2025-02-14 updated:..2024-12-31")
+		require.NoError(t, err)
+		require.Len(t, q.Filters, 5)
+
+		assert.Equal(t, CreatedOperator, q.Filters[0].Operator)
+		assert.True(t, q.Filters[0].From.IsZero())
+		assert.Equal(t, day("2026-01-01"), q.Filters[0].To)
+
+		assert.Equal(t, day("2025-06-01"), q.Filters[1].From)
+		assert.True(t, q.Filters[1].To.IsZero())
+
+		assert.Equal(t, UpdatedOperator, q.Filters[2].Operator)
+		assert.Equal(t, day("2025-03-01"), q.Filters[2].From)
+		assert.Equal(t, day("2025-04-01"), q.Filters[2].To)
+
+		assert.Equal(t, day("2025-02-14"), q.Filters[3].From)
+		assert.Equal(t, day("2025-02-15"), q.Filters[3].To)
+
+		assert.True(t, q.Filters[4].From.IsZero())
+		assert.Equal(t, day("2025-01-01"), q.Filters[4].To)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := Parse("color:red tag:ok is:pinned before:yesterday created:2025-02-01..2025-01-01 size:big")
+
+		var opErr *OperatorError
+		require.ErrorAs(t, err, &opErr)
+		assert.Len(t, opErr.Invalid, 3)
+		assert.Equal(t, opErr.Invalid, opErr.Details()["invalid_operators"])
+	})
+
+	t.Run("Unknown keys are words", func(t *testing.T) {
+		for input, tsquery := range map[string]string{
+			"color:red":           "('color' <-> 'red')",
+			"Re:foo":              "('re' <-> 'foo')",
+			"https://example.com": "('https' <-> 'example.com')",
+		} {
+			q, err := Parse(input)
+			require.NoError(t, err, input)
+			assert.Empty(t, q.Filters, input)
+			assert.Equal(t, tsquery, q.TSQuery(), input)
+		}
+	})
+
+	t.Run("Colons in words", func(t *testing.T) {
+		q, err := Parse("Re: meeting at 10:30")
+		require.NoError(t, err)
+		assert.Empty(t, q.Filters)
+		assert.Equal(t, "'re' & 'meeting' & 'at' & ('10' <-> '30')", q.TSQuery())
+	})
+}
+
+func TestCorrect(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		q, err := Parse(`"staging db" -prod aws OR gcp pass* 12:30 tag:infra -is:archived`)
+		require.NoError(t, err)
+		assert.Equal(t, `"staging db" -prod aws OR gcp pass* "12 30" tag:infra -is:archived`, q.String())
+	})
+
+	t.Run("Replaces words", func(t *testing.T) {
+		q, err := Parse(`"stagign db" -prod pasword`)
+		require.NoError(t, err)
+
+		corrected := q.Correct(map[string]string{"stagign": "staging", "pasword": "password"})
+		assert.Equal(t, `"staging db" -prod password`, corrected.String())
+		assert.Equal(t, `"stagign db" -prod pasword`, q.String(), "the original is left as is")
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	mark := func(s string) string { return startSel + s + stopSel }
+
+	t.Run("Marks matches", func(t *testing.T) {
+		assert.Equal(t, "the <mark>staging</mark> db", Highlight("the "+mark("staging")+" db"))
+	})
+
+	t.Run("Escapes text", func(t *testing.T) {
+		assert.Equal(
+			t,
+			"&lt;script&gt; <mark>alert</mark>",
+			Highlight("<script> "+mark("alert")),
+		)
+	})
+
+	t.Run("Unbalanced markers", func(t *testing.T) {
+		assert.Equal(t, "a b", Highlight("a "+stopSel+"b"))
+		assert.Equal(t, "<mark>a b</mark>", Highlight(startSel+"a "+startSel+"b"))
+	})
+}
@@ -0,0 +1,180 @@
+// Package secretref resolves references to note fields, such as vault://<noteId>/<field>,
+// inside env templates.
+package secretref
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const Scheme = "vault://"
+
+// a reference ends at whitespace, a quote or the first character that can't be part of a field name,
+// so it can be embedded in a larger value, e.g. postgres://app:vault://<noteId>/password@db/app
+var refPattern = regexp.MustCompile(`vault://[^\s"'/]*(?:/[A-Za-z0-9_-]*)?`)
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Ref points at a field of a note.
+type Ref struct {
+	Raw    string
+	NoteID uuid.UUID
+	Field  string
+}
+
+func (r Ref) String() string {
+	return r.Raw
+}
+
+// Parse parses a single vault://<noteId>/<field> reference.
+func Parse(raw string) (Ref, error) {
+	rest, ok := strings.CutPrefix(raw, Scheme)
+	if !ok {
+		return Ref{}, fmt.Errorf("%s: not a %s reference", raw, Scheme)
+	}
+
+	id, field, ok := strings.Cut(rest, "/")
+	if !ok || field == "" {
+		return Ref{}, fmt.Errorf("%s: expected %s<noteId>/<field>", raw, Scheme)
+	}
+
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		return Ref{}, fmt.Errorf("%s: invalid note ID: %w", raw, err)
+	}
+
+	return Ref{Raw: raw, NoteID: noteID, Field: field}, nil
+}
+
+// Entry is a single KEY=value line of an env file.
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// ParseEnv reads a dotenv-style template. Blank lines and # comments are skipped,
+// an optional "export " prefix is allowed and surrounding quotes are stripped from values.
+func ParseEnv(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		text = strings.TrimPrefix(text, "export ")
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+
+		entries = append(entries, Entry{Key: key, Value: unquote(strings.TrimSpace(value))})
+	}
+
+	return entries, scanner.Err()
+}
+
+// Resolver returns the value a reference points at.
+type Resolver func(ref Ref) (string, error)
+
+// Expand replaces every reference in the entries' values with what resolve returns.
+// All references are attempted, errors are returned for each one that could not be resolved.
+func Expand(entries []Entry, resolve Resolver) ([]Entry, []error) {
+	var errs []error
+	out := make([]Entry, len(entries))
+
+	for i, entry := range entries {
+		value := refPattern.ReplaceAllStringFunc(entry.Value, func(raw string) string {
+			ref, err := Parse(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Key, err))
+				return raw
+			}
+			resolved, err := resolve(ref)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", entry.Key, raw, err))
+				return raw
+			}
+			return resolved
+		})
+		out[i] = Entry{Key: entry.Key, Value: value}
+	}
+
+	return out, errs
+}
+
+// Field returns a field of a note: any of its structured fields, or its title or content.
+func Field(note models.NoteOut, field string) (string, bool) {
+	if v, ok := note.Fields[field]; ok {
+		return v, true
+	}
+	switch field {
+	case "title":
+		return note.Title, true
+	case "content":
+		return note.Content, true
+	}
+	return "", false
+}
+
+// FormatEnv renders entries as a dotenv file with double-quoted values.
+func FormatEnv(entries []Entry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.Key)
+		b.WriteString("=")
+		b.WriteString(quote(entry.Value))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Environ renders entries as KEY=value pairs for a process environment.
+func Environ(entries []Entry) []string {
+	env := make([]string, len(entries))
+	for i, entry := range entries {
+		env[i] = entry.Key + "=" + entry.Value
+	}
+	return env
+}
+
+// escapes are what quote escapes in a double-quoted value, each character followed by its escape.
+var escapes = []string{`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, `$`, `\$`}
+
+// quoter and unquoter escape and unescape double-quoted values, one undoing the other.
+var quoter, unquoter = strings.NewReplacer(escapes...), strings.NewReplacer(reversed(escapes)...)
+
+// reversed swaps each pair of replacer arguments.
+func reversed(pairs []string) []string {
+	out := make([]string, len(pairs))
+	for i := 0; i < len(pairs); i += 2 {
+		out[i], out[i+1] = pairs[i+1], pairs[i]
+	}
+	return out
+}
+
+func quote(v string) string {
+	return `"` + quoter.Replace(v) + `"`
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 {
+		switch {
+		case v[0] == '"' && v[len(v)-1] == '"':
+			return unquoter.Replace(v[1 : len(v)-1])
+		case v[0] == '\'' && v[len(v)-1] == '\'':
+			return v[1 : len(v)-1]
+		}
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package secretref
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"vault/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	id := uuid.New()
+
+	ref, err := Parse(fmt.Sprintf("vault://%s/password", id))
+	require.NoError(t, err)
+	assert.Equal(t, id, ref.NoteID)
+	assert.Equal(t, "password", ref.Field)
+
+	for _, raw := range []string{"vault://", "vault://not-a-uuid/password", fmt.Sprintf("vault://%s/", id), "https://example.com"} {
+		_, err := Parse(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	id := uuid.New()
+	template := fmt.Sprintf(`
+# database
+export DB_PASSWORD=vault://%[1]s/password
+DB_URL="postgres://app:vault://%[1]s/password@db:5432/app"
+PLAIN='not a secret'
+MISSING=vault://%[1]s/token
+`, id)
+
+	entries, err := ParseEnv(strings.NewReader(template))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	note := models.NoteOut{Title: "Database", Fields: models.NoteFields{"password": `s3cr"t`}}
+	resolved, errs := Expand(entries, func(ref Ref) (string, error) {
+		if ref.NoteID != id {
+			return "", fmt.Errorf("not found")
+		}
+		v, ok := Field(note, ref.Field)
+		if !ok {
+			return "", fmt.Errorf("no field %s", ref.Field)
+		}
+		return v, nil
+	})
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "MISSING")
+	assert.Equal(t, `s3cr"t`, resolved[0].Value)
+	assert.Equal(t, `postgres://app:s3cr"t@db:5432/app`, resolved[1].Value)
+	assert.Equal(t, "not a secret", resolved[2].Value)
+
+	assert.Equal(t, `DB_PASSWORD="s3cr\"t"`+"\n", FormatEnv(resolved[:1]))
+}
+
+func TestQuote(t *testing.T) {
+	for _, v := range []string{"", "plain", `pa$$word`, "line\r\nbreak", `say "hi"`, `C:\path\n`, `\$HOME`, `\"`} {
+		assert.Equal(t, v, unquote(quote(v)), v)
+
+		entries, err := ParseEnv(strings.NewReader(FormatEnv([]Entry{{Key: "SECRET", Value: v}})))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, v, entries[0].Value)
+	}
+}
+
+func TestParseEnvRejectsGarbage(t *testing.T) {
+	_, err := ParseEnv(strings.NewReader("NOT AN ASSIGNMENT"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,601 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalPath is where the API serves local storage from.
+const LocalPath = "/storage"
+
+// Local keeps files in a directory for running the API without AWS. The API process serves
+// its presigned URLs and forms, signed with a secret, and runs OnUpload after each upload the way S3 notifies ingest.
+// Content types are kept next to the files, under .meta, and multipart uploads under .multipart until completed.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+
+	// Public are key prefixes anyone can download without a signature, like avatars a CDN serves in production.
+	Public []string
+	// OnUpload runs once a file is uploaded, before the upload is answered.
+	OnUpload func(ctx context.Context, key string)
+}
+
+// NewLocal keeps files in dir, baseURL being where clients reach the API, e.g. http://localhost:8080.
+func NewLocal(dir string, baseURL string, secret []byte) (*Local, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("local storage needs a secret to sign URLs")
+	}
+	for _, sub := range []string{".meta", ".tmp", ".multipart"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}, nil
+}
+
+// URL is the unsigned address of a file, only downloadable when it's public.
+func (l *Local) URL(key string) string {
+	return l.baseURL + LocalPath + (&url.URL{Path: "/" + key}).EscapedPath()
+}
+
+// PresignPost signs a form for uploading to the key, which carries the policy in its fields.
+func (l *Local) PresignPost(_ context.Context, key string, policy UploadPolicy) (PresignedPost, error) {
+	if err := validKey(key); err != nil {
+		return PresignedPost{}, err
+	}
+	expires := strconv.FormatInt(time.Now().Add(PresignTTL).Unix(), 10)
+	fields := map[string]string{
+		"key":                  key,
+		"Content-Type":         policy.ContentType,
+		"content-length-range": fmt.Sprintf("%d,%d", policy.MinSize, policy.MaxSize),
+		"expires":              expires,
+	}
+	fields["signature"] = l.sign(http.MethodPost, key, expires, postDetail(fields))
+	return PresignedPost{URL: l.URL(key), Fields: fields}, nil
+}
+
+// postDetail is what the signature of an upload form covers besides its key, the policy.
+func postDetail(fields map[string]string) string {
+	return fields["Content-Type"] + "\n" + fields["content-length-range"]
+}
+
+func (l *Local) PresignGet(_ context.Context, key string, fileName string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(PresignTTL).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {l.sign(http.MethodGet, key, expires, fileName)}}
+	if fileName != "" {
+		query.Set("filename", fileName)
+	}
+	return l.URL(key) + "?" + query.Encode(), nil
+}
+
+// sign signs a request for the method and key. The detail is the file name of downloads,
+// the policy of uploads and the upload and number of parts, which the request has to come with.
+func (l *Local) sign(method string, key string, expires string, detail string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(strings.Join([]string{method, key, expires, detail}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a presigned request: signed for this method, key and detail, and not expired.
+func (l *Local) verify(method string, key string, detail string, expires string, signature string) bool {
+	at, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > at {
+		return false
+	}
+	want := l.sign(method, key, expires, detail)
+	return hmac.Equal([]byte(want), []byte(signature))
+}
+
+func (l *Local) public(key string) bool {
+	for _, prefix := range l.Public {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP answers presigned uploads and downloads under LocalPath.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, LocalPath+"/")
+	if err := validKey(key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		query := r.URL.Query()
+		fileName := query.Get("filename")
+		if !l.public(key) && !l.verify(http.MethodGet, key, fileName, query.Get("expires"), query.Get("signature")) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+		if fileName != "" {
+			w.Header().Set("Content-Disposition", contentDisposition(fileName))
+		}
+		l.serve(w, r, key)
+
+	case http.MethodPost:
+		l.upload(w, r, key)
+
+	case http.MethodPut:
+		l.uploadPart(w, r, key)
+
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// maxField is the longest form field an upload reads.
+const maxField = 4096
+
+// upload stores the file of a presigned form, which S3 would check against the policy the same way.
+func (l *Local) upload(w http.ResponseWriter, r *http.Request, key string) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+
+	// the file comes last, after the fields signed for it
+	fields := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			http.Error(w, "the form has no file", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			value, err := io.ReadAll(io.LimitReader(part, maxField))
+			if err != nil {
+				http.Error(w, "failed to read the form", http.StatusBadRequest)
+				return
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+
+		if fields["key"] != key || !l.verify(http.MethodPost, key, postDetail(fields), fields["expires"], fields["signature"]) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+
+		var minSize, maxSize int64
+		if _, err := fmt.Sscanf(fields["content-length-range"], "%d,%d", &minSize, &maxSize); err != nil {
+			http.Error(w, "invalid content-length-range", http.StatusBadRequest)
+			return
+		}
+
+		err = l.write(key, fields["Content-Type"], part, &UploadPolicy{MinSize: minSize, MaxSize: maxSize})
+		if errors.Is(err, errSize) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to store %s: %v", key, err)
+			http.Error(w, "failed to store the file", http.StatusInternalServerError)
+			return
+		}
+
+		if l.OnUpload != nil {
+			l.OnUpload(r.Context(), key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+}
+
+// uploadPart stores a part of a multipart upload at a presigned URL, replacing the part uploaded before under its number.
+func (l *Local) uploadPart(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	uploadID, n := query.Get("uploadId"), query.Get("partNumber")
+	if !l.verify(http.MethodPut, key, partDetail(uploadID, n), query.Get("expires"), query.Get("signature")) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+	if number, err := strconv.Atoi(n); err != nil || number < 1 || number > MaxParts {
+		http.Error(w, "invalid part number", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := l.multipart(key, uploadID); err != nil {
+		http.Error(w, "no such upload", http.StatusNotFound)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(l.dir, ".tmp"), "part-")
+	if err != nil {
+		http.Error(w, "failed to store the part", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(l.multipartPath(uploadID), n))
+	}
+	if err != nil {
+		log.Printf("Failed to store part %s of %s: %v", n, key, err)
+		http.Error(w, "failed to store the part", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(hex.EncodeToString(hash.Sum(nil))))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (l *Local) serve(w http.ResponseWriter, r *http.Request, key string) {
+	file, err := os.Open(l.path(key))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if contentType := l.contentType(key); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), file)
+}
+
+func (l *Local) Head(_ context.Context, key string) (Object, error) {
+	if err := validKey(key); err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(l.path(key))
+	if err != nil || info.IsDir() {
+		return Object{}, missing(key, err)
+	}
+	return Object{Key: key, Size: info.Size(), ContentType: l.contentType(key), Modified: info.ModTime()}, nil
+}
+
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(l.path(key))
+	if err != nil {
+		return nil, missing(key, err)
+	}
+	return file, nil
+}
+
+// Delete removes a file, keys holding none are left as they are like in S3.
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	for _, p := range []string{l.path(key), l.metaPath(key)} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if strings.HasPrefix(key, ".") && key != "." {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), ContentType: l.contentType(key), Modified: info.ModTime()})
+		return nil
+	})
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, err
+}
+
+func (l *Local) Copy(_ context.Context, from string, to string) error {
+	if err := validKey(from); err != nil {
+		return err
+	}
+	file, err := os.Open(l.path(from))
+	if err != nil {
+		return missing(from, err)
+	}
+	defer file.Close()
+
+	return l.write(to, l.contentType(from), file, nil)
+}
+
+func (l *Local) Put(_ context.Context, key string, contentType string, body io.ReadSeeker) error {
+	return l.write(key, contentType, body, nil)
+}
+
+// CreateMultipart starts an upload in .multipart/{uploadID}, which holds its key and content type
+// in a file named upload and its parts in files named by their number.
+func (l *Local) CreateMultipart(_ context.Context, key string, contentType string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+
+	if err := os.Mkdir(l.multipartPath(uploadID), 0o755); err != nil {
+		return "", err
+	}
+	upload := []byte(key + "\n" + contentType)
+	if err := os.WriteFile(filepath.Join(l.multipartPath(uploadID), "upload"), upload, 0o644); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+func (l *Local) PresignPart(_ context.Context, key string, uploadID string, n int) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(PresignTTL).Unix(), 10)
+	number := strconv.Itoa(n)
+	query := url.Values{
+		"uploadId":   {uploadID},
+		"partNumber": {number},
+		"expires":    {expires},
+		"signature":  {l.sign(http.MethodPut, key, expires, partDetail(uploadID, number))},
+	}
+	return l.URL(key) + "?" + query.Encode(), nil
+}
+
+// partDetail is what the signature of a part's URL covers besides its key.
+func partDetail(uploadID string, n string) string {
+	return uploadID + "\n" + n
+}
+
+func (l *Local) ListParts(_ context.Context, key string, uploadID string) ([]Part, error) {
+	if _, _, err := l.multipart(key, uploadID); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(l.multipartPath(uploadID))
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []Part
+	for _, entry := range entries {
+		n, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue // the upload file
+		}
+		part, err := l.part(uploadID, n)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// part describes an uploaded part, tagged with the MD5 of its content like S3 does.
+func (l *Local) part(uploadID string, n int) (Part, error) {
+	file, err := os.Open(filepath.Join(l.multipartPath(uploadID), strconv.Itoa(n)))
+	if err != nil {
+		return Part{}, err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{Number: n, Size: size, ETag: strconv.Quote(hex.EncodeToString(hash.Sum(nil)))}, nil
+}
+
+// CompleteMultipart joins the parts in the order given, which have to be the ones uploaded,
+// and runs OnUpload like a file uploaded in one go.
+func (l *Local) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	_, contentType, err := l.multipart(key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	var files []io.Reader
+	for _, p := range parts {
+		uploaded, err := l.part(uploadID, p.Number)
+		if err != nil || uploaded.ETag != p.ETag {
+			return fmt.Errorf("part %d was not uploaded as given", p.Number)
+		}
+		file, err := os.Open(filepath.Join(l.multipartPath(uploadID), strconv.Itoa(p.Number)))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		files = append(files, file)
+	}
+
+	if err := l.write(key, contentType, io.MultiReader(files...), nil); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(l.multipartPath(uploadID)); err != nil {
+		return err
+	}
+
+	if l.OnUpload != nil {
+		l.OnUpload(ctx, key)
+	}
+	return nil
+}
+
+func (l *Local) AbortMultipart(_ context.Context, key string, uploadID string) error {
+	if _, _, err := l.multipart(key, uploadID); err != nil {
+		return err
+	}
+	return os.RemoveAll(l.multipartPath(uploadID))
+}
+
+func (l *Local) ListMultipart(_ context.Context, prefix string) ([]Multipart, error) {
+	entries, err := os.ReadDir(filepath.Join(l.dir, ".multipart"))
+	if err != nil {
+		return nil, err
+	}
+
+	var uploads []Multipart
+	for _, entry := range entries {
+		key, _, err := l.multipart("", entry.Name())
+		if err != nil || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, Multipart{Key: key, UploadID: entry.Name(), Started: info.ModTime()})
+	}
+
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].Started.Before(uploads[j].Started) })
+	return uploads, nil
+}
+
+// multipart is the key and content type of an upload, which has to be for key unless that's empty.
+func (l *Local) multipart(key string, uploadID string) (string, string, error) {
+	if uploadID == "" || strings.ContainsAny(uploadID, `/\.`) {
+		return "", "", fmt.Errorf("invalid upload ID: %q", uploadID)
+	}
+	data, err := os.ReadFile(filepath.Join(l.multipartPath(uploadID), "upload"))
+	if err != nil {
+		return "", "", missing(uploadID, err)
+	}
+	uploadKey, contentType, _ := strings.Cut(string(data), "\n")
+	if key != "" && uploadKey != key {
+		return "", "", fmt.Errorf("%w: upload %s of %s", ErrNotFound, uploadID, key)
+	}
+	return uploadKey, contentType, nil
+}
+
+func (l *Local) multipartPath(uploadID string) string {
+	return filepath.Join(l.dir, ".multipart", uploadID)
+}
+
+// errSize is returned for uploads outside their policy's content-length-range.
+var errSize = errors.New("the file is not the size the upload was signed for")
+
+// write stores a file through a temporary one, so readers never see it half written.
+// With a policy, files outside its size range aren't stored.
+func (l *Local) write(key string, contentType string, body io.Reader, policy *UploadPolicy) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(l.dir, ".tmp"), "upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if policy != nil {
+		body = io.LimitReader(body, policy.MaxSize+1)
+	}
+	size, err := io.Copy(tmp, body)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if policy != nil && (size < policy.MinSize || size > policy.MaxSize) {
+		return errSize
+	}
+
+	for _, p := range []string{l.path(key), l.metaPath(key)} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(l.metaPath(key), []byte(contentType), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), l.path(key))
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
+
+func (l *Local) metaPath(key string) string {
+	return filepath.Join(l.dir, ".meta", filepath.FromSlash(key))
+}
+
+func (l *Local) contentType(key string) string {
+	data, err := os.ReadFile(l.metaPath(key))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// validKey keeps keys inside the storage directory and out of its hidden bookkeeping.
+func validKey(key string) error {
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.HasPrefix(key, ".") {
+		return fmt.Errorf("invalid key: %q", key)
+	}
+	return nil
+}
+
+func missing(key string, err error) error {
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocal(t *testing.T) (*Local, *[]string) {
+	var local *Local
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	local, err := NewLocal(t.TempDir(), server.URL, []byte("secret"))
+	require.NoError(t, err)
+
+	var uploaded []string
+	local.OnUpload = func(_ context.Context, key string) {
+		uploaded = append(uploaded, key)
+	}
+	return local, &uploaded
+}
+
+// post uploads a file through a presigned form, with some of its fields changed.
+func post(t *testing.T, form PresignedPost, body string, changed map[string]string) int {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, value := range form.Fields {
+		if v, ok := changed[name]; ok {
+			value = v
+		}
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	file, err := writer.CreateFormFile("file", "keys.txt")
+	require.NoError(t, err)
+	_, err = io.WriteString(file, body)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	resp, err := http.Post(form.URL, writer.FormDataContentType(), &buf)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func get(t *testing.T, url string) (int, http.Header, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header, string(body)
+}
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	local, uploaded := newTestLocal(t)
+	key := "attachments/note/keys.txt"
+
+	t.Run("Presigned upload", func(t *testing.T) {
+		size := int64(len("staging keys"))
+		form, err := local.PresignPost(ctx, key, UploadPolicy{ContentType: "text/plain", MinSize: size, MaxSize: size})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, post(t, form, "<b>staging</b>", map[string]string{"Content-Type": "text/html"}))
+		assert.Equal(t, http.StatusForbidden, post(t, form, "staging keys and more", map[string]string{"content-length-range": "1,1000"}))
+		assert.Equal(t, http.StatusForbidden, post(t, form, "staging keys", map[string]string{"key": "attachments/note/other.txt"}))
+		assert.Equal(t, http.StatusForbidden, post(t, form, "staging keys", map[string]string{"signature": "0"}))
+		assert.Equal(t, http.StatusBadRequest, post(t, form, "staging keys and more", nil))
+		assert.Equal(t, http.StatusBadRequest, post(t, form, "keys", nil))
+		assert.Empty(t, *uploaded)
+
+		_, err = local.Head(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		assert.Equal(t, http.StatusNoContent, post(t, form, "staging keys", nil))
+		assert.Equal(t, []string{key}, *uploaded)
+
+		object, err := local.Head(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("staging keys")), object.Size)
+		assert.Equal(t, "text/plain", object.ContentType)
+	})
+
+	t.Run("Presigned download", func(t *testing.T) {
+		url, err := local.PresignGet(ctx, key, "")
+		require.NoError(t, err)
+
+		status, header, body := get(t, url)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "text/plain", header.Get("Content-Type"))
+		assert.Empty(t, header.Get("Content-Disposition"))
+		assert.Equal(t, "staging keys", body)
+
+		status, _, _ = get(t, local.URL(key))
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Downloads under a file name", func(t *testing.T) {
+		url, err := local.PresignGet(ctx, key, "Clés staging.txt")
+		require.NoError(t, err)
+
+		status, header, _ := get(t, url)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "attachment; filename*=utf-8''Cl%C3%A9s%20staging.txt", header.Get("Content-Disposition"))
+
+		status, _, _ = get(t, strings.Replace(url, "filename=", "filename=x", 1))
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Public prefixes", func(t *testing.T) {
+		form, err := local.PresignPost(ctx, "avatars/user", UploadPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 1024})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, post(t, form, "png", nil))
+
+		status, _, _ := get(t, local.URL("avatars/user"))
+		assert.Equal(t, http.StatusForbidden, status)
+
+		local.Public = []string{"avatars/"}
+		status, header, body := get(t, local.URL("avatars/user"))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "image/png", header.Get("Content-Type"))
+		assert.Equal(t, "png", body)
+	})
+
+	t.Run("Copy, list and delete", func(t *testing.T) {
+		copied := "attachments/other/keys.txt"
+		require.NoError(t, local.Copy(ctx, key, copied))
+
+		objects, err := local.List(ctx, "attachments/")
+		require.NoError(t, err)
+		require.Len(t, objects, 2)
+		assert.Equal(t, key, objects[0].Key)
+		assert.Equal(t, copied, objects[1].Key)
+		assert.Equal(t, "text/plain", objects[1].ContentType)
+
+		require.NoError(t, local.Delete(ctx, key))
+		require.NoError(t, local.Delete(ctx, key))
+
+		_, err = local.Head(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = local.Open(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, local.Copy(ctx, key, "attachments/x"), ErrNotFound)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		thumbnail := "thumbnails/note/keys/small.jpg"
+		require.NoError(t, local.Put(ctx, thumbnail, "image/jpeg", strings.NewReader("jpeg")))
+
+		object, err := local.Head(ctx, thumbnail)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), object.Size)
+		assert.Equal(t, "image/jpeg", object.ContentType)
+		assert.Len(t, *uploaded, 2, "only uploads run OnUpload")
+	})
+
+	t.Run("Keys stay inside the directory", func(t *testing.T) {
+		for _, key := range []string{"", "../etc/passwd", "attachments/../../x", ".meta/attachments", "/abs", "a//b"} {
+			_, err := local.PresignPost(ctx, key, UploadPolicy{ContentType: "text/plain", MaxSize: 1})
+			assert.Error(t, err, key)
+		}
+	})
+}
+
+func put(t *testing.T, url string, body string) int {
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestLocalMultipart(t *testing.T) {
+	ctx := context.Background()
+	local, uploaded := newTestLocal(t)
+	key := "attachments/note/backup.tar"
+
+	uploadID, err := local.CreateMultipart(ctx, key, "application/x-tar")
+	require.NoError(t, err)
+
+	t.Run("Parts upload in any order and again", func(t *testing.T) {
+		for n, body := range map[int]string{2: "world", 1: "hullo ", 3: "!"} {
+			url, err := local.PresignPart(ctx, key, uploadID, n)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, put(t, url, body))
+		}
+		url, err := local.PresignPart(ctx, key, uploadID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, put(t, url, "hello "))
+		assert.Equal(t, http.StatusForbidden, put(t, strings.Replace(url, "partNumber=1", "partNumber=2", 1), "x"))
+
+		parts, err := local.ListParts(ctx, key, uploadID)
+		require.NoError(t, err)
+		require.Len(t, parts, 3)
+		assert.Equal(t, []int{1, 2, 3}, []int{parts[0].Number, parts[1].Number, parts[2].Number})
+		assert.Equal(t, int64(len("hello ")), parts[0].Size)
+
+		uploads, err := local.ListMultipart(ctx, "attachments/")
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, key, uploads[0].Key)
+		assert.Equal(t, uploadID, uploads[0].UploadID)
+	})
+
+	t.Run("Completing joins the parts", func(t *testing.T) {
+		parts, err := local.ListParts(ctx, key, uploadID)
+		require.NoError(t, err)
+
+		changed := append([]Part{}, parts...)
+		changed[1].ETag = `"0"`
+		assert.Error(t, local.CompleteMultipart(ctx, key, uploadID, changed))
+		_, err = local.ListParts(ctx, "attachments/note/other.tar", uploadID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, local.CompleteMultipart(ctx, key, uploadID, parts))
+		assert.Equal(t, []string{key}, *uploaded)
+
+		object, err := local.Head(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "application/x-tar", object.ContentType)
+		body, err := local.Open(ctx, key)
+		require.NoError(t, err)
+		data, err := io.ReadAll(body)
+		body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "hello world!", string(data))
+
+		_, err = local.ListParts(ctx, key, uploadID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Aborting drops the parts", func(t *testing.T) {
+		uploadID, err := local.CreateMultipart(ctx, key, "application/x-tar")
+		require.NoError(t, err)
+		url, err := local.PresignPart(ctx, key, uploadID, 1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, put(t, url, "partial"))
+
+		require.NoError(t, local.AbortMultipart(ctx, key, uploadID))
+		assert.ErrorIs(t, local.AbortMultipart(ctx, key, uploadID), ErrNotFound)
+		assert.Equal(t, http.StatusNotFound, put(t, url, "partial"))
+
+		uploads, err := local.ListMultipart(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, uploads)
+	})
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3 keeps files in a bucket.
+type S3 struct {
+	client *s3.S3
+	bucket string
+}
+
+func NewS3(bucket string, region string) (*S3, error) {
+	if bucket == "" || region == "" {
+		return nil, fmt.Errorf("S3 storage needs ATTACHMENT_BUCKET and REGION")
+	}
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	return &S3{client: s3.New(sess), bucket: bucket}, nil
+}
+
+// PresignPost signs a POST policy for the bucket with Signature Version 4, which the SDK has no helper for.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+func (s *S3) PresignPost(ctx context.Context, key string, policy UploadPolicy) (PresignedPost, error) {
+	creds, err := s.client.Config.Credentials.GetWithContext(ctx)
+	if err != nil {
+		return PresignedPost{}, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	now := time.Now().UTC()
+	region := aws.StringValue(s.client.Config.Region)
+	date := now.Format("20060102")
+
+	fields := map[string]string{
+		"key":              key,
+		"Content-Type":     policy.ContentType,
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": strings.Join([]string{creds.AccessKeyID, date, region, "s3", "aws4_request"}, "/"),
+		"x-amz-date":       now.Format("20060102T150405Z"),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+
+	// every field is a condition, exactly matched
+	conditions := []any{
+		map[string]string{"bucket": s.bucket},
+		[]any{"content-length-range", policy.MinSize, policy.MaxSize},
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conditions = append(conditions, map[string]string{name: fields[name]})
+	}
+
+	document, err := json.Marshal(map[string]any{
+		"expiration": now.Add(PresignTTL).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return PresignedPost{}, fmt.Errorf("failed to encode policy: %w", err)
+	}
+	fields["policy"] = base64.StdEncoding.EncodeToString(document)
+
+	signingKey := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey, fields["policy"]))
+
+	return PresignedPost{URL: fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", s.bucket, region), Fields: fields}, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (s *S3) PresignGet(_ context.Context, key string, fileName string) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	if fileName != "" {
+		input.ResponseContentDisposition = aws.String(contentDisposition(fileName))
+	}
+
+	req, _ := s.client.GetObjectRequest(input)
+	u, err := req.Presign(PresignTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+	return u, nil
+}
+
+func (s *S3) Head(ctx context.Context, key string) (Object, error) {
+	head, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return Object{}, notFound(err)
+	}
+
+	return Object{
+		Key:         key,
+		Size:        aws.Int64Value(head.ContentLength),
+		ContentType: aws.StringValue(head.ContentType),
+		Modified:    aws.TimeValue(head.LastModified),
+	}, nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return object.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := s.client.ListObjectsV2PagesWithContext(
+		ctx,
+		&s3.ListObjectsV2Input{Bucket: aws.String(s.bucket), Prefix: aws.String(prefix)},
+		func(page *s3.ListObjectsV2Output, _ bool) bool {
+			for _, o := range page.Contents {
+				objects = append(objects, Object{
+					Key:      aws.StringValue(o.Key),
+					Size:     aws.Int64Value(o.Size),
+					Modified: aws.TimeValue(o.LastModified),
+				})
+			}
+			return true
+		},
+	)
+	return objects, err
+}
+
+func (s *S3) Copy(ctx context.Context, from string, to string) error {
+	_, err := s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		CopySource: aws.String(s.bucket + "/" + (&url.URL{Path: from}).EscapedPath()),
+		Key:        aws.String(to),
+	})
+	return notFound(err)
+}
+
+func (s *S3) Put(ctx context.Context, key string, contentType string, body io.ReadSeeker) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	return err
+}
+
+func (s *S3) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (s *S3) PresignPart(_ context.Context, key string, uploadID string, n int) (string, error) {
+	req, _ := s.client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(int64(n)),
+	})
+	u, err := req.Presign(PresignTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+	return u, nil
+}
+
+func (s *S3) ListParts(ctx context.Context, key string, uploadID string) ([]Part, error) {
+	var parts []Part
+	err := s.client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, func(page *s3.ListPartsOutput, _ bool) bool {
+		for _, p := range page.Parts {
+			parts = append(parts, Part{
+				Number: int(aws.Int64Value(p.PartNumber)),
+				Size:   aws.Int64Value(p.Size),
+				ETag:   aws.StringValue(p.ETag),
+			})
+		}
+		return true
+	})
+	return parts, notFound(err)
+}
+
+func (s *S3) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = &s3.CompletedPart{PartNumber: aws.Int64(int64(p.Number)), ETag: aws.String(p.ETag)}
+	}
+
+	_, err := s.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return notFound(err)
+}
+
+func (s *S3) AbortMultipart(ctx context.Context, key string, uploadID string) error {
+	_, err := s.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return notFound(err)
+}
+
+func (s *S3) ListMultipart(ctx context.Context, prefix string) ([]Multipart, error) {
+	var uploads []Multipart
+	err := s.client.ListMultipartUploadsPagesWithContext(ctx, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListMultipartUploadsOutput, _ bool) bool {
+		for _, u := range page.Uploads {
+			uploads = append(uploads, Multipart{
+				Key:      aws.StringValue(u.Key),
+				UploadID: aws.StringValue(u.UploadId),
+				Started:  aws.TimeValue(u.Initiated),
+			})
+		}
+		return true
+	})
+	return uploads, err
+}
+
+// notFound turns S3's missing key errors into ErrNotFound.
+func notFound(err error) error {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchUpload, "NotFound":
+			return fmt.Errorf("%w: %s", ErrNotFound, aerr.Message())
+		}
+	}
+	return err
+}
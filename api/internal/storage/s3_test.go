@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3PresignPost(t *testing.T) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("ca-central-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", "TOKEN"),
+	})
+	require.NoError(t, err)
+	store := &S3{client: s3.New(sess), bucket: "vault"}
+
+	form, err := store.PresignPost(context.Background(), "attachments/note/id", UploadPolicy{ContentType: "text/plain", MinSize: 12, MaxSize: 12})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://vault.s3.ca-central-1.amazonaws.com/", form.URL)
+	assert.Equal(t, "attachments/note/id", form.Fields["key"])
+	assert.Equal(t, "text/plain", form.Fields["Content-Type"])
+	assert.Equal(t, "TOKEN", form.Fields["x-amz-security-token"])
+	assert.Regexp(t, `^AKID/\d{8}/ca-central-1/s3/aws4_request$`, form.Fields["x-amz-credential"])
+
+	document, err := base64.StdEncoding.DecodeString(form.Fields["policy"])
+	require.NoError(t, err)
+	var policy struct {
+		Conditions []any `json:"conditions"`
+	}
+	require.NoError(t, json.Unmarshal(document, &policy))
+	assert.Contains(t, policy.Conditions, map[string]any{"bucket": "vault"})
+	assert.Contains(t, policy.Conditions, []any{"content-length-range", 12.0, 12.0})
+	assert.Contains(t, policy.Conditions, map[string]any{"Content-Type": "text/plain"})
+	assert.Contains(t, policy.Conditions, map[string]any{"key": "attachments/note/id"})
+
+	key := []byte("AWS4SECRET")
+	for _, part := range []string{form.Fields["x-amz-date"][:8], "ca-central-1", "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	assert.Equal(t, hex.EncodeToString(hmacSHA256(key, form.Fields["policy"])), form.Fields["x-amz-signature"])
+}
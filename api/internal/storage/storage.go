@@ -0,0 +1,99 @@
+// Package storage keeps attachment and avatar files, in S3 or on local disk for development.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"time"
+)
+
+// PresignTTL is how long presigned URLs stay valid.
+const PresignTTL = 15 * time.Minute
+
+// ErrNotFound is returned for keys that hold no object.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	Modified    time.Time
+}
+
+// UploadPolicy is what a presigned upload accepts: a file of exactly this content type,
+// between MinSize and MaxSize bytes.
+type UploadPolicy struct {
+	ContentType string
+	MinSize     int64
+	MaxSize     int64
+}
+
+// PresignedPost is a browser-style upload: the fields, then the file as "file",
+// POSTed as multipart/form-data to URL. Storage refuses files the policy doesn't allow.
+type PresignedPost struct {
+	URL    string
+	Fields map[string]string
+}
+
+// MaxParts is how many parts a multipart upload can have, numbered from 1.
+const MaxParts = 10000
+
+// MinPartSize is how small parts can be, but for the last.
+const MinPartSize = 5 << 20
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int // from 1
+	Size   int64
+	ETag   string
+}
+
+// Multipart is a multipart upload under way.
+type Multipart struct {
+	Key      string
+	UploadID string
+	Started  time.Time
+}
+
+// Storage keeps files under keys like "attachments/{noteId}/{attachmentId}".
+// Clients upload and download through presigned URLs, the API itself only looks files up and moves them around,
+// and stores the ones it makes, like thumbnails.
+type Storage interface {
+	// PresignPost is a form to upload a file the policy allows with.
+	PresignPost(ctx context.Context, key string, policy UploadPolicy) (PresignedPost, error)
+	// PresignGet is a URL to download a file from, sent as an attachment named fileName when it's set.
+	PresignGet(ctx context.Context, key string, fileName string) (string, error)
+	Head(ctx context.Context, key string) (Object, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List is every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Copy(ctx context.Context, from string, to string) error
+	// Put stores a file of the content type the API made itself.
+	Put(ctx context.Context, key string, contentType string, body io.ReadSeeker) error
+
+	// CreateMultipart starts uploading a file of the content type in parts, which can be uploaded
+	// in parallel and again when they fail. It returns the ID of the upload.
+	CreateMultipart(ctx context.Context, key string, contentType string) (string, error)
+	// PresignPart is a URL to PUT part number n of a multipart upload to.
+	PresignPart(ctx context.Context, key string, uploadID string, n int) (string, error)
+	// ListParts is the parts uploaded so far, by number.
+	ListParts(ctx context.Context, key string, uploadID string) ([]Part, error)
+	// CompleteMultipart joins the parts into the file.
+	CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error
+	// AbortMultipart drops an upload and its parts.
+	AbortMultipart(ctx context.Context, key string, uploadID string) error
+	// ListMultipart is every multipart upload under way whose key starts with prefix.
+	ListMultipart(ctx context.Context, prefix string) ([]Multipart, error)
+}
+
+// contentDisposition is the Content-Disposition header to download a file under its name.
+func contentDisposition(fileName string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": fileName})
+}
+
+// Default is the storage the API and ingest were configured with.
+var Default Storage
@@ -0,0 +1,40 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"regexp"
+	"vault/internal/extract"
+)
+
+// minPreview is the smallest side of an embedded image taken for a PDF's preview, smaller ones being logos and icons.
+const minPreview = 200
+
+var (
+	imagePattern = regexp.MustCompile(`/Subtype\s*/Image\b`)
+	jpegPattern  = regexp.MustCompile(`/Filter\s*(\[\s*)?/DCTDecode\b`)
+	filters      = regexp.MustCompile(`/[A-Za-z0-9]+Decode\b`)
+)
+
+// pdfPreview is the first JPEG a PDF embeds that's large enough to be a page, which in scanned documents
+// is the first page. Rendering the pages themselves takes more than pure Go.
+func pdfPreview(data []byte) ([]byte, bool) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, false
+	}
+
+	var preview []byte
+	extract.PDFStreams(data, func(dict string, raw []byte) bool {
+		// only JPEG alone, other filters would need decoding first
+		if !imagePattern.MatchString(dict) || !jpegPattern.MatchString(dict) || len(filters.FindAllString(dict, -1)) != 1 {
+			return true
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+		if err == nil && config.Width >= minPreview && config.Height >= minPreview {
+			preview = raw
+			return false
+		}
+		return true
+	})
+	return preview, preview != nil
+}
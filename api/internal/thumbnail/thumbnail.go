@@ -0,0 +1,293 @@
+// Package thumbnail makes JPEG thumbnails of image attachments, previews of PDFs that embed
+// a JPEG of their first page, like scanned documents, and square avatars. It's pure Go: formats
+// the standard library can't decode, like WebP, and PDFs that would need rendering get none.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers the decoder
+	"image/jpeg"
+	_ "image/png"
+	"strings"
+	"vault/internal/models"
+)
+
+// Sizes is the side of the square each thumbnail fits in, in pixels, largest first.
+// Thumbnails are never larger than the original.
+var Sizes = []struct {
+	Name models.ThumbnailSize
+	Side int
+}{
+	{models.LargeThumbnail, 1200},
+	{models.MediumThumbnail, 480},
+	{models.SmallThumbnail, 160},
+}
+
+// ContentType is what thumbnails are encoded as.
+const ContentType = "image/jpeg"
+
+// maxPixels caps the images decoded, which take 4 bytes a pixel, against decompression bombs.
+const maxPixels = 40_000_000
+
+// quality is the JPEG quality of thumbnails.
+const quality = 80
+
+var ErrUnsupported = errors.New("no thumbnails for this type")
+
+var decoded = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"application/pdf": true,
+}
+
+// Supports tells whether thumbnails may be made of files of the MIME type.
+func Supports(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	return decoded[strings.TrimSpace(mimeType)]
+}
+
+// Make makes a JPEG thumbnail of each size out of an image, or a PDF's preview,
+// keyed by size. Transparent images are laid over white.
+func Make(mimeType string, data []byte) (map[models.ThumbnailSize][]byte, error) {
+	if !Supports(mimeType) {
+		return nil, ErrUnsupported
+	}
+	if strings.HasPrefix(strings.ToLower(mimeType), "application/pdf") {
+		preview, ok := pdfPreview(data)
+		if !ok {
+			return nil, fmt.Errorf("the PDF embeds no JPEG preview: %w", ErrUnsupported)
+		}
+		data = preview
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sides := make([]int, len(Sizes))
+	for i, size := range Sizes {
+		sides[i] = size.Side
+	}
+	images, err := scale(img, orientation(data), sides)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnails := make(map[models.ThumbnailSize][]byte, len(Sizes))
+	for i, size := range Sizes {
+		thumbnails[size.Name] = images[i]
+	}
+	return thumbnails, nil
+}
+
+// Avatar makes a square JPEG of each side, largest first, out of the middle of an image.
+// Being encoded anew, they keep none of its metadata, like where a photo was taken.
+func Avatar(data []byte, sides ...int) ([][]byte, error) {
+	img, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	side := min(w, h)
+	x, y := (w-side)/2, (h-side)/2
+	square := img.SubImage(image.Rect(x, y, x+side, y+side)).(*image.RGBA)
+	return scale(square, orientation(data), sides)
+}
+
+// decode decodes an image of up to maxPixels.
+func decode(data []byte) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("image is %dx%d, larger than %d pixels", config.Width, config.Height, maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba, nil
+}
+
+// scale encodes an image shrunk to fit in squares of each side, largest first, turned to its EXIF orientation.
+func scale(img *image.RGBA, orientation int, sides []int) ([][]byte, error) {
+	// each size is shrunk from the one before, which is faster and no worse with box filtering
+	images := make([][]byte, len(sides))
+	for i, side := range sides {
+		img = shrink(img, side)
+		if i == 0 {
+			img = orient(img, orientation)
+			opaque(img)
+		}
+
+		var out bytes.Buffer
+		if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		images[i] = out.Bytes()
+	}
+	return images, nil
+}
+
+// shrink scales an image down to fit in a square of side pixels, each pixel the average of those it covers.
+func shrink(src *image.RGBA, side int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= side && h <= side {
+		return src
+	}
+
+	dw, dh := side, side
+	if w >= h {
+		dh = max(1, h*side/w)
+	} else {
+		dw = max(1, w*side/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * h / dh
+		y1 := max((y+1)*h/dh, y0+1)
+		for x := 0; x < dw; x++ {
+			x0 := x * w / dw
+			x1 := max((x+1)*w/dw, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			i := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// opaque lays an image over white, JPEG having no transparency.
+func opaque(img *image.RGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		// colors are premultiplied by alpha, so white shows through in proportion
+		white := 255 - img.Pix[i+3]
+		img.Pix[i] += white
+		img.Pix[i+1] += white
+		img.Pix[i+2] += white
+		img.Pix[i+3] = 255
+	}
+}
+
+// orient turns an image the way its EXIF orientation says it's meant to be seen.
+func orient(src *image.RGBA, o int) *image.RGBA {
+	if o < 2 || o > 8 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if o >= 5 { // turned a quarter
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				dx, dy = x, h-1-y
+			case 5: // mirrored, turned left
+				dx, dy = y, x
+			case 6: // turned left
+				dx, dy = h-1-y, x
+			case 7: // mirrored, turned right
+				dx, dy = h-1-y, w-1-x
+			case 8: // turned right
+				dx, dy = y, w-1-x
+			}
+			from := y*src.Stride + x*4 // src may be cropped, not starting at 0, 0
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[from:from+4])
+		}
+	}
+	return dst
+}
+
+// orientation reads the EXIF orientation of a JPEG, 1 when it has none: 2 to 8 say how it was flipped and turned.
+func orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+
+	// segments up to the image data: marker, length, payload
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		length := int(data[i+2])<<8 | int(data[i+3])
+		if marker == 0xda || length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds the orientation tag in the first directory of EXIF's TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var u16 func([]byte) int
+	var u32 func([]byte) int
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		u16 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 }
+		u32 = func(b []byte) int { return u16(b) | u16(b[2:])<<16 }
+	case "MM\x00*":
+		u16 = func(b []byte) int { return int(b[0])<<8 | int(b[1]) }
+		u32 = func(b []byte) int { return u16(b)<<16 | u16(b[2:]) }
+	default:
+		return 1
+	}
+
+	ifd := u32(tiff[4:])
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := u16(tiff[ifd:])
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if u16(tiff[entry:]) == 0x0112 {
+			return u16(tiff[entry+8:])
+		}
+	}
+	return 1
+}
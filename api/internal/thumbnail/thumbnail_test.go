@@ -0,0 +1,185 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupports(t *testing.T) {
+	assert.True(t, Supports("image/png"))
+	assert.True(t, Supports("IMAGE/JPEG"))
+	assert.True(t, Supports("application/pdf; charset=binary"))
+	assert.False(t, Supports("image/webp"))
+	assert.False(t, Supports("text/plain"))
+}
+
+func TestMake(t *testing.T) {
+	t.Run("Sizes", func(t *testing.T) {
+		thumbnails, err := Make("image/jpeg", encodeJPEG(t, fill(2400, 1200, color.RGBA{R: 200, A: 255})))
+		require.NoError(t, err)
+		require.Len(t, thumbnails, 3)
+
+		for size, want := range map[models.ThumbnailSize]image.Point{
+			models.LargeThumbnail:  {1200, 600},
+			models.MediumThumbnail: {480, 240},
+			models.SmallThumbnail:  {160, 80},
+		} {
+			img := decodeJPEG(t, thumbnails[size])
+			assert.Equal(t, want, img.Bounds().Size(), size)
+		}
+	})
+
+	t.Run("NotEnlarged", func(t *testing.T) {
+		thumbnails, err := Make("image/png", encodePNG(t, fill(300, 100, color.RGBA{G: 255, A: 255})))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(300, 100), decodeJPEG(t, thumbnails[models.LargeThumbnail]).Bounds().Size())
+		assert.Equal(t, image.Pt(300, 100), decodeJPEG(t, thumbnails[models.MediumThumbnail]).Bounds().Size())
+		assert.Equal(t, image.Pt(160, 53), decodeJPEG(t, thumbnails[models.SmallThumbnail]).Bounds().Size())
+	})
+
+	t.Run("Transparent", func(t *testing.T) {
+		thumbnails, err := Make("image/png", encodePNG(t, fill(10, 10, color.RGBA{})))
+		require.NoError(t, err)
+		r, g, b, _ := decodeJPEG(t, thumbnails[models.SmallThumbnail]).At(5, 5).RGBA()
+		assert.Greater(t, r>>8, uint32(240))
+		assert.Greater(t, g>>8, uint32(240))
+		assert.Greater(t, b>>8, uint32(240))
+	})
+
+	t.Run("Oriented", func(t *testing.T) {
+		// turned left: what's on top is meant to be on the right
+		img := fill(40, 20, color.RGBA{B: 255, A: 255})
+		for x := 0; x < 40; x++ {
+			for y := 0; y < 5; y++ {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			}
+		}
+		thumbnails, err := Make("image/jpeg", withOrientation(encodeJPEG(t, img), 6))
+		require.NoError(t, err)
+
+		out := decodeJPEG(t, thumbnails[models.SmallThumbnail])
+		assert.Equal(t, image.Pt(20, 40), out.Bounds().Size())
+		r, _, b, _ := out.At(18, 20).RGBA()
+		assert.Greater(t, r, b)
+		r, _, b, _ = out.At(2, 20).RGBA()
+		assert.Greater(t, b, r)
+	})
+
+	t.Run("PDF", func(t *testing.T) {
+		logo := encodeJPEG(t, fill(50, 50, color.RGBA{A: 255}))
+		page := encodeJPEG(t, fill(600, 800, color.RGBA{R: 255, G: 255, B: 255, A: 255}))
+		thumbnails, err := Make("application/pdf", pdf(logo, page))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(360, 480), decodeJPEG(t, thumbnails[models.MediumThumbnail]).Bounds().Size())
+
+		_, err = Make("application/pdf", pdf(logo))
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		_, err := Make("image/webp", []byte("RIFF"))
+		assert.ErrorIs(t, err, ErrUnsupported)
+
+		_, err = Make("image/png", []byte("not an image"))
+		assert.Error(t, err)
+
+		// a header claiming more pixels than are decoded
+		bomb := encodePNG(t, fill(1, 1, color.RGBA{A: 255}))
+		copy(bomb[16:24], []byte{0, 0, 0x40, 0, 0, 0, 0x40, 0})
+		binary.BigEndian.PutUint32(bomb[29:33], crc32.ChecksumIEEE(bomb[12:29]))
+		_, err = Make("image/png", bomb)
+		assert.ErrorContains(t, err, "larger than")
+	})
+}
+
+func TestAvatar(t *testing.T) {
+	// a wide photo, red at the sides, with where it was taken
+	img := fill(900, 600, color.RGBA{B: 255, A: 255})
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 150; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+			img.Set(899-x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	avatars, err := Avatar(withOrientation(encodeJPEG(t, img), 1), 512, 128)
+	require.NoError(t, err)
+	require.Len(t, avatars, 2)
+
+	for i, side := range []int{512, 128} {
+		assert.NotContains(t, string(avatars[i]), "Exif")
+		out := decodeJPEG(t, avatars[i])
+		assert.Equal(t, image.Pt(side, side), out.Bounds().Size())
+		r, _, b, _ := out.At(2, side/2).RGBA()
+		assert.Greater(t, b, r, "cropped to the middle")
+	}
+
+	// small ones aren't enlarged
+	avatars, err = Avatar(encodePNG(t, fill(64, 80, color.RGBA{A: 255})), 512)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(64, 64), decodeJPEG(t, avatars[0]).Bounds().Size())
+
+	_, err = Avatar([]byte("RIFF....WEBP"), 512)
+	assert.Error(t, err)
+}
+
+func fill(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var b bytes.Buffer
+	require.NoError(t, jpeg.Encode(&b, img, nil))
+	return b.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, img))
+	return b.Bytes()
+}
+
+func decodeJPEG(t *testing.T, data []byte) image.Image {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+// withOrientation adds an EXIF segment with the orientation, big-endian, after the JPEG's start.
+func withOrientation(data []byte, o byte) []byte {
+	tiff := []byte{'M', 'M', 0, '*', 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, o, 0, 0, 0, 0, 0, 0}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// pdf builds a PDF embedding JPEG images.
+func pdf(images ...[]byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	b.WriteString("4 0 obj << /Length 8 /Filter /FlateDecode >>\nstream\nxxxxxxxx\nendstream\nendobj\n")
+	for i, img := range images {
+		fmt.Fprintf(&b, "%d 0 obj << /Type /XObject /Subtype /Image /Filter /DCTDecode /Length %d >>\nstream\n", 5+i, len(img))
+		b.Write(img)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
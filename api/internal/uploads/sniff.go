@@ -0,0 +1,93 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SniffLen is how many of a file's first bytes Sniff looks at.
+const SniffLen = 512
+
+// signed are types the sniffer recognizes by their magic number, which files claiming them have to start with.
+var signed = map[string]bool{
+	"application/pdf":  true,
+	"application/zip":  true,
+	"application/gzip": true,
+	"image/png":        true,
+	"image/jpeg":       true,
+	"image/gif":        true,
+	"image/webp":       true,
+	"image/bmp":        true,
+}
+
+// Sniff tells why the first bytes of a file give away that it isn't of the content type it was uploaded as,
+// if they do: executables never pass, files of a signed type have to start with its magic number,
+// text has to be text and Office documents zip archives. Types the sniffer knows nothing about pass.
+func Sniff(contentType string, head []byte) error {
+	declared, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q", contentType)
+	}
+	if executable(head) {
+		return fmt.Errorf("the file is an executable, not %s", declared)
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if matches(declared, sniffed) {
+		return nil
+	}
+	return fmt.Errorf("the file's content is %s, not %s", sniffed, declared)
+}
+
+func matches(declared string, sniffed string) bool {
+	switch {
+	case declared == sniffed:
+		return true
+	case sniffed == "application/octet-stream": // nothing recognized
+		return !signed[declared] && !textual(declared) && !zipped(declared)
+	case strings.HasPrefix(sniffed, "text/"):
+		return textual(declared)
+	case sniffed == "application/zip":
+		return zipped(declared)
+	case sniffed == "application/x-gzip":
+		return declared == "application/gzip"
+	default:
+		return false
+	}
+}
+
+// textual types are text, which the sniffer tells from binary data.
+func textual(t string) bool {
+	return strings.HasPrefix(t, "text/") ||
+		t == "application/json" || t == "application/xml" ||
+		strings.HasSuffix(t, "+json") || strings.HasSuffix(t, "+xml")
+}
+
+// zipped types are zip archives underneath, like Office Open XML and OpenDocument files.
+func zipped(t string) bool {
+	return t == "application/zip" || strings.HasSuffix(t, "+zip") ||
+		strings.HasPrefix(t, "application/vnd.openxmlformats-officedocument.") ||
+		strings.HasPrefix(t, "application/vnd.oasis.opendocument.")
+}
+
+// executable tells Windows, Linux and macOS binaries by their headers.
+func executable(head []byte) bool {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return true
+	case bytes.HasPrefix(head, []byte("MZ")) && len(head) >= 0x40:
+		// the DOS stub points to the PE header
+		offset := int(binary.LittleEndian.Uint32(head[0x3c:]))
+		return offset+4 <= len(head) && bytes.Equal(head[offset:offset+4], []byte("PE\x00\x00"))
+	case len(head) >= 4:
+		switch binary.BigEndian.Uint32(head) {
+		case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe: // Mach-O
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Package uploads limits the size and content type of files clients upload, per kind of upload,
+// and the storage their attachments may take, per plan.
+package uploads
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// Kind is what a file is uploaded as, named after the storage prefix it goes under.
+type Kind string
+
+const (
+	Attachments Kind = "attachments"
+	Avatars     Kind = "avatars"
+)
+
+// Limit is how large a kind of upload may be and which content types it may have.
+type Limit struct {
+	MaxSize          int64    // of a file uploaded in one go
+	MaxMultipartSize int64    // of a file uploaded in parts, none may be when 0
+	Types            []string // "image/*" allows a whole family, "*" anything
+}
+
+// limits are replaced by Init with the configured ones.
+var limits = map[Kind]Limit{
+	Attachments: {MaxSize: 10 * 1024 * 1024, MaxMultipartSize: 1024 * 1024 * 1024, Types: []string{"*"}},
+	Avatars:     {MaxSize: 5 * 1024 * 1024, Types: []string{"image/*"}},
+}
+
+// quotas are how many bytes of attachments users on each plan may keep, replaced by Init.
+var quotas = map[models.Plan]int64{
+	models.FreePlan: 100 * 1024 * 1024,
+	models.ProPlan:  10 * 1024 * 1024 * 1024,
+}
+
+// Init sets the limits of each kind of upload and the quota of each plan from config.
+func Init(cfg config.UploadConfig) {
+	limits = map[Kind]Limit{
+		Attachments: {
+			MaxSize:          int64(cfg.AttachmentMaxSize),
+			MaxMultipartSize: int64(cfg.AttachmentMultipartMaxSize),
+			Types:            split(cfg.AttachmentTypes),
+		},
+		Avatars: {MaxSize: int64(cfg.AvatarMaxSize), Types: split(cfg.AvatarTypes)},
+	}
+	quotas = map[models.Plan]int64{
+		models.FreePlan: int64(cfg.FreeQuota),
+		models.ProPlan:  int64(cfg.ProQuota),
+	}
+}
+
+// Quota is how many bytes of attachments a user on the plan may keep. Unknown plans get the free quota.
+func Quota(plan models.Plan) int64 {
+	if quota, ok := quotas[plan]; ok {
+		return quota
+	}
+	return quotas[models.FreePlan]
+}
+
+func split(types string) []string {
+	var out []string
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// MaxSize is the largest file of a kind uploaded in one go, in bytes.
+func MaxSize(kind Kind) int64 {
+	return limits[kind].MaxSize
+}
+
+// Allows tells whether a content type, parameters aside, is one of the limit's types.
+func (l Limit) Allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range l.Types {
+		if family, ok := strings.CutSuffix(t, "*"); ok && strings.HasPrefix(mediaType, family) {
+			return true
+		}
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Check tells why a file of the content type and size can't be uploaded as kind, if it can't, either in one go or in parts.
+func Check(kind Kind, contentType string, size int64) error {
+	limit := limits[kind]
+	return check(limit, max(limit.MaxSize, limit.MaxMultipartSize), contentType, size)
+}
+
+func check(limit Limit, maxSize int64, contentType string, size int64) error {
+	if !limit.Allows(contentType) {
+		return fmt.Errorf("content type %q is not allowed, expected one of %s", contentType, strings.Join(limit.Types, ", "))
+	}
+	if size <= 0 {
+		return fmt.Errorf("size has to be positive")
+	}
+	if size > maxSize {
+		return &SizeError{Size: size, Limit: maxSize}
+	}
+	return nil
+}
+
+// SizeError is a file over the size limit.
+type SizeError struct {
+	Size  int64
+	Limit int64
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("file is %d bytes, the limit is %d", e.Size, e.Limit)
+}
+
+// Policy checks the content type and size a client declared for an upload in one go against the limits of its kind,
+// and is what storage should accept for it: a file of exactly that type and size.
+func Policy(kind Kind, contentType string, size int64) (storage.UploadPolicy, error) {
+	limit := limits[kind]
+	if err := check(limit, limit.MaxSize, contentType, size); err != nil {
+		var tooLarge *SizeError
+		if errors.As(err, &tooLarge) && size <= limit.MaxMultipartSize {
+			return storage.UploadPolicy{}, fmt.Errorf("%w, larger files are uploaded in parts", err)
+		}
+		return storage.UploadPolicy{}, err
+	}
+	return storage.UploadPolicy{ContentType: contentType, MinSize: size, MaxSize: size}, nil
+}
+
+// MultipartPolicy is Policy for an upload in parts, which may be larger.
+func MultipartPolicy(kind Kind, contentType string, size int64) (storage.UploadPolicy, error) {
+	limit := limits[kind]
+	if err := check(limit, limit.MaxMultipartSize, contentType, size); err != nil {
+		return storage.UploadPolicy{}, err
+	}
+	return storage.UploadPolicy{ContentType: contentType, MinSize: size, MaxSize: size}, nil
+}
+
+// partSize is what files uploaded in parts are split into, unless they'd need more than storage.MaxParts.
+const partSize = 8 * 1024 * 1024
+
+// Parts is how large the parts of a file uploaded in parts are, all but the last, and how many there are.
+func Parts(size int64) (int64, int) {
+	part := max(partSize, (size+storage.MaxParts-1)/storage.MaxParts)
+	return part, int((size + part - 1) / part)
+}
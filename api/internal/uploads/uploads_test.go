@@ -0,0 +1,156 @@
+package uploads
+
+import (
+	"testing"
+	"vault/internal/config"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	Init(config.UploadConfig{
+		AttachmentMaxSize:          1024,
+		AttachmentMultipartMaxSize: 4096,
+		AttachmentTypes:            "application/pdf, text/plain,image/*",
+		AvatarMaxSize:              100,
+		AvatarTypes:                "image/png",
+		FreeQuota:                  1000,
+		ProQuota:                   5000,
+	})
+
+	t.Run("Allows", func(t *testing.T) {
+		policy, err := Policy(Attachments, "text/plain; charset=utf-8", 512)
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain; charset=utf-8", policy.ContentType)
+		assert.Equal(t, int64(512), policy.MinSize)
+		assert.Equal(t, int64(512), policy.MaxSize)
+
+		_, err = Policy(Attachments, "image/webp", 1024)
+		assert.NoError(t, err)
+		_, err = Policy(Avatars, "image/png", 100)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		for _, c := range []struct {
+			kind        Kind
+			contentType string
+			size        int64
+		}{
+			{Attachments, "text/html", 10},
+			{Attachments, "imagery/png", 10},
+			{Attachments, "not a type", 10},
+			{Attachments, "application/pdf", 1025},
+			{Attachments, "application/pdf", 0},
+			{Avatars, "image/jpeg", 10},
+			{Avatars, "image/png", 101},
+		} {
+			_, err := Policy(c.kind, c.contentType, c.size)
+			assert.Error(t, err, c)
+		}
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		_, err := Policy(Attachments, "application/pdf", 2048)
+		assert.ErrorContains(t, err, "uploaded in parts")
+		var tooLarge *SizeError
+		assert.ErrorAs(t, err, &tooLarge)
+
+		// only size is helped by uploading in parts
+		_, err = Policy(Attachments, "text/html", 2048)
+		assert.ErrorContains(t, err, "not allowed")
+		assert.NotContains(t, err.Error(), "uploaded in parts")
+		_, err = Policy(Attachments, "text/html", 10)
+		assert.NotContains(t, err.Error(), "uploaded in parts")
+		_, err = Policy(Attachments, "application/pdf", 0)
+		assert.NotContains(t, err.Error(), "uploaded in parts")
+
+		policy, err := MultipartPolicy(Attachments, "application/pdf", 2048)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2048), policy.MaxSize)
+
+		_, err = MultipartPolicy(Attachments, "application/pdf", 4097)
+		assert.Error(t, err)
+		_, err = MultipartPolicy(Avatars, "image/png", 10)
+		assert.Error(t, err)
+
+		// ingest takes files uploaded either way
+		assert.NoError(t, Check(Attachments, "application/pdf", 4096))
+		assert.Error(t, Check(Attachments, "application/pdf", 4097))
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		assert.Equal(t, int64(1000), Quota(models.FreePlan))
+		assert.Equal(t, int64(5000), Quota(models.ProPlan))
+		assert.Equal(t, int64(1000), Quota("enterprise"))
+	})
+}
+
+func TestParts(t *testing.T) {
+	for _, c := range []struct {
+		size     int64
+		partSize int64
+		parts    int
+	}{
+		{1, partSize, 1},
+		{partSize, partSize, 1},
+		{partSize + 1, partSize, 2},
+		{100 * 1024 * 1024, partSize, 13},
+		{10000 * 10 * 1024 * 1024, 10 * 1024 * 1024, 10000},
+		{10000*10*1024*1024 + 1, 10*1024*1024 + 1, 10000},
+	} {
+		size, parts := Parts(c.size)
+		assert.Equal(t, c.partSize, size, c.size)
+		assert.Equal(t, c.parts, parts, c.size)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x06\x00")
+	pe := make([]byte, 0x100)
+	copy(pe, "MZ")
+	pe[0x3c] = 0x80
+	copy(pe[0x80:], "PE\x00\x00")
+
+	t.Run("Matches", func(t *testing.T) {
+		for _, c := range []struct {
+			contentType string
+			head        []byte
+		}{
+			{"image/png", png},
+			{"text/plain; charset=utf-8", []byte("staging keys")},
+			{"text/markdown", []byte("<!-- draft -->\n# Keys")},
+			{"application/json", []byte(`{"keys": []}`)},
+			{"application/pdf", []byte("%PDF-1.7\n")},
+			{"application/zip", zip},
+			{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", zip},
+			{"application/msword", []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1\x00")},
+			{"text/plain", []byte("MZ is where it starts")},
+		} {
+			assert.NoError(t, Sniff(c.contentType, c.head), c.contentType)
+		}
+	})
+
+	t.Run("Gives away", func(t *testing.T) {
+		for _, c := range []struct {
+			contentType string
+			head        []byte
+		}{
+			{"image/png", pe},
+			{"application/msword", pe},
+			{"image/png", []byte("\x7fELF\x02\x01\x01")},
+			{"application/octet-stream", []byte("\xcf\xfa\xed\xfe\x07\x00")},
+			{"image/png", []byte("\xff\xd8\xff\xe0\x00\x10JFIF")},
+			{"image/png", []byte("staging keys")},
+			{"text/plain", []byte("\x00\x01\x02\x03binary")},
+			{"application/pdf", zip},
+			{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", []byte("staging keys")},
+			{"not a type", png},
+		} {
+			assert.Error(t, Sniff(c.contentType, c.head), c.contentType)
+		}
+	})
+}
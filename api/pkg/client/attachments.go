@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentsQuery filters GetAttachments. Zero values are left out of the request.
+type AttachmentsQuery struct {
+	Cursor    string // next_cursor of the previous page
+	Page      int    // Deprecated: use Cursor
+	Limit     int
+	Deleted   *bool
+	MimeType  string
+	NoteID    uuid.UUID
+	Sort      string // "asc" or "desc"
+	SkipCount bool   // leaves Total at -1, saving the server a count
+}
+
+func (q AttachmentsQuery) values() url.Values {
+	v := url.Values{}
+	setString(v, "cursor", q.Cursor)
+	setInt(v, "page", q.Page)
+	setInt(v, "limit", q.Limit)
+	setBool(v, "deleted", q.Deleted)
+	setString(v, "mime_type", q.MimeType)
+	if q.NoteID != uuid.Nil {
+		v.Set("note_id", q.NoteID.String())
+	}
+	setString(v, "sort", q.Sort)
+	skipCount(v, q.SkipCount)
+	return v
+}
+
+func (c *Client) GetAttachments(ctx context.Context, q AttachmentsQuery) (*AttachmentResponse, error) {
+	var out AttachmentResponse
+	if err := c.get(ctx, "/notes/attachments", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Attachments iterates over all attachments matching q, fetching pages as needed, starting from q.Cursor.
+func (c *Client) Attachments(ctx context.Context, q AttachmentsQuery) iter.Seq2[AttachmentRef, error] {
+	return follow(q.Cursor, func(cursor string) ([]AttachmentRef, string, error) {
+		q.Cursor = cursor
+		resp, err := c.GetAttachments(ctx, q)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Attachments, resp.NextCursor, nil
+	})
+}
+
+// GetUploadURL returns a presigned URL to upload an attachment to a note.
+func (c *Client) GetUploadURL(ctx context.Context, noteID uuid.UUID, in PresignUploadRequest) (*PresignUploadResponse, error) {
+	var out PresignUploadResponse
+	if err := c.post(ctx, notePath(noteID)+"/attachments", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Upload sends a file of size bytes to a presigned upload form, e.g. from GetUploadURL.
+// Storage refuses files of another size or content type than the form was presigned for.
+func (c *Client) Upload(ctx context.Context, form *PresignUploadResponse, fileName string, file io.Reader, size int64) error {
+	var head bytes.Buffer
+	writer := multipart.NewWriter(&head)
+
+	names := make([]string, 0, len(form.Fields))
+	for name := range form.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writer.WriteField(name, form.Fields[name]); err != nil {
+			return err
+		}
+	}
+	// the file goes last, storage ignores fields after it
+	if _, err := writer.CreateFormFile("file", fileName); err != nil {
+		return err
+	}
+	tail := "\r\n--" + writer.Boundary() + "--\r\n"
+
+	body := io.MultiReader(&head, file, strings.NewReader(tail))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, form.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	// storage wants the length up front, which streaming the file would otherwise hide
+	req.ContentLength = int64(head.Len()) + size + int64(len(tail))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload failed: %s: %s", resp.Status, message)
+	}
+	return nil
+}
+
+// CreateMultipartUpload starts uploading a large attachment to a note in parts, see UploadParts.
+func (c *Client) CreateMultipartUpload(ctx context.Context, noteID uuid.UUID, in PresignUploadRequest) (*MultipartUploadResponse, error) {
+	var out MultipartUploadResponse
+	if err := c.post(ctx, notePath(noteID)+"/attachments/multipart", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PresignParts returns URLs to PUT parts of a multipart upload to, by number.
+func (c *Client) PresignParts(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID, parts []int) (*PresignPartsResponse, error) {
+	var out PresignPartsResponse
+	in := PresignPartsRequest{Parts: parts}
+	if err := c.post(ctx, attachmentPath(noteID, attachmentID)+"/parts", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUploadedParts returns the parts of a multipart upload that arrived and how the file is split into parts.
+func (c *Client) GetUploadedParts(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID) (*UploadedPartsResponse, error) {
+	var out UploadedPartsResponse
+	if err := c.get(ctx, attachmentPath(noteID, attachmentID)+"/parts", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadParts uploads the parts of a multipart upload of file, size bytes, that haven't arrived yet, workers at a time.
+// Calling it again after it failed resumes the upload, CompleteUpload then finishes it.
+func (c *Client) UploadParts(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID, file io.ReaderAt, size int64, workers int) error {
+	uploaded, err := c.GetUploadedParts(ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	workers = max(workers, 1)
+
+	// parts are all of PartSize but the last, which is checked when the upload is completed
+	arrived := make(map[int]bool, len(uploaded.Parts))
+	for _, p := range uploaded.Parts {
+		arrived[p.Number] = p.Number == uploaded.Total || p.Size == uploaded.PartSize
+	}
+	var missing []int
+	for n := 1; n <= uploaded.Total; n++ {
+		if !arrived[n] {
+			missing = append(missing, n)
+		}
+	}
+
+	// URLs are presigned as parts are about to go, so they don't expire waiting
+	for len(missing) > 0 {
+		batch := missing[:min(workers, len(missing))]
+		missing = missing[len(batch):]
+
+		presigned, err := c.PresignParts(ctx, noteID, attachmentID, batch)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, len(presigned.Parts))
+		for i, part := range presigned.Parts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				offset := int64(part.Number-1) * uploaded.PartSize
+				length := min(uploaded.PartSize, size-offset)
+				if errs[i] = c.putPart(ctx, part.URL, io.NewSectionReader(file, offset, length), length); errs[i] != nil {
+					errs[i] = fmt.Errorf("part %d: %w", part.Number, errs[i])
+				}
+			}()
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putPart uploads a part of size bytes to a presigned URL.
+func (c *Client) putPart(ctx context.Context, url string, body io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload failed: %s: %s", resp.Status, message)
+	}
+	return nil
+}
+
+// GetDownloadURL returns where an attachment's upload stands, with a presigned URL to download it once it's ready.
+func (c *Client) GetDownloadURL(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID) (*PresignDownloadResponse, error) {
+	var out PresignDownloadResponse
+	if err := c.get(ctx, attachmentPath(noteID, attachmentID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompleteUpload confirms an attachment was uploaded. It's scanning until the file has been checked,
+// GetDownloadURL then tells whether it's ready or rejected.
+func (c *Client) CompleteUpload(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID) (*PresignDownloadResponse, error) {
+	var out PresignDownloadResponse
+	if err := c.post(ctx, attachmentPath(noteID, attachmentID)+"/complete", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) DeleteAttachment(ctx context.Context, noteID uuid.UUID, attachmentID uuid.UUID) error {
+	return c.delete(ctx, attachmentPath(noteID, attachmentID), nil)
+}
+
+func attachmentPath(noteID uuid.UUID, attachmentID uuid.UUID) string {
+	return fmt.Sprintf("/notes/%s/attachments/%s", noteID, attachmentID)
+}
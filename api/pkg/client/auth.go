@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// SignInWithFirebase exchanges a Firebase ID token for a Vault session, which the client then uses.
+func (c *Client) SignInWithFirebase(ctx context.Context, in FirebaseSignInRequest) (*LoginOut, error) {
+	var out LoginOut
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/firebase", body: in}, &out); err != nil {
+		return nil, err
+	}
+	c.setSession(out.Session)
+	return &out, nil
+}
+
+// Refresh exchanges the refresh token for a new session.
+// It's called automatically when a request is rejected as unauthorized.
+func (c *Client) Refresh(ctx context.Context) error {
+	body := map[string]string{"refresh_token": c.Session().Refresh}
+
+	var out Session
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/refresh", body: body}, &out); err != nil {
+		return err
+	}
+	c.setSession(out)
+	return nil
+}
+
+// Me returns the authenticated user.
+func (c *Client) Me(ctx context.Context) (*UserOut, error) {
+	var out UserOut
+	if err := c.get(ctx, "/me", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PresignAvatar returns an upload URL for a new avatar.
+func (c *Client) PresignAvatar(ctx context.Context, in PresignUploadRequest) (*PresignUploadResponse, error) {
+	var out PresignUploadResponse
+	if err := c.post(ctx, "/me/avatar", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PasswordHealth reports breached, reused and weak passwords among the user's login items.
+func (c *Client) PasswordHealth(ctx context.Context) (*PasswordHealthReport, error) {
+	var out PasswordHealthReport
+	if err := c.get(ctx, "/me/password-health", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Usage reports the storage the user's attachments take against their quota, with the largest limit notes.
+func (c *Client) Usage(ctx context.Context, limit int) (*UsageOut, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var out UsageOut
+	if err := c.get(ctx, "/me/usage", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GeneratePassword generates a random password on the server.
+func (c *Client) GeneratePassword(ctx context.Context, in PasswordRequest) (*GeneratedSecretOut, error) {
+	var out GeneratedSecretOut
+	if err := c.post(ctx, "/generate/password", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GeneratePassphrase generates a diceware passphrase on the server.
+func (c *Client) GeneratePassphrase(ctx context.Context, in PassphraseRequest) (*GeneratedSecretOut, error) {
+	var out GeneratedSecretOut
+	if err := c.post(ctx, "/generate/passphrase", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,265 @@
+// Package client is a Go client for the Vault API.
+//
+//	c := client.New("https://api.vault.example", client.WithSession(session))
+//	note, err := c.GetNote(ctx, noteID)
+//
+// Access tokens are refreshed automatically through /refresh when a request is rejected as unauthorized,
+// and idempotent requests are retried with exponential backoff on network errors and transient server errors.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client talks to a Vault API server. It is safe for concurrent use.
+type Client struct {
+	baseURL   string
+	http      *http.Client
+	retry     RetryPolicy
+	onSession func(Session)
+
+	mu      sync.Mutex
+	session Session
+}
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// WithSession authenticates the client with an existing session.
+func WithSession(s Session) Option {
+	return func(c *Client) {
+		c.session = s
+	}
+}
+
+// WithToken authenticates the client with an access token only, it won't be refreshed.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.session = Session{Token: token}
+	}
+}
+
+// WithRetry replaces the default retry policy.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// OnSession registers a callback invoked whenever the session changes, e.g. to persist refreshed tokens.
+func OnSession(f func(Session)) Option {
+	return func(c *Client) {
+		c.onSession = f
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+		retry:   DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Session returns the current session.
+func (c *Client) Session() Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+func (c *Client) setSession(s Session) {
+	c.mu.Lock()
+	c.session = s
+	c.mu.Unlock()
+
+	if c.onSession != nil {
+		c.onSession(s)
+	}
+}
+
+// request is a single API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	auth   bool
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	return c.do(ctx, request{method: http.MethodGet, path: path, query: query, auth: true}, out)
+}
+
+func (c *Client) post(ctx context.Context, path string, body any, out any) error {
+	return c.do(ctx, request{method: http.MethodPost, path: path, body: body, auth: true}, out)
+}
+
+func (c *Client) put(ctx context.Context, path string, body any, out any) error {
+	return c.do(ctx, request{method: http.MethodPut, path: path, body: body, auth: true}, out)
+}
+
+func (c *Client) delete(ctx context.Context, path string, query url.Values) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: path, query: query, auth: true}, nil)
+}
+
+// do sends a request, refreshing the session once on 401 and retrying transient failures,
+// and decodes a JSON response into out when it's not nil.
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && r.auth && c.Session().Refresh != "" {
+		_ = resp.Body.Close()
+		if err := c.Refresh(ctx); err != nil {
+			return err
+		}
+		if resp, err = c.send(ctx, r); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send performs a request with retries, returning the last response or error.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	var payload []byte
+	if r.body != nil {
+		var err error
+		if payload, err = json.Marshal(r.body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	attempts := max(c.retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, r, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(req)
+
+		if attempt >= attempts || !retryable(r.method, resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, r request, payload []byte) (*http.Request, error) {
+	u := c.baseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Session().Token; r.auth && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// retryable reports whether a request should be tried again.
+// POST isn't idempotent, so it's only retried when the server explicitly asks to slow down.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return method != http.MethodPost
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method != http.MethodPost
+	}
+	return false
+}
+
+// backoff returns an exponentially growing delay with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.retry.MaxDelay {
+		delay = c.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(delay)))
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vault/internal/models"
+	"vault/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	noteID := uuid.New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /refresh", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "old-refresh", body["refresh_token"])
+		_ = json.NewEncoder(w).Encode(models.Session{Token: "new-token", Refresh: "new-refresh"})
+	})
+	mux.HandleFunc("GET /notes/{noteId}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Invalid token"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.NoteOut{ID: noteID, Title: "Refreshed"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var saved models.Session
+	c := New(
+		server.URL,
+		WithSession(models.Session{Token: "expired", Refresh: "old-refresh"}),
+		OnSession(func(s models.Session) { saved = s }),
+	)
+
+	note, err := c.GetNote(context.Background(), noteID)
+	require.NoError(t, err)
+	assert.Equal(t, "Refreshed", note.Title)
+	assert.Equal(t, "new-token", c.Session().Token)
+	assert.Equal(t, "new-refresh", saved.Refresh)
+}
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.UserOut{Username: "jane"})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithToken("token"), WithRetry(fastRetry))
+
+	user, err := c.Me(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "jane", user.Username)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// POST is not retried on server errors
+	calls.Store(0)
+	_, err = c.CreateNote(context.Background(), models.NoteIn{Title: "t", Content: "c"})
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notes/attachments":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"You do not have access to this note","code":"Forbidden"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Note not found","code":"NotFound"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithToken("token"))
+
+	_, err := c.GetNote(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, "Note not found", apiErr.Message)
+
+	_, err = c.GetAttachments(context.Background(), AttachmentsQuery{})
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestNotesIterator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		resp := models.NotesResponse{Notes: []models.NoteOut{{Title: "a"}, {Title: "b"}}, Total: 4}
+		if r.URL.Query().Get("cursor") == "" {
+			resp.NextCursor = "next"
+		} else {
+			assert.Equal(t, "next", r.URL.Query().Get("cursor"))
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithToken("token"))
+
+	var titles []string
+	for note, err := range c.Notes(context.Background(), NotesQuery{Limit: 2}) {
+		require.NoError(t, err)
+		titles = append(titles, note.Title)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b"}, titles)
+}
+
+func TestUpload(t *testing.T) {
+	var local *storage.Local
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Positive(t, r.ContentLength)
+		local.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	local, err := storage.NewLocal(t.TempDir(), server.URL, []byte("secret"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := "attachments/note/keys.txt"
+	form, err := local.PresignPost(ctx, key, storage.UploadPolicy{ContentType: "text/plain", MinSize: 12, MaxSize: 12})
+	require.NoError(t, err)
+	presigned := &models.PresignUploadResponse{URL: form.URL, Fields: form.Fields, Key: key}
+
+	c := New(server.URL)
+	require.NoError(t, c.Upload(ctx, presigned, "keys.txt", strings.NewReader("staging keys"), 12))
+
+	object, err := local.Head(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), object.Size)
+	assert.Equal(t, "text/plain", object.ContentType)
+
+	err = c.Upload(ctx, presigned, "keys.txt", strings.NewReader("production keys"), 15)
+	assert.ErrorContains(t, err, "400")
+}
+
+func TestUploadParts(t *testing.T) {
+	ctx := context.Background()
+	noteID, attachmentID := uuid.New(), uuid.New()
+	key := "attachments/note/backup.tar"
+	file := "hello world!"
+
+	var local *storage.Local
+	var uploadID string
+	var puts atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /notes/{noteId}/attachments/{attachmentId}/parts", func(w http.ResponseWriter, r *http.Request) {
+		parts, err := local.ListParts(ctx, key, uploadID)
+		require.NoError(t, err)
+		out := models.UploadedPartsResponse{PartSize: 5, Total: 3, Parts: []models.UploadedPart{}}
+		for _, p := range parts {
+			out.Parts = append(out.Parts, models.UploadedPart{Number: p.Number, Size: p.Size, ETag: p.ETag})
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("POST /notes/{noteId}/attachments/{attachmentId}/parts", func(w http.ResponseWriter, r *http.Request) {
+		var in models.PresignPartsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		out := models.PresignPartsResponse{}
+		for _, n := range in.Parts {
+			url, err := local.PresignPart(ctx, key, uploadID, n)
+			require.NoError(t, err)
+			out.Parts = append(out.Parts, models.PartURL{Number: n, URL: url})
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc(storage.LocalPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		// the second part fails the first time
+		if puts.Add(1) <= 3 && r.URL.Query().Get("partNumber") == "2" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		local.ServeHTTP(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	local, err := storage.NewLocal(t.TempDir(), server.URL, []byte("secret"))
+	require.NoError(t, err)
+	uploadID, err = local.CreateMultipart(ctx, key, "application/x-tar")
+	require.NoError(t, err)
+
+	c := New(server.URL)
+	err = c.UploadParts(ctx, noteID, attachmentID, strings.NewReader(file), int64(len(file)), 4)
+	assert.ErrorContains(t, err, "part 2")
+	assert.Equal(t, int32(3), puts.Load())
+
+	require.NoError(t, c.UploadParts(ctx, noteID, attachmentID, strings.NewReader(file), int64(len(file)), 4))
+	assert.Equal(t, int32(4), puts.Load(), "only the failed part is uploaded again")
+
+	parts, err := local.ListParts(ctx, key, uploadID)
+	require.NoError(t, err)
+	require.NoError(t, local.CompleteMultipart(ctx, key, uploadID, parts))
+
+	body, err := local.Open(ctx, key)
+	require.NoError(t, err)
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, file, string(data))
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors matching the codes the API puts in error responses, use with errors.Is:
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+var (
+	ErrValidation   = errors.New("ValidationError")
+	ErrUnauthorized = errors.New("Unauthorized")
+	ErrForbidden    = errors.New("Forbidden")
+	ErrNotFound     = errors.New("NotFound")
+	ErrServer       = errors.New("ServerError")
+)
+
+var codes = map[string]error{
+	ErrValidation.Error():   ErrValidation,
+	ErrUnauthorized.Error(): ErrUnauthorized,
+	ErrForbidden.Error():    ErrForbidden,
+	ErrNotFound.Error():     ErrNotFound,
+	ErrServer.Error():       ErrServer,
+}
+
+// Error is an error response returned by the API.
+type Error struct {
+	Status  int            `json:"-"`
+	Message string         `json:"error"`
+	Code    string         `json:"code,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s (%d)", e.Code, e.Message, e.Status)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Status)
+}
+
+// Is matches the sentinel error for the response's code,
+// falling back to the status code for responses without one (e.g. from middleware).
+func (e *Error) Is(target error) bool {
+	if sentinel, ok := codes[e.Code]; ok {
+		return sentinel == target
+	}
+	switch e.Status {
+	case http.StatusBadRequest:
+		return target == ErrValidation
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	}
+	return e.Status >= http.StatusInternalServerError && target == ErrServer
+}
+
+func decodeError(resp *http.Response) error {
+	e := &Error{Status: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, e); err != nil || e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
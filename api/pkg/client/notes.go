@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// NotesQuery filters GetNotes. Zero values are left out of the request.
+type NotesQuery struct {
+	Cursor    string // next_cursor of the previous page
+	Page      int    // Deprecated: use Cursor
+	Limit     int
+	Q         string
+	Archived  *bool
+	Encrypted *bool
+	Sort      string // newest, oldest, updated, title or relevance
+	SkipCount bool   // leaves Total at -1, saving the server a count
+}
+
+func (q NotesQuery) values() url.Values {
+	v := url.Values{}
+	setString(v, "cursor", q.Cursor)
+	setInt(v, "page", q.Page)
+	setInt(v, "limit", q.Limit)
+	setString(v, "q", q.Q)
+	setBool(v, "archived", q.Archived)
+	setBool(v, "encrypted", q.Encrypted)
+	setString(v, "sort", q.Sort)
+	skipCount(v, q.SkipCount)
+	return v
+}
+
+// SearchQuery holds the parameters of SearchNotes, Q being required.
+type SearchQuery struct {
+	Page      int
+	Limit     int
+	Q         string
+	Archived  *bool
+	Encrypted *bool
+	Fuzzy     bool   // fall back to similar notes when nothing matches
+	Scope     string // "all" (default), "mine" or "shared"
+	Sort      string // "relevance" (default), "newest", "oldest", "updated" or "title"
+	Mode      string // "keyword" (default) or "semantic"
+}
+
+func (q SearchQuery) values() url.Values {
+	v := NotesQuery{Page: q.Page, Limit: q.Limit, Q: q.Q, Archived: q.Archived, Encrypted: q.Encrypted}.values()
+	if q.Fuzzy {
+		v.Set("fuzzy", "true")
+	}
+	setString(v, "scope", q.Scope)
+	setString(v, "sort", q.Sort)
+	setString(v, "mode", q.Mode)
+	return v
+}
+
+// PageQuery pages through GetDeletedNotes and SharedWithMe with Cursor,
+// and through ranked results like RelatedNotes with Page.
+type PageQuery struct {
+	Cursor    string
+	Page      int
+	Limit     int
+	Sort      string // newest, oldest, updated or title
+	SkipCount bool   // leaves Total at -1, saving the server a count
+}
+
+func (q PageQuery) values() url.Values {
+	v := url.Values{}
+	setString(v, "cursor", q.Cursor)
+	setInt(v, "page", q.Page)
+	setInt(v, "limit", q.Limit)
+	setString(v, "sort", q.Sort)
+	skipCount(v, q.SkipCount)
+	return v
+}
+
+func (c *Client) GetNotes(ctx context.Context, q NotesQuery) (*NotesResponse, error) {
+	var out NotesResponse
+	if err := c.get(ctx, "/notes", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Notes iterates over all notes matching q, fetching pages as needed, starting from q.Cursor.
+func (c *Client) Notes(ctx context.Context, q NotesQuery) iter.Seq2[NoteOut, error] {
+	return follow(q.Cursor, func(cursor string) ([]NoteOut, string, error) {
+		q.Cursor = cursor
+		resp, err := c.GetNotes(ctx, q)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Notes, resp.NextCursor, nil
+	})
+}
+
+func (c *Client) CreateNote(ctx context.Context, in NoteIn) (*NoteOut, error) {
+	var out NoteOut
+	if err := c.post(ctx, "/notes", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetNote(ctx context.Context, noteID uuid.UUID) (*NoteOut, error) {
+	var out NoteOut
+	if err := c.get(ctx, notePath(noteID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) EditNote(ctx context.Context, noteID uuid.UUID, in NoteIn) (*NoteOut, error) {
+	var out NoteOut
+	if err := c.put(ctx, notePath(noteID), in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteNote moves a note to the bin, or deletes it for good when hard is set.
+func (c *Client) DeleteNote(ctx context.Context, noteID uuid.UUID, hard bool) error {
+	var q url.Values
+	if hard {
+		q = url.Values{"hard": {"true"}}
+	}
+	return c.delete(ctx, notePath(noteID), q)
+}
+
+func (c *Client) RestoreNote(ctx context.Context, noteID uuid.UUID) (*NoteOut, error) {
+	var out NoteOut
+	if err := c.post(ctx, notePath(noteID)+"/restore", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetDeletedNotes(ctx context.Context, q PageQuery) (*NotesResponse, error) {
+	var out NotesResponse
+	if err := c.get(ctx, "/notes/deleted", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) SharedWithMe(ctx context.Context, q PageQuery) (*NotesResponse, error) {
+	var out NotesResponse
+	if err := c.get(ctx, "/notes/shared-with-me", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchNotes runs a full-text query and returns ranked results with highlighted snippets.
+func (c *Client) SearchNotes(ctx context.Context, q SearchQuery) (*SearchResponse, error) {
+	var out SearchResponse
+	if err := c.get(ctx, "/notes/search", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RelatedNotes lists notes similar in meaning to the given one, most similar first.
+func (c *Client) RelatedNotes(ctx context.Context, noteID uuid.UUID, q PageQuery) (*SearchResponse, error) {
+	var out SearchResponse
+	if err := c.get(ctx, notePath(noteID)+"/related", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func notePath(noteID uuid.UUID) string {
+	return fmt.Sprintf("/notes/%s", noteID)
+}
+
+// follow turns a page fetcher into an iterator that follows next cursors up to the last page or the first error.
+func follow[T any](start string, fetch func(cursor string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cursor := start
+		for {
+			items, next, err := fetch(cursor)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+func setInt(v url.Values, key string, n int) {
+	if n != 0 {
+		v.Set(key, strconv.Itoa(n))
+	}
+}
+
+func setString(v url.Values, key string, s string) {
+	if s != "" {
+		v.Set(key, s)
+	}
+}
+
+func setBool(v url.Values, key string, b *bool) {
+	if b != nil {
+		v.Set(key, strconv.FormatBool(*b))
+	}
+}
+
+func skipCount(v url.Values, skip bool) {
+	if skip {
+		v.Set("count", "false")
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// GetSavedSearches lists saved searches, with the number of notes each matches when counts is set.
+func (c *Client) GetSavedSearches(ctx context.Context, counts bool) (*SavedSearchesResponse, error) {
+	var query url.Values
+	if counts {
+		query = url.Values{"counts": {"true"}}
+	}
+
+	var out SavedSearchesResponse
+	if err := c.get(ctx, "/me/searches", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) CreateSavedSearch(ctx context.Context, in SavedSearchIn) (*SavedSearchOut, error) {
+	var out SavedSearchOut
+	if err := c.post(ctx, "/me/searches", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetSavedSearch(ctx context.Context, searchID uuid.UUID) (*SavedSearchOut, error) {
+	var out SavedSearchOut
+	if err := c.get(ctx, savedSearchPath(searchID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) EditSavedSearch(ctx context.Context, searchID uuid.UUID, in SavedSearchIn) (*SavedSearchOut, error) {
+	var out SavedSearchOut
+	if err := c.put(ctx, savedSearchPath(searchID), in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) DeleteSavedSearch(ctx context.Context, searchID uuid.UUID) error {
+	return c.delete(ctx, savedSearchPath(searchID), nil)
+}
+
+// RunSavedSearch fetches a page of a saved search's results.
+func (c *Client) RunSavedSearch(ctx context.Context, searchID uuid.UUID, q PageQuery) (*SearchResponse, error) {
+	var out SearchResponse
+	if err := c.get(ctx, savedSearchPath(searchID)+"/results", q.values(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func savedSearchPath(searchID uuid.UUID) string {
+	return fmt.Sprintf("/me/searches/%s", searchID)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ShareNote shares a note with a user given by ID, email or username.
+func (c *Client) ShareNote(ctx context.Context, noteID uuid.UUID, in ShareToUserRequest) error {
+	return c.post(ctx, notePath(noteID)+"/share", in, nil)
+}
+
+func (c *Client) GetNoteShares(ctx context.Context, noteID uuid.UUID) (*NoteShareResponse, error) {
+	var out NoteShareResponse
+	if err := c.get(ctx, notePath(noteID)+"/share", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) RevokeNoteShare(ctx context.Context, noteID uuid.UUID, userID uuid.UUID) error {
+	return c.delete(ctx, fmt.Sprintf("%s/shares/%s", notePath(noteID), userID), nil)
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// Sync lists what changed since token, empty for everything, uploading offline edits first when there are any.
+// The returned token goes into the next call; sync again right away while More is set.
+func (c *Client) Sync(ctx context.Context, token string, edits []SyncUpload) (*SyncResponse, error) {
+	var out SyncResponse
+
+	if len(edits) == 0 {
+		var query url.Values
+		if token != "" {
+			query = url.Values{"since": {token}}
+		}
+		if err := c.get(ctx, "/sync", query, &out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+
+	if err := c.post(ctx, "/sync", SyncRequest{Token: token, Changes: edits}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
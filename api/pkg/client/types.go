@@ -0,0 +1,106 @@
+package client
+
+import "vault/internal/models"
+
+// What the API takes and returns. The server's own types live in an internal package, which code outside
+// this module can't import, so they're named here. Being aliases, they are the same types as the server's.
+type (
+	Session               = models.Session
+	LoginOut              = models.LoginOut
+	FirebaseSignInRequest = models.FirebaseSignInRequest
+	UserOut               = models.UserOut
+	PublicUserOut         = models.PublicUserOut
+	Plan                  = models.Plan
+	UsageOut              = models.UsageOut
+	NoteUsage             = models.NoteUsage
+	TypeUsage             = models.TypeUsage
+
+	NoteIn        = models.NoteIn
+	NoteOut       = models.NoteOut
+	NotesResponse = models.NotesResponse
+	NoteType      = models.NoteType
+	NoteFields    = models.NoteFields
+
+	NoteShareOut       = models.NoteShareOut
+	NoteShareResponse  = models.NoteShareResponse
+	ShareToUserRequest = models.ShareToUserRequest
+	Permission         = models.Permission
+
+	AttachmentOut           = models.AttachmentOut
+	AttachmentRef           = models.AttachmentRef
+	AttachmentResponse      = models.AttachmentResponse
+	ThumbnailURLs           = models.ThumbnailURLs
+	UploadStatus            = models.UploadStatus
+	PresignUploadRequest    = models.PresignUploadRequest
+	PresignUploadResponse   = models.PresignUploadResponse
+	PresignDownloadResponse = models.PresignDownloadResponse
+	MultipartUploadResponse = models.MultipartUploadResponse
+	PresignPartsRequest     = models.PresignPartsRequest
+	PresignPartsResponse    = models.PresignPartsResponse
+	PartURL                 = models.PartURL
+	UploadedPart            = models.UploadedPart
+	UploadedPartsResponse   = models.UploadedPartsResponse
+
+	SearchResponse        = models.SearchResponse
+	SearchResult          = models.SearchResult
+	SearchSnippet         = models.SearchSnippet
+	SearchScope           = models.SearchScope
+	SearchSort            = models.SearchSort
+	SavedSearchIn         = models.SavedSearchIn
+	SavedSearchOut        = models.SavedSearchOut
+	SavedSearchesResponse = models.SavedSearchesResponse
+
+	SyncRequest  = models.SyncRequest
+	SyncResponse = models.SyncResponse
+	SyncUpload   = models.SyncUpload
+	SyncOp       = models.SyncOp
+	SyncChange   = models.SyncChange
+	SyncKind     = models.SyncKind
+	SyncResult   = models.SyncResult
+	SyncStatus   = models.SyncStatus
+
+	PasswordRequest      = models.PasswordRequest
+	PassphraseRequest    = models.PassphraseRequest
+	GeneratedSecretOut   = models.GeneratedSecretOut
+	PasswordHealthReport = models.PasswordHealthReport
+	PasswordHealthItem   = models.PasswordHealthItem
+)
+
+const (
+	FreePlan = models.FreePlan
+	ProPlan  = models.ProPlan
+
+	PlainNote = models.PlainNote
+	LoginNote = models.LoginNote
+
+	ReadPermission  = models.ReadPermission
+	WritePermission = models.WritePermission
+	OwnerPermission = models.OwnerPermission
+
+	PendingUpload  = models.PendingUpload
+	ScanningUpload = models.ScanningUpload
+	ReadyUpload    = models.ReadyUpload
+	RejectedUpload = models.RejectedUpload
+
+	AllScope    = models.AllScope
+	MineScope   = models.MineScope
+	SharedScope = models.SharedScope
+
+	RelevanceSort = models.RelevanceSort
+	NewestSort    = models.NewestSort
+	OldestSort    = models.OldestSort
+	UpdatedSort   = models.UpdatedSort
+	TitleSort     = models.TitleSort
+
+	UpsertOp = models.UpsertOp
+	DeleteOp = models.DeleteOp
+
+	NoteKind       = models.NoteKind
+	AttachmentKind = models.AttachmentKind
+	ShareKind      = models.ShareKind
+	TagKind        = models.TagKind
+
+	AppliedSync  = models.AppliedSync
+	ConflictSync = models.ConflictSync
+	RejectedSync = models.RejectedSync
+)
@@ -0,0 +1,37 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes back the similarity fallback of fuzzy search
+CREATE INDEX IF NOT EXISTS idx_notes_title_trgm
+    ON notes USING gin (title gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_notes_content_trgm
+    ON notes USING gin (content gin_trgm_ops);
+
+-- Suggests corrections for words a user searched for that never occur in their notes,
+-- picking the most similar word of their own vocabulary, the most frequent one on ties
+DROP FUNCTION IF EXISTS suggest_words(UUID, TEXT[]);
+CREATE OR REPLACE FUNCTION suggest_words(owner UUID, words TEXT[])
+    RETURNS TABLE
+            (
+                word       TEXT,
+                suggestion TEXT
+            )
+AS
+$$
+WITH vocabulary AS (SELECT s.word, s.nentry
+                    FROM ts_stat(format(
+                            'SELECT to_tsvector(''simple'', title || '' '' || content) FROM notes ' ||
+                            'WHERE user_id = %L AND deleted_at IS NULL AND NOT encrypted',
+                            owner
+                                 )) s)
+SELECT w.word,
+       (SELECT v.word
+        FROM vocabulary v
+        WHERE v.word % w.word
+        ORDER BY similarity(v.word, w.word) DESC, v.nentry DESC
+        LIMIT 1)
+FROM unnest(words) AS w(word)
+WHERE NOT EXISTS (SELECT 1 FROM vocabulary v WHERE v.word = w.word);
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON FUNCTION suggest_words(UUID, TEXT[]) IS 'Did-you-mean suggestions from the user''s own vocabulary';
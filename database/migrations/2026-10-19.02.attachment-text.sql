@@ -0,0 +1,86 @@
+-- Text extracted from attachments by the ingest function, one row per attachment
+CREATE TABLE IF NOT EXISTS attachment_texts
+(
+    attachment_id UUID PRIMARY KEY REFERENCES attachments (id) ON DELETE CASCADE,
+    text          TEXT NOT NULL,
+    created_at    TIMESTAMPTZ
+);
+
+-- Search vector of everything attached to a note: file names at weight C, extracted text at D
+DROP FUNCTION IF EXISTS attachments_to_search(UUID);
+CREATE OR REPLACE FUNCTION attachments_to_search(note UUID) RETURNS tsvector AS
+$$
+SELECT setweight(to_tsvector('english', COALESCE(string_agg(a.file_name, ' '), '')), 'C') ||
+       setweight(to_tsvector('english', left(COALESCE(string_agg(t.text, ' '), ''), 1048576)), 'D')
+FROM attachments a
+         LEFT JOIN attachment_texts t ON t.attachment_id = a.id
+WHERE a.note_id = note;
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS note_to_search() CASCADE;
+CREATE OR REPLACE FUNCTION note_to_search() RETURNS trigger AS
+$$
+BEGIN
+    NEW.search_vector :=
+            setweight(to_tsvector('english', COALESCE(NEW.title, '')), 'A') ||
+            setweight(to_tsvector('english', COALESCE(NEW.content, '')), 'B') ||
+            attachments_to_search(NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_note_2
+    BEFORE INSERT OR UPDATE OF title, content
+    ON notes
+    FOR EACH ROW
+EXECUTE FUNCTION note_to_search();
+
+-- Runs after the change so the attachment, or its text, is already in place or gone
+DROP FUNCTION IF EXISTS attachment_to_search() CASCADE;
+CREATE OR REPLACE FUNCTION attachment_to_search() RETURNS trigger AS
+$$
+DECLARE
+    changed UUID;
+BEGIN
+    IF TG_TABLE_NAME = 'attachment_texts' THEN
+        SELECT note_id INTO changed FROM attachments WHERE id = COALESCE(NEW.attachment_id, OLD.attachment_id);
+    ELSE
+        changed := COALESCE(NEW.note_id, OLD.note_id);
+    END IF;
+
+    UPDATE notes
+    SET search_vector =
+            setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+            setweight(to_tsvector('english', COALESCE(content, '')), 'B') ||
+            attachments_to_search(id)
+    WHERE id = changed;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_attachment_2
+    AFTER INSERT OR DELETE
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION attachment_to_search();
+
+CREATE TRIGGER on_attachment_text
+    AFTER INSERT OR UPDATE OR DELETE
+    ON attachment_texts
+    FOR EACH ROW
+EXECUTE FUNCTION attachment_to_search();
+
+COMMENT ON FUNCTION attachments_to_search(UUID) IS 'Search vector of the file names and extracted text of a note''s attachments';
+COMMENT ON FUNCTION note_to_search() IS 'Converts the note into a search vector';
+COMMENT ON FUNCTION attachment_to_search() IS 'Refreshes the search vector of the note an attachment belongs to';
+COMMENT ON TRIGGER on_note_2 ON notes IS 'Converts the note into a search vector';
+COMMENT ON TRIGGER on_attachment_2 ON attachments IS 'Refreshes the note''s search vector';
+COMMENT ON TRIGGER on_attachment_text ON attachment_texts IS 'Refreshes the note''s search vector';
+
+-- one-time rebuild, vectors used to only hold the name of the latest attachment
+UPDATE notes
+SET search_vector =
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(content, '')), 'B') ||
+        attachments_to_search(id);
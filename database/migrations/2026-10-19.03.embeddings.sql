@@ -0,0 +1,39 @@
+-- Note embeddings are computed by the API, see internal/embed
+CREATE TABLE IF NOT EXISTS note_embeddings
+(
+    note_id    UUID PRIMARY KEY REFERENCES notes (id) ON DELETE CASCADE,
+    model      TEXT   NOT NULL,
+    embedding  REAL[] NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_embeddings_model ON note_embeddings (model);
+
+-- Cosine similarity over plain arrays, for databases without pgvector
+DROP FUNCTION IF EXISTS cosine_similarity(REAL[], REAL[]);
+CREATE OR REPLACE FUNCTION cosine_similarity(a REAL[], b REAL[]) RETURNS DOUBLE PRECISION AS
+$$
+SELECT SUM(x * y) / NULLIF(sqrt(SUM(x * x)) * sqrt(SUM(y * y)), 0)
+FROM unnest(a, b) AS t(x, y);
+$$ LANGUAGE sql IMMUTABLE
+                PARALLEL SAFE;
+
+COMMENT ON FUNCTION cosine_similarity(REAL[], REAL[]) IS 'Cosine similarity of two embeddings of the same size';
+
+-- With pgvector, embeddings are mirrored into an indexed vector column the API switches to on startup.
+-- The size has to match embed.Dimensions.
+DO
+$$
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS vector;
+
+        ALTER TABLE note_embeddings
+            ADD COLUMN IF NOT EXISTS vector vector(256) GENERATED ALWAYS AS (embedding::vector(256)) STORED;
+
+        CREATE INDEX IF NOT EXISTS idx_note_embeddings_vector
+            ON note_embeddings USING hnsw (vector vector_cosine_ops);
+    EXCEPTION
+        WHEN OTHERS THEN
+            RAISE NOTICE 'pgvector is not available, similarity is computed over arrays: %', SQLERRM;
+    END
+$$;
@@ -0,0 +1,163 @@
+-- Every write to a synced table takes the next value of one sequence, so a client that saw everything
+-- up to a version only needs the rows above it. A version is taken when the row is written, not committed:
+-- a slow transaction can commit below a version already handed out. So rows also keep the transaction
+-- that wrote them, and a sync sends again whatever was written by transactions still running at the last one.
+CREATE SEQUENCE IF NOT EXISTS sync_versions;
+
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS xid     XID8   NOT NULL DEFAULT '0';
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS xid     XID8   NOT NULL DEFAULT '0';
+ALTER TABLE note_shares
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS xid     XID8   NOT NULL DEFAULT '0';
+ALTER TABLE tags
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS xid     XID8   NOT NULL DEFAULT '0';
+
+DROP FUNCTION IF EXISTS next_version() CASCADE;
+CREATE OR REPLACE FUNCTION next_version() RETURNS trigger AS
+$$
+BEGIN
+    NEW.xid := pg_current_xact_id();
+    NEW.version := nextval('sync_versions');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_note_version
+    BEFORE INSERT OR UPDATE
+    ON notes
+    FOR EACH ROW
+EXECUTE FUNCTION next_version();
+
+CREATE TRIGGER on_attachment_version
+    BEFORE INSERT OR UPDATE
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION next_version();
+
+CREATE TRIGGER on_share_version
+    BEFORE INSERT OR UPDATE
+    ON note_shares
+    FOR EACH ROW
+EXECUTE FUNCTION next_version();
+
+CREATE TRIGGER on_tag_version
+    BEFORE INSERT OR UPDATE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION next_version();
+
+-- Rows written before versions existed get one each, the triggers pick the value
+UPDATE notes
+SET version = 0
+WHERE version = 0;
+UPDATE attachments
+SET version = 0
+WHERE version = 0;
+UPDATE note_shares
+SET version = 0
+WHERE version = 0;
+UPDATE tags
+SET version = 0
+WHERE version = 0;
+
+CREATE INDEX IF NOT EXISTS idx_notes_version ON notes (user_id, version);
+CREATE INDEX IF NOT EXISTS idx_attachments_version ON attachments (version);
+CREATE INDEX IF NOT EXISTS idx_note_shares_version ON note_shares (version);
+CREATE INDEX IF NOT EXISTS idx_tags_version ON tags (user_id, version);
+CREATE INDEX IF NOT EXISTS idx_notes_xid ON notes (user_id, xid);
+CREATE INDEX IF NOT EXISTS idx_attachments_xid ON attachments (xid);
+CREATE INDEX IF NOT EXISTS idx_note_shares_xid ON note_shares (xid);
+CREATE INDEX IF NOT EXISTS idx_tags_xid ON tags (user_id, xid);
+
+-- What was deleted for good, per user who has to hear about it. Soft deleted notes keep their row
+-- and are told apart by deleted_at.
+CREATE TABLE IF NOT EXISTS sync_tombstones
+(
+    version    BIGINT PRIMARY KEY   DEFAULT nextval('sync_versions'),
+    user_id    UUID        NOT NULL,
+    kind       TEXT        NOT NULL,
+    entity_id  UUID        NOT NULL,
+    xid        XID8        NOT NULL DEFAULT pg_current_xact_id(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_user ON sync_tombstones (user_id, version);
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_xid ON sync_tombstones (user_id, xid);
+
+DROP FUNCTION IF EXISTS record_tombstone() CASCADE;
+CREATE OR REPLACE FUNCTION record_tombstone() RETURNS trigger AS
+$$
+BEGIN
+    CASE TG_TABLE_NAME
+        WHEN 'notes' THEN INSERT INTO sync_tombstones (user_id, kind, entity_id)
+                          VALUES (OLD.user_id, 'note', OLD.id);
+
+        WHEN 'tags' THEN INSERT INTO sync_tombstones (user_id, kind, entity_id)
+                         VALUES (OLD.user_id, 'tag', OLD.id);
+
+        -- the owner loses the attachment, and so does everyone the note is shared with
+        WHEN 'attachments' THEN INSERT INTO sync_tombstones (user_id, kind, entity_id)
+                                SELECT notes.user_id, 'attachment', OLD.id
+                                FROM notes
+                                WHERE notes.id = OLD.note_id
+                                UNION
+                                SELECT note_shares.shared_with_user_id, 'attachment', OLD.id
+                                FROM note_shares
+                                WHERE note_shares.note_id = OLD.note_id;
+
+        -- the recipient loses the note along with the share, the owner just the share.
+        -- When the note itself is being deleted its owner is already gone from notes and gets the note's tombstone.
+        WHEN 'note_shares' THEN INSERT INTO sync_tombstones (user_id, kind, entity_id)
+                                VALUES (OLD.shared_with_user_id, 'share', OLD.id),
+                                       (OLD.shared_with_user_id, 'note', OLD.note_id);
+                                INSERT INTO sync_tombstones (user_id, kind, entity_id)
+                                SELECT notes.user_id, 'share', OLD.id
+                                FROM notes
+                                WHERE notes.id = OLD.note_id;
+        END CASE;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_note_tombstone
+    AFTER DELETE
+    ON notes
+    FOR EACH ROW
+EXECUTE FUNCTION record_tombstone();
+
+CREATE TRIGGER on_attachment_tombstone
+    AFTER DELETE
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION record_tombstone();
+
+CREATE TRIGGER on_share_tombstone
+    AFTER DELETE
+    ON note_shares
+    FOR EACH ROW
+EXECUTE FUNCTION record_tombstone();
+
+CREATE TRIGGER on_tag_tombstone
+    AFTER DELETE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION record_tombstone();
+
+COMMENT ON SEQUENCE sync_versions IS 'Versions of synced rows and tombstones, see GET /sync';
+COMMENT ON TABLE sync_tombstones IS 'Deletions and revoked shares for clients to sync';
+COMMENT ON FUNCTION next_version() IS 'Stamps a synced row with the next version and the transaction writing it';
+COMMENT ON FUNCTION record_tombstone() IS 'Records a deletion for everyone who synced the row';
+COMMENT ON TRIGGER on_note_version ON notes IS 'Stamps the note with the next version';
+COMMENT ON TRIGGER on_attachment_version ON attachments IS 'Stamps the attachment with the next version';
+COMMENT ON TRIGGER on_share_version ON note_shares IS 'Stamps the share with the next version';
+COMMENT ON TRIGGER on_tag_version ON tags IS 'Stamps the tag with the next version';
+COMMENT ON TRIGGER on_note_tombstone ON notes IS 'Records the deletion for sync';
+COMMENT ON TRIGGER on_attachment_tombstone ON attachments IS 'Records the deletion for sync';
+COMMENT ON TRIGGER on_share_tombstone ON note_shares IS 'Records the revocation for sync';
+COMMENT ON TRIGGER on_tag_tombstone ON tags IS 'Records the deletion for sync';
@@ -0,0 +1,75 @@
+-- Attachments are stored under their ID and created pending when their upload is presigned.
+-- Older ones keep the key made of their file name.
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS object_key TEXT;
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE attachments
+SET object_key = 'attachments/' || note_id || '/' || file_name
+WHERE object_key IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_attachments_pending ON attachments (pending);
+
+-- Pending attachments are neither searched nor counted until their upload arrives
+CREATE OR REPLACE FUNCTION attachments_to_search(note UUID) RETURNS tsvector AS
+$$
+SELECT setweight(to_tsvector('english', COALESCE(string_agg(a.file_name, ' '), '')), 'C') ||
+       setweight(to_tsvector('english', left(COALESCE(string_agg(t.text, ' '), ''), 1048576)), 'D')
+FROM attachments a
+         LEFT JOIN attachment_texts t ON t.attachment_id = a.id
+WHERE a.note_id = note
+  AND NOT a.pending;
+$$ LANGUAGE sql STABLE;
+
+DROP TRIGGER IF EXISTS on_attachment_2 ON attachments;
+CREATE TRIGGER on_attachment_2
+    AFTER INSERT OR DELETE OR UPDATE OF pending
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION attachment_to_search();
+
+DROP FUNCTION IF EXISTS count_attachments() CASCADE;
+CREATE OR REPLACE FUNCTION count_attachments() RETURNS TRIGGER
+AS
+$$
+DECLARE
+    change INT := 0;
+    note   UUID;
+BEGIN
+    IF TG_OP = 'INSERT' AND NOT NEW.pending THEN
+        change := 1;
+        note := NEW.note_id;
+    ELSIF TG_OP = 'UPDATE' AND OLD.pending AND NOT NEW.pending THEN
+        -- upload arrived
+        change := 1;
+        note := NEW.note_id;
+    ELSIF TG_OP = 'DELETE' AND NOT OLD.pending THEN
+        change := -1;
+        note := OLD.note_id;
+    END IF;
+
+    IF change <> 0 THEN
+        UPDATE users u
+        SET attachments_count = greatest(attachments_count + change, 0)
+        FROM notes n
+        WHERE n.id = note
+          AND u.id = n.user_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_attachment
+    AFTER INSERT OR DELETE OR UPDATE OF pending
+    ON attachments
+    FOR EACH ROW
+EXECUTE PROCEDURE count_attachments();
+
+COMMENT ON FUNCTION attachments_to_search(UUID) IS 'Search vector of the file names and extracted text of a note''s uploaded attachments';
+COMMENT ON FUNCTION count_attachments()
+    IS 'Trigger function to update the attachment count for a user when an attachment is uploaded or deleted.';
+COMMENT ON TRIGGER on_attachment ON attachments
+    IS 'Trigger to update the attachment count for a user when an attachment is uploaded or deleted.';
+COMMENT ON TRIGGER on_attachment_2 ON attachments IS 'Refreshes the note''s search vector';
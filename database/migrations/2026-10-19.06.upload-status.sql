@@ -0,0 +1,85 @@
+-- Attachments go from pending, when their upload is presigned, through scanning to ready or rejected
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ready'
+        CHECK (status IN ('pending', 'scanning', 'ready', 'rejected'));
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+UPDATE attachments
+SET status     = CASE WHEN pending THEN 'pending' ELSE 'ready' END,
+    updated_at = COALESCE(updated_at, created_at);
+
+-- the triggers watch the column being replaced
+DROP TRIGGER IF EXISTS on_attachment ON attachments;
+DROP TRIGGER IF EXISTS on_attachment_2 ON attachments;
+DROP INDEX IF EXISTS idx_attachments_pending;
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS pending;
+
+CREATE INDEX IF NOT EXISTS idx_attachments_status ON attachments (status, updated_at);
+
+-- Only ready attachments are searched and counted
+CREATE OR REPLACE FUNCTION attachments_to_search(note UUID) RETURNS tsvector AS
+$$
+SELECT setweight(to_tsvector('english', COALESCE(string_agg(a.file_name, ' '), '')), 'C') ||
+       setweight(to_tsvector('english', left(COALESCE(string_agg(t.text, ' '), ''), 1048576)), 'D')
+FROM attachments a
+         LEFT JOIN attachment_texts t ON t.attachment_id = a.id
+WHERE a.note_id = note
+  AND a.status = 'ready';
+$$ LANGUAGE sql STABLE;
+
+CREATE TRIGGER on_attachment_2
+    AFTER INSERT OR DELETE OR UPDATE OF status
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION attachment_to_search();
+
+CREATE OR REPLACE FUNCTION count_attachments() RETURNS TRIGGER
+AS
+$$
+DECLARE
+    change INT := 0;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        -- leaving ready, or deleted while ready
+        IF OLD.status = 'ready' THEN
+            change := change - 1;
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        -- becoming ready
+        IF NEW.status = 'ready' THEN
+            change := change + 1;
+        END IF;
+    END IF;
+
+    IF change <> 0 THEN
+        UPDATE users u
+        SET attachments_count = greatest(attachments_count + change, 0)
+        FROM notes n
+        WHERE n.id = COALESCE(NEW.note_id, OLD.note_id)
+          AND u.id = n.user_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER on_attachment
+    AFTER INSERT OR DELETE OR UPDATE OF status
+    ON attachments
+    FOR EACH ROW
+EXECUTE PROCEDURE count_attachments();
+
+COMMENT ON COLUMN attachments.status IS 'pending until the upload arrives, scanning while it''s checked, then ready or rejected';
+COMMENT ON COLUMN attachments.reason IS 'Why the upload was rejected';
+COMMENT ON FUNCTION attachments_to_search(UUID) IS 'Search vector of the file names and extracted text of a note''s ready attachments';
+COMMENT ON FUNCTION count_attachments()
+    IS 'Trigger function to update the attachment count for a user when an attachment becomes ready or is deleted.';
+COMMENT ON TRIGGER on_attachment ON attachments
+    IS 'Trigger to update the attachment count for a user when an attachment becomes ready or is deleted.';
+COMMENT ON TRIGGER on_attachment_2 ON attachments IS 'Refreshes the note''s search vector';
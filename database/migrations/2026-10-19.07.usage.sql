@@ -0,0 +1,68 @@
+-- Bytes of ready attachments per user, counted next to the number of attachments, and the plan their quota comes from
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS storage_used BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT 'free';
+
+CREATE OR REPLACE FUNCTION count_attachments() RETURNS TRIGGER
+AS
+$$
+DECLARE
+    change INT    := 0;
+    bytes  BIGINT := 0;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        -- leaving ready, or deleted while ready
+        IF OLD.status = 'ready' THEN
+            change := change - 1;
+            bytes := bytes - OLD.size;
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        -- becoming ready, or replaced by another upload while ready
+        IF NEW.status = 'ready' THEN
+            change := change + 1;
+            bytes := bytes + NEW.size;
+        END IF;
+    END IF;
+
+    IF change <> 0 OR bytes <> 0 THEN
+        UPDATE users u
+        SET attachments_count = greatest(attachments_count + change, 0),
+            storage_used      = greatest(storage_used + bytes, 0)
+        FROM notes n
+        WHERE n.id = COALESCE(NEW.note_id, OLD.note_id)
+          AND u.id = n.user_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS on_attachment ON attachments;
+CREATE TRIGGER on_attachment
+    AFTER INSERT OR DELETE OR UPDATE OF status, size
+    ON attachments
+    FOR EACH ROW
+EXECUTE PROCEDURE count_attachments();
+
+COMMENT ON COLUMN users.storage_used IS 'Bytes of the user''s ready attachments, kept by count_attachments()';
+COMMENT ON COLUMN users.plan IS 'Plan the user''s storage quota comes from, see QUOTA_* in the API config';
+COMMENT ON FUNCTION count_attachments()
+    IS 'Trigger function to update the attachment count and storage used by a user when an attachment becomes ready, changes size or is deleted.';
+COMMENT ON TRIGGER on_attachment ON attachments
+    IS 'Trigger to update the attachment count and storage used by a user when an attachment becomes ready, changes size or is deleted.';
+
+-- one-time count of storage used
+WITH usage AS (
+    SELECT n.user_id, sum(a.size) AS bytes
+    FROM notes n
+             JOIN attachments a ON a.note_id = n.id
+    WHERE a.status = 'ready'
+    GROUP BY n.user_id
+)
+UPDATE users u
+SET storage_used = usage.bytes
+FROM usage
+WHERE u.id = usage.user_id;
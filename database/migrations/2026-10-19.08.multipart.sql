@@ -0,0 +1,7 @@
+-- Large attachments are uploaded in parts, their pending rows hold the ID of the multipart upload until it's completed
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS upload_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_attachments_upload_id ON attachments (upload_id) WHERE upload_id <> '';
+
+COMMENT ON COLUMN attachments.upload_id IS 'ID of the storage multipart upload of a pending attachment, empty once completed or for uploads in one go';
@@ -0,0 +1,5 @@
+-- Ingest makes thumbnails of image attachments and of PDFs with an embedded page image, stored next to their files
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS thumbnails BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN attachments.thumbnails IS 'Whether thumbnails of the attachment are stored under thumbnails/{note_id}/{id}/';
@@ -0,0 +1,53 @@
+-- Attachment files are stored once per user and content, in blobs that count the attachments stored in them
+CREATE TABLE IF NOT EXISTS blobs
+(
+    key        TEXT PRIMARY KEY,
+    user_id    UUID        NOT NULL,
+    sha256     TEXT        NOT NULL,
+    size       BIGINT      NOT NULL,
+    refs       INT         NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_blobs_unused ON blobs (refs, updated_at);
+
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS sha256 TEXT NOT NULL DEFAULT '';
+
+-- blobs are only deleted once no attachment is stored in them
+CREATE INDEX IF NOT EXISTS idx_attachments_object_key ON attachments (object_key);
+
+CREATE OR REPLACE FUNCTION count_blob_refs() RETURNS TRIGGER
+AS
+$$
+BEGIN
+    -- files that aren't blobs match no row
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE blobs SET refs = greatest(refs - 1, 0) WHERE key = OLD.object_key;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        UPDATE blobs SET refs = refs + 1 WHERE key = NEW.object_key;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS on_attachment_blob ON attachments;
+CREATE TRIGGER on_attachment_blob
+    AFTER INSERT OR DELETE OR UPDATE OF object_key
+    ON attachments
+    FOR EACH ROW
+EXECUTE PROCEDURE count_blob_refs();
+
+COMMENT ON TABLE blobs IS 'Attachment files stored once for all of a user''s attachments with the same content';
+COMMENT ON COLUMN blobs.key IS 'Where the file is kept in storage, blobs/{user_id}/{sha256}';
+COMMENT ON COLUMN blobs.refs IS 'Attachments stored in the blob, kept by count_blob_refs(); ingest deletes unused blobs';
+COMMENT ON COLUMN blobs.updated_at IS 'When an attachment last took the blob, unused blobs are kept a while after';
+COMMENT ON COLUMN attachments.sha256 IS 'SHA-256 of the attachment''s content, in hex, empty until ingest stores it in a blob';
+COMMENT ON FUNCTION count_blob_refs()
+    IS 'Trigger function to count the attachments stored in a blob when one is stored in it, moves out or is deleted.';
+COMMENT ON TRIGGER on_attachment_blob ON attachments
+    IS 'Trigger to count the attachments stored in a blob when one is stored in it, moves out or is deleted.';
@@ -24,6 +24,7 @@ export type { PublicUserOut } from './models/PublicUserOut';
 export type { Session } from './models/Session';
 export type { Share } from './models/Share';
 export type { ShareToUserRequest } from './models/ShareToUserRequest';
+export type { ThumbnailURLs } from './models/ThumbnailURLs';
 export type { UserOut } from './models/UserOut';
 
 export { AuthService } from './services/AuthService';
@@ -2,10 +2,19 @@
 /* istanbul ignore file */
 /* tslint:disable */
 /* eslint-disable */
+import type { ThumbnailURLs } from './ThumbnailURLs';
 export type AttachmentOut = {
     filename?: string;
     id: string;
     mime_type?: string;
+    /**
+     * of the content, to check downloads against
+     */
+    sha256?: string;
     size?: number;
+    /**
+     * of images and some PDFs
+     */
+    thumbnail_url?: ThumbnailURLs;
 };
 
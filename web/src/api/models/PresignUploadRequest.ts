@@ -5,5 +5,9 @@
 export type PresignUploadRequest = {
     content_type: string;
     filename: string;
+    /**
+     * in bytes, the upload has to be exactly this size
+     */
+    size: number;
 };
 
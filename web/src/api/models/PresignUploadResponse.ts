@@ -3,6 +3,11 @@
 /* tslint:disable */
 /* eslint-disable */
 export type PresignUploadResponse = {
+    fields: Record<string, string>;
+    /**
+     * of the attachment being uploaded
+     */
+    id?: string;
     key: string;
     url: string;
 };
@@ -1,7 +1,7 @@
 import * as React from 'react';
 import { useCallback, useEffect, useState } from 'react';
 import { useNavigate, useParams } from 'react-router-dom';
-import { type AttachmentRef, NotesService } from '../api';
+import { type AttachmentOut, type AttachmentRef, NotesService } from '../api';
 import { Seo } from '../components/Seo';
 import { Download, File as FileIcon, FileImage, FileText } from 'lucide-react';
 import { formatBytes } from '../utils/numbers';
@@ -16,6 +16,12 @@ function getFileIcon(mimeType: string = ''): React.ReactElement {
   return <FileIcon size={ 24 } className="text-[var(--muted-foreground)]"/>;
 }
 
+// thumbnail of images and some PDFs, the icon of their type otherwise
+function getPreview(attachment: AttachmentOut, size: 'small' | 'medium', className: string): React.ReactElement {
+  if (!attachment.thumbnail_url) return getFileIcon(attachment.mime_type);
+  return <img src={ attachment.thumbnail_url[size] } alt="" loading="lazy" className={ className }/>;
+}
+
 function AttachmentList({ attachments, selectedId, onSelect }: {
   attachments: AttachmentRef[],
   selectedId?: string,
@@ -35,7 +41,7 @@ function AttachmentList({ attachments, selectedId, onSelect }: {
                 )
               }
             >
-              <div className="flex-shrink-0">{ getFileIcon(ref.attachment.mime_type) }</div>
+              <div className="flex-shrink-0">{ getPreview(ref.attachment, 'small', 'w-10 h-10 rounded object-cover') }</div>
               <div className="flex-1 min-w-0">
                 <p className="font-medium truncate">{ ref.attachment.filename }</p>
                 <p className="text-sm text-[var(--muted-foreground)]">{ formatBytes(ref.attachment.size || 0) }</p>
@@ -60,9 +66,13 @@ function AttachmentDetailView({ attachmentRef }: { attachmentRef?: AttachmentRef
 
   return (
     <div className="p-6">
-      <div className="flex items-center justify-center w-32 h-32 rounded-lg bg-[var(--subtle-bg)] mx-auto">
-        { getFileIcon(attachment.mime_type) }
-      </div>
+      { attachment.thumbnail_url ? (
+        getPreview(attachment, 'medium', 'max-w-full max-h-80 rounded-lg object-contain mx-auto')
+      ) : (
+        <div className="flex items-center justify-center w-32 h-32 rounded-lg bg-[var(--subtle-bg)] mx-auto">
+          { getFileIcon(attachment.mime_type) }
+        </div>
+      ) }
       <h2 className="text-xl font-bold text-center mt-4 break-all">{ attachment.filename }</h2>
 
       <div className="mt-8 space-y-4">
@@ -6,13 +6,12 @@ import { RichTextEditor } from "../components/editor/RichTextEditor.tsx";
 import { useDebounce } from "use-debounce";
 import { type SaveStatus } from "../components/editor/SaveStatusIndicator.tsx";
 import { useDropzone } from "react-dropzone";
-import axios from "axios";
 import { AttachmentItem } from "../components/AttachmentItem.tsx";
 import { fileTypeFromBlob } from "file-type";
 import { formatBytes } from "../utils/numbers.ts";
 import toast from "react-hot-toast";
 import { AttachmentsEmptyState } from "../components/AttachmentsEmptyState.tsx";
-import downloadAttachment from "../utils/network.ts";
+import downloadAttachment, { uploadForm } from "../utils/network.ts";
 import ShareButton from "../components/ShareButton.tsx";
 import { isNoteBy } from "../api/utils.ts";
 import { useAuth } from "../features/AuthContext.tsx";
@@ -176,22 +175,20 @@ export default function NoteDetail() {
       const body = {
         content_type: type,
         filename: upload.file.name,
+        size: upload.file.size,
       };
 
-      const { url } = await NotesService.getUploadUrl({ noteId: noteId!, requestBody: body });
+      const presigned = await NotesService.getUploadUrl({ noteId: noteId!, requestBody: body });
 
-      if (url) {
-        await axios.put(
-          url,
+      if (presigned.url) {
+        await uploadForm(
+          presigned,
           upload.file,
-          {
-            headers: { 'Content-Type': type },
-            onUploadProgress: (event) => {
-              const percentCompleted = Math.round((event.loaded * 100) / event.total!);
-              setUploadingFiles(prev =>
-                prev.map(f => f.id === upload.id ? { ...f, progress: percentCompleted } : f)
-              );
-            }
+          (event) => {
+            const percentCompleted = Math.round((event.loaded * 100) / event.total!);
+            setUploadingFiles(prev =>
+              prev.map(f => f.id === upload.id ? { ...f, progress: percentCompleted } : f)
+            );
           }
         );
 
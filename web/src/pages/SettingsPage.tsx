@@ -4,7 +4,7 @@ import toast from 'react-hot-toast';
 import { Camera, FileText, FileUp, Pencil, Trash2 } from 'lucide-react';
 import { AuthService, type UserOut } from "../api";
 import { useAuth } from "../features/AuthContext.tsx";
-import axios from "axios";
+import { uploadForm } from "../utils/network.ts";
 import { formatBytes } from "../utils/numbers.ts";
 
 const MAX_AVATAR_SIZE_BYTES = 5 * 1024 * 1024; // 5 MB
@@ -33,29 +33,26 @@ export default function ProfilePage() {
     const toastId = toast.loading('Preparing upload...');
 
     try {
-      const { url } = await AuthService.presignAvatar({
+      const presigned = await AuthService.presignAvatar({
         requestBody: {
           filename: file.name,
           content_type: file.type || 'application/octet-stream',
+          size: file.size,
         },
       });
 
       toast.loading('Uploading avatar...', { id: toastId });
 
-      await axios.put(url, file, {
-        headers: { 'Content-Type': file.type || 'application/octet-stream' },
-      });
+      await uploadForm(presigned, file);
 
       toast.success('Avatar updated!', { id: toastId });
 
       setTimeout(async () => {
         const updatedUser = await AuthService.me();
-        localStorage.setItem("user", JSON.stringify(updatedUser));
-        const baseUrl = updatedUser.avatar_url?.split('?')[0];
-        const cacheBustedUrl = `${ baseUrl }?v=${ new Date().getTime() }`;
+        // every new avatar has a URL of its own, so no cached one shows
         const finalUser: UserOut = {
           ...user,
-          avatar_url: cacheBustedUrl,
+          avatar_url: updatedUser.avatar_url,
         };
 
         setUser(finalUser);
@@ -75,7 +72,7 @@ export default function ProfilePage() {
 
   const { getRootProps, getInputProps, isDragActive } = useDropzone({
     onDrop: onFileSelect,
-    accept: { 'image/*': ['.jpeg', '.png', '.gif', '.webp'] },
+    accept: { 'image/*': ['.jpeg', '.png', '.gif'] },
     multiple: false,
     noClick: false,
     noKeyboard: true,
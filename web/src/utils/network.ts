@@ -1,5 +1,19 @@
-import { NotesService } from "../api";
+import { NotesService, type PresignUploadResponse } from "../api";
 import toast from "react-hot-toast";
+import axios, { type AxiosProgressEvent } from "axios";
+
+// Posts a file to a presigned upload form: its fields first, the file last, as storage expects.
+export async function uploadForm(
+  presigned: PresignUploadResponse,
+  file: File,
+  onUploadProgress?: (event: AxiosProgressEvent) => void,
+): Promise<void> {
+  const form = new FormData();
+  Object.entries(presigned.fields).forEach(([name, value]) => form.append(name, value));
+  form.append('file', file);
+
+  await axios.post(presigned.url, form, { onUploadProgress });
+}
 
 export default async function downloadAttachment(noteId: string, attachmentId: string): Promise<void> {
   try {